@@ -0,0 +1,260 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// analyzerRequest is the body of the analyzer create and update endpoints
+type analyzerRequest struct {
+	Name             string   `json:"name"`
+	Weight           *float64 `json:"weight"`
+	ProcessingTimeMs int      `json:"processing_time_ms"`
+	MaxInFlight      int      `json:"max_in_flight"`
+}
+
+// AddAnalyzer registers a new analyzer at runtime
+func (h *Handler) AddAnalyzer(c *gin.Context) {
+	cfg, ok := bindAnalyzerConfig(c)
+	if !ok {
+		return
+	}
+
+	if err := h.distributor.AddAnalyzer(cfg); err != nil {
+		h.respondAnalyzerError(c, cfg.ID, err)
+		return
+	}
+
+	h.logger.Info("Analyzer added via API",
+		zap.String("analyzer_id", cfg.ID),
+		zap.Float64("weight", cfg.Weight),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Analyzer registered",
+		"analyzer":  analyzerResponse(cfg),
+		"timestamp": time.Now(),
+	})
+}
+
+// UpdateAnalyzer replaces the name, weight, processing time and in-flight limit of a registered analyzer
+func (h *Handler) UpdateAnalyzer(c *gin.Context) {
+	cfg, ok := bindAnalyzerConfig(c)
+	if !ok {
+		return
+	}
+
+	if err := h.distributor.UpdateAnalyzer(cfg); err != nil {
+		h.respondAnalyzerError(c, cfg.ID, err)
+		return
+	}
+
+	h.logger.Info("Analyzer updated via API",
+		zap.String("analyzer_id", cfg.ID),
+		zap.Float64("weight", cfg.Weight),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Analyzer updated",
+		"analyzer":  analyzerResponse(cfg),
+		"timestamp": time.Now(),
+	})
+}
+
+// RemoveAnalyzer takes an analyzer out of rotation; its in-flight packets finish in the background
+func (h *Handler) RemoveAnalyzer(c *gin.Context) {
+	analyzerID := c.Param("id")
+
+	if err := h.distributor.RemoveAnalyzer(analyzerID); err != nil {
+		h.respondAnalyzerError(c, analyzerID, err)
+		return
+	}
+
+	h.logger.Info("Analyzer removed via API",
+		zap.String("analyzer_id", analyzerID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":     "Analyzer removed - packets already sent to it are finishing",
+		"analyzer_id": analyzerID,
+		"timestamp":   time.Now(),
+	})
+}
+
+// overrideAuto clears an analyzer's override, returning it to health check control
+const overrideAuto = "auto"
+
+// analyzerHealthRequest is the body of the analyzer health override endpoint
+type analyzerHealthRequest struct {
+	Mode      string     `json:"mode"`       // an override mode, or "auto" to clear the override
+	Healthy   *bool      `json:"healthy"`    // shorthand for forced-healthy / forced-unhealthy
+	Reason    string     `json:"reason"`     // free text shown alongside the override
+	Duration  string     `json:"duration"`   // e.g. "30m"; alternative to expires_at
+	ExpiresAt *time.Time `json:"expires_at"` // RFC 3339
+}
+
+// SetAnalyzerHealth sets an operator override that takes precedence over health checks
+func (h *Handler) SetAnalyzerHealth(c *gin.Context) {
+	analyzerID := c.Param("id")
+
+	var req analyzerHealthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	override, err := overrideFromRequest(req, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.distributor.SetAnalyzerOverride(analyzerID, override); err != nil {
+		h.respondAnalyzerError(c, analyzerID, err)
+		return
+	}
+
+	mode := overrideAuto
+	if override != nil {
+		mode = string(override.Mode)
+	}
+	h.logger.Info("Analyzer health override set via API",
+		zap.String("analyzer_id", analyzerID),
+		zap.String("mode", mode),
+		zap.String("reason", req.Reason),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Analyzer health override updated",
+		"analyzer_id": analyzerID,
+		"override":    override,
+		"timestamp":   time.Now(),
+	})
+}
+
+// overrideFromRequest builds the requested override; a nil override with no error clears it
+func overrideFromRequest(req analyzerHealthRequest, now time.Time) (*models.HealthOverride, error) {
+	mode := req.Mode
+	if mode == "" && req.Healthy != nil {
+		mode = string(models.OverrideForcedUnhealthy)
+		if *req.Healthy {
+			mode = string(models.OverrideForcedHealthy)
+		}
+	}
+
+	switch mode {
+	case "":
+		return nil, fmt.Errorf("mode is required: one of %s, %s, %s, %s or %s", models.OverrideForcedHealthy,
+			models.OverrideForcedUnhealthy, models.OverrideDraining, models.OverrideMaintenance, overrideAuto)
+	case overrideAuto:
+		return nil, nil
+	}
+
+	override := &models.HealthOverride{
+		Mode:      models.OverrideMode(mode),
+		Reason:    req.Reason,
+		SetAt:     now,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if req.Duration != "" {
+		if req.ExpiresAt != nil {
+			return nil, fmt.Errorf("set either duration or expires_at, not both")
+		}
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid duration %q - expected a positive duration such as 30m", req.Duration)
+		}
+		expiresAt := now.Add(duration)
+		override.ExpiresAt = &expiresAt
+	}
+
+	return override, nil
+}
+
+// bindAnalyzerConfig builds an analyzer config from the path ID and request body.
+// The name defaults to the ID and the in-flight limit to DefaultAnalyzerMaxInFlight; the weight
+// is required since zero is a valid weight.
+func bindAnalyzerConfig(c *gin.Context) (config.AnalyzerConfig, bool) {
+	var req analyzerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return config.AnalyzerConfig{}, false
+	}
+	if req.Weight == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "weight is required",
+		})
+		return config.AnalyzerConfig{}, false
+	}
+
+	cfg := config.AnalyzerConfig{
+		ID:               c.Param("id"),
+		Name:             req.Name,
+		Weight:           *req.Weight,
+		ProcessingTimeMs: req.ProcessingTimeMs,
+		MaxInFlight:      req.MaxInFlight,
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.ID
+	}
+	if cfg.MaxInFlight == 0 {
+		cfg.MaxInFlight = config.DefaultAnalyzerMaxInFlight
+	}
+	return cfg, true
+}
+
+// respondAnalyzerError maps analyzer registry errors to HTTP statuses
+func (h *Handler) respondAnalyzerError(c *gin.Context, analyzerID string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, interfaces.ErrInvalidAnalyzer), errors.Is(err, interfaces.ErrInvalidOverride):
+		status = http.StatusBadRequest
+	case errors.Is(err, interfaces.ErrAnalyzerExists):
+		status = http.StatusConflict
+	case errors.Is(err, interfaces.ErrAnalyzerNotFound):
+		status = http.StatusNotFound
+	}
+
+	if status == http.StatusInternalServerError {
+		h.logger.Error("Analyzer registry change failed",
+			zap.String("analyzer_id", analyzerID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	}
+
+	c.JSON(status, gin.H{
+		"error":       err.Error(),
+		"analyzer_id": analyzerID,
+	})
+}
+
+// analyzerResponse renders an analyzer config for API responses
+func analyzerResponse(cfg config.AnalyzerConfig) gin.H {
+	return gin.H{
+		"id":                 cfg.ID,
+		"name":               cfg.Name,
+		"weight":             cfg.Weight,
+		"processing_time_ms": cfg.ProcessingTimeMs,
+		"max_in_flight":      cfg.MaxInFlight,
+	}
+}
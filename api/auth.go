@@ -0,0 +1,82 @@
+package api
+
+import (
+	"logs-distributor/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// apiKeyHeader carries the API key; "Authorization: Bearer <key>" is accepted as well
+const apiKeyHeader = "X-API-Key"
+
+// requireScope authenticates the request's API key and checks it grants the scope.
+// Authentication is disabled when no key store is configured.
+func (h *Handler) requireScope(scope auth.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.keyStore == nil {
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader(apiKeyHeader)
+		if key == "" {
+			key = auth.KeyFromBearer(c.GetHeader("Authorization"))
+		}
+
+		principal, ok := h.keyStore.Authenticate(key)
+		if !ok {
+			h.logger.Error("Rejected request with missing or invalid API key",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			c.Header("WWW-Authenticate", `Bearer realm="logs-distributor"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Missing or invalid API key",
+			})
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			h.logger.Error("Rejected request without required scope",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("key", principal.KeyName),
+				zap.String("scope", string(scope)),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "API key does not grant the " + string(scope) + " scope",
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// tenantID returns the tenant of the authenticated request, or "" when authentication is disabled
+func tenantID(c *gin.Context) string {
+	return auth.TenantFromContext(c.Request.Context())
+}
+
+// originAllowed reports whether the CORS policy allows the origin
+func (h *Handler) originAllowed(origin string) bool {
+	for _, allowed := range h.allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWebSocketOrigin applies the CORS policy to WebSocket upgrades.
+// Non-browser clients send no Origin and same-origin pages are always allowed.
+func (h *Handler) checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "http://"+r.Host || origin == "https://"+r.Host {
+		return true
+	}
+	return h.originAllowed(origin)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"logs-distributor/config"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap"
+)
+
+// errUnsupportedEncoding is returned for Content-Encoding values the middleware cannot decode
+var errUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// errDecodedBodyTooLarge is returned when a body declares a decoded size above MaxDecompressedBodyBytes
+var errDecodedBodyTooLarge = errors.New("decompressed body too large")
+
+// snappyStreamMagic prefixes snappy framed streams; anything else is treated as the block format
+var snappyStreamMagic = []byte("\xff\x06\x00\x00sNaPpY")
+
+// decompressionMiddleware transparently decodes gzip, zstd and snappy request bodies.
+// The decoded body is capped at MaxDecompressedBodyBytes so small payloads cannot expand without bound.
+func (h *Handler) decompressionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+		if encoding == "" || encoding == "identity" {
+			c.Next()
+			return
+		}
+
+		body, err := newDecompressingReader(encoding, c.Request.Body)
+		if err != nil {
+			status := http.StatusBadRequest
+			switch {
+			case errors.Is(err, errUnsupportedEncoding):
+				status = http.StatusUnsupportedMediaType
+			case errors.Is(err, errDecodedBodyTooLarge):
+				status = http.StatusRequestEntityTooLarge
+			}
+			h.logger.Error("Failed to decode compressed request body",
+				zap.String("content_encoding", encoding),
+				zap.Error(err),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			c.AbortWithStatusJSON(status, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, body, config.MaxDecompressedBodyBytes)
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Request.ContentLength = -1
+
+		c.Next()
+	}
+}
+
+// newDecompressingReader wraps body with a decoder for the given Content-Encoding
+func newDecompressingReader(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		return &decompressingReader{Reader: reader, closers: []func() error{reader.Close, body.Close}}, nil
+
+	case "zstd":
+		decoder, err := zstd.NewReader(body,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxMemory(config.MaxDecompressedBodyBytes),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd body: %w", err)
+		}
+		return &decompressingReader{Reader: decoder, closers: []func() error{
+			func() error { decoder.Close(); return nil },
+			body.Close,
+		}}, nil
+
+	case "snappy", "x-snappy-framed":
+		return newSnappyReader(body)
+
+	default:
+		return nil, fmt.Errorf("%w %q", errUnsupportedEncoding, encoding)
+	}
+}
+
+// newSnappyReader handles both the framed stream format and the single-block format
+func newSnappyReader(body io.ReadCloser) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(body)
+	if header, _ := buffered.Peek(len(snappyStreamMagic)); bytes.Equal(header, snappyStreamMagic) {
+		return &decompressingReader{Reader: snappy.NewReader(buffered), closers: []func() error{body.Close}}, nil
+	}
+
+	// The block format has no streaming decoder, so read the block (bounded by the largest
+	// valid encoding) and check the declared length before allocating the output
+	compressed, err := io.ReadAll(io.LimitReader(buffered, int64(snappy.MaxEncodedLen(config.MaxDecompressedBodyBytes))+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy body: %w", err)
+	}
+	decodedLen, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy body: %w", err)
+	}
+	if decodedLen > config.MaxDecompressedBodyBytes {
+		return nil, fmt.Errorf("%w: snappy body decodes to %d bytes, maximum is %d", errDecodedBodyTooLarge, decodedLen, config.MaxDecompressedBodyBytes)
+	}
+	decoded, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy body: %w", err)
+	}
+
+	return &decompressingReader{Reader: bytes.NewReader(decoded), closers: []func() error{body.Close}}, nil
+}
+
+// decompressingReader closes the decoder and the underlying request body together
+type decompressingReader struct {
+	io.Reader
+	closers []func() error
+}
+
+func (r *decompressingReader) Close() error {
+	var firstErr error
+	for _, closeFn := range r.closers {
+		if err := closeFn(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// isBodyTooLarge reports whether a body read failed because the decompressed size cap was hit
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
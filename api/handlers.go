@@ -1,30 +1,69 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"logs-distributor/auth"
 	"logs-distributor/config"
 	"logs-distributor/distributor/interfaces"
+	"logs-distributor/metrics"
 	"logs-distributor/models"
+	"logs-distributor/ratelimit"
+	"logs-distributor/tracing"
 	"net/http"
-	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 )
 
+// idempotencyKeyHeader lets clients retry a submission without it being analyzed twice
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// HandlerConfig holds the access control settings for the HTTP API
+type HandlerConfig struct {
+	KeyStore       *auth.KeyStore     // nil disables API key authentication
+	AllowedOrigins []string           // CORS origins; "*" allows any origin
+	RateLimiter    *ratelimit.Limiter // nil disables rate limiting
+	Metrics        *metrics.Metrics   // nil disables /metrics and HTTP request metrics
+	TrustedProxies []string           // IPs or CIDRs whose X-Forwarded-For sets the client IP; none by default
+}
+
 type Handler struct {
-	distributor interfaces.Distributor
-	logger      *zap.Logger
+	distributor    interfaces.Distributor
+	logger         *zap.Logger
+	keyStore       *auth.KeyStore
+	allowedOrigins []string
+	rateLimiter    *ratelimit.Limiter
+	metrics        *metrics.Metrics
+	trustedProxies []string
+	upgrader       websocket.Upgrader
+
+	// Closed on server shutdown to end long-lived result streams
+	streamsDone      chan struct{}
+	closeStreamsOnce sync.Once
 }
 
-func NewHandler(d interfaces.Distributor, logger *zap.Logger) *Handler {
-	return &Handler{
-		distributor: d,
-		logger:      logger,
+func NewHandler(d interfaces.Distributor, logger *zap.Logger, cfg *HandlerConfig) *Handler {
+	h := &Handler{
+		distributor:    d,
+		logger:         logger,
+		keyStore:       cfg.KeyStore,
+		allowedOrigins: cfg.AllowedOrigins,
+		rateLimiter:    cfg.RateLimiter,
+		metrics:        cfg.Metrics,
+		trustedProxies: cfg.TrustedProxies,
+		streamsDone:    make(chan struct{}),
 	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin:     h.checkWebSocketOrigin,
+	}
+	return h
 }
 
 // SetupRoutes configures all API routes
@@ -32,19 +71,52 @@ func (h *Handler) SetupRoutes() *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
+	// Client IPs key rate limits, so forwarding headers are only believed from configured proxies
+	if err := r.SetTrustedProxies(h.trustedProxies); err != nil {
+		h.logger.Error("Invalid trusted proxies - client IPs are taken from connections", zap.Error(err))
+		r.SetTrustedProxies(nil)
+	}
 	r.Use(gin.Recovery())
 	r.Use(h.loggingMiddleware())
 	r.Use(h.corsMiddleware())
 
+	// Bodies are only decompressed after the API key is checked
+	ingest := []gin.HandlerFunc{h.tracingMiddleware(), h.requireScope(auth.ScopeIngest), h.decompressionMiddleware()}
+	read := []gin.HandlerFunc{h.requireScope(auth.ScopeRead)}
+	admin := []gin.HandlerFunc{h.requireScope(auth.ScopeAdmin), h.decompressionMiddleware()}
+
+	// Prometheus scrapes the conventional path
+	if h.metrics != nil {
+		r.GET("/metrics", append(read, gin.WrapH(h.metrics.Handler()))...)
+	}
+
+	// OTLP/HTTP receiver uses the standard OpenTelemetry path
+	r.POST("/v1/logs", append(ingest, h.ExportOTLPLogs)...)
+
 	// API routes
 	api := r.Group("/api/v1")
 	{
-		api.POST("/logs", h.SubmitLogs)
+		// Left open so load balancers and container health checks can probe it
 		api.GET("/health", h.HealthCheck)
-		api.GET("/stats", h.GetStats)
-		api.GET("/analyzers", h.GetAnalyzers)
-		api.GET("/dead-letter", h.GetDeadLetterPackets)
-		api.POST("/analyzers/:id/health", h.SetAnalyzerHealth)
+
+		ingestRoutes := api.Group("", ingest...)
+		ingestRoutes.POST("/logs", h.SubmitLogs)
+		ingestRoutes.POST("/logs/stream", h.SubmitLogStream)
+
+		readRoutes := api.Group("", read...)
+		readRoutes.GET("/stats", h.GetStats)
+		readRoutes.GET("/analyzers", h.GetAnalyzers)
+		readRoutes.GET("/packets/:id", h.GetPacketStatus)
+		readRoutes.GET("/results/stream", h.StreamResults)
+		readRoutes.GET("/results/ws", h.StreamResultsWebSocket)
+		readRoutes.GET("/dead-letter", h.GetDeadLetterPackets)
+
+		adminRoutes := api.Group("", admin...)
+		adminRoutes.POST("/analyzers/:id", h.AddAnalyzer)
+		adminRoutes.PUT("/analyzers/:id", h.UpdateAnalyzer)
+		adminRoutes.DELETE("/analyzers/:id", h.RemoveAnalyzer)
+		adminRoutes.POST("/analyzers/:id/health", h.SetAnalyzerHealth)
+		adminRoutes.POST("/dead-letter/replay", h.ReplayDeadLetters)
 	}
 
 	return r
@@ -58,6 +130,12 @@ func (h *Handler) SubmitLogs(c *gin.Context) {
 			zap.Error(err),
 			zap.String("client_ip", c.ClientIP()),
 		)
+		if isBodyTooLarge(err) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("Request body exceeds maximum of %d bytes", config.MaxDecompressedBodyBytes),
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid format - expected array of log packets",
 		})
@@ -78,8 +156,15 @@ func (h *Handler) SubmitLogs(c *gin.Context) {
 		return
 	}
 
-	var successCount, failCount int
+	if !h.allowSubmission(c, len(packets), countMessages(packets)) {
+		return
+	}
+
+	var successCount, failCount, duplicateCount int
 	var processedPackets []string
+	idempotencyKey := c.GetHeader(idempotencyKeyHeader)
+	tenant := tenantID(c)
+	traceParent := tracing.TraceParent(c.Request.Context())
 
 	for i := range packets {
 		if len(packets[i].Messages) == 0 {
@@ -87,11 +172,15 @@ func (h *Handler) SubmitLogs(c *gin.Context) {
 			continue
 		}
 
-		if packets[i].ID == "" {
-			packets[i] = models.NewLogPacket(packets[i].Messages)
-		}
+		packets[i] = preparePacket(packets[i], tenant, idempotencyKey, traceParent, i)
 
-		if err := h.distributor.SubmitPacket(packets[i]); err != nil {
+		err := h.distributor.SubmitPacket(packets[i])
+		if errors.Is(err, interfaces.ErrDuplicatePacket) {
+			// Already accepted earlier - acknowledge without dispatching again
+			duplicateCount++
+			successCount++
+			processedPackets = append(processedPackets, packets[i].ID)
+		} else if err != nil {
 			failCount++
 			h.logger.Error("Failed to submit packet",
 				zap.String("packet_id", packets[i].ID),
@@ -114,6 +203,7 @@ func (h *Handler) SubmitLogs(c *gin.Context) {
 		zap.Int("total", len(packets)),
 		zap.Int("successful", successCount),
 		zap.Int("failed", failCount),
+		zap.Int("duplicates", duplicateCount),
 		zap.String("client_ip", c.ClientIP()),
 	)
 
@@ -121,10 +211,35 @@ func (h *Handler) SubmitLogs(c *gin.Context) {
 		"total_packets":     len(packets),
 		"successful":        successCount,
 		"failed":            failCount,
+		"duplicates":        duplicateCount,
 		"processed_packets": processedPackets,
 	})
 }
 
+// preparePacket stamps the caller's tenant and trace context and assigns the packet's ID and idempotency key before submission.
+// A request-level Idempotency-Key is scoped per packet by its position in the request,
+// and packets without an ID get one derived from their key so retries reuse the same ID.
+// Any client-supplied tenant is overwritten so packets cannot be attributed to another tenant.
+func preparePacket(packet models.LogPacket, tenantID, idempotencyKey, traceParent string, index int) models.LogPacket {
+	if packet.IdempotencyKey == "" && idempotencyKey != "" {
+		packet.IdempotencyKey = fmt.Sprintf("%s/%d", idempotencyKey, index)
+	}
+
+	if packet.ID == "" {
+		priority := packet.Priority
+		if packet.IdempotencyKey != "" {
+			packet = models.NewIdempotentLogPacket(packet.Messages, tenantID, packet.IdempotencyKey)
+		} else {
+			packet = models.NewLogPacket(packet.Messages)
+		}
+		packet.Priority = priority
+	}
+
+	packet.TenantID = tenantID
+	packet.TraceParent = traceParent
+	return packet
+}
+
 // HealthCheck returns the health status of the distributor
 func (h *Handler) HealthCheck(c *gin.Context) {
 	stats := h.distributor.GetStats()
@@ -157,10 +272,14 @@ func (h *Handler) GetStats(c *gin.Context) {
 	sanitizedStats := gin.H{
 		"total_packets_received":      stats.TotalPacketsReceived,
 		"total_messages_routed":       stats.TotalMessagesRouted,
+		"duplicate_packets":           stats.DuplicatePackets,
+		"result_stream_subscribers":   stats.ResultSubscribers,
+		"dropped_stream_results":      stats.DroppedStreamResults,
 		"active_analyzers":            stats.ActiveAnalyzers,
 		"packet_channel_util_percent": stats.PacketChannelUtil,
 		"result_channel_util_percent": stats.ResultChannelUtil,
 		"retry_channel_util_percent":  stats.RetryChannelUtil,
+		"packet_lanes":                stats.Lanes,
 		"uptime":                      stats.Uptime.String(),
 		"timestamp":                   time.Now(),
 	}
@@ -170,121 +289,177 @@ func (h *Handler) GetStats(c *gin.Context) {
 		analyzerSummary[id] = gin.H{
 			"name":            analyzer.Name,
 			"is_healthy":      analyzer.IsHealthy,
+			"circuit":         stats.Circuits[id].State,
+			"in_flight":       stats.Bulkheads[id].InFlight,
+			"queued":          stats.Bulkheads[id].Queued,
 			"processed_count": analyzer.GetProcessedCount(),
 			"error_count":     analyzer.GetErrorCount(),
 		}
 	}
 	sanitizedStats["analyzers"] = analyzerSummary
 
+	if len(stats.Routes) > 0 {
+		sanitizedStats["routes"] = stats.Routes
+	}
+	if len(stats.Hedging) > 0 {
+		sanitizedStats["hedging"] = stats.Hedging
+	}
+
+	if h.rateLimiter != nil {
+		sanitizedStats["rate_limits"] = h.rateLimiter.Stats()
+	}
+
 	c.JSON(http.StatusOK, sanitizedStats)
 }
 
 // GetAnalyzers returns information about all analyzers
 func (h *Handler) GetAnalyzers(c *gin.Context) {
 	stats := h.distributor.GetStats()
+	now := time.Now()
 
 	analyzers := make(map[string]interface{})
 	for id, analyzer := range stats.AnalyzerStats {
-		analyzers[id] = gin.H{
-			"id":                analyzer.ID,
-			"name":              analyzer.Name,
-			"weight":            analyzer.Weight,
-			"is_healthy":        analyzer.IsHealthy,
-			"processed_count":   analyzer.GetProcessedCount(),
-			"error_count":       analyzer.GetErrorCount(),
-			"last_health_check": analyzer.LastHealthCheck,
+		info := gin.H{
+			"id":                 analyzer.ID,
+			"name":               analyzer.Name,
+			"weight":             analyzer.Weight,
+			"processing_time_ms": analyzer.ProcessingTimeMs,
+			"is_healthy":         analyzer.IsHealthy,
+			"accepting_packets":  analyzer.AcceptsPackets(now),
+			"override":           analyzer.Override,
+			"processed_count":    analyzer.GetProcessedCount(),
+			"error_count":        analyzer.GetErrorCount(),
+			"last_health_check":  analyzer.LastHealthCheck,
+		}
+		// Adaptive load balancing reports the weight it actually uses and why
+		if weight, adapted := stats.EffectiveWeights[id]; adapted {
+			info["effective_weight"] = weight.Weight
+			info["error_rate"] = weight.ErrorRate
+			info["weight_reason"] = weight.Reason
+			if !weight.ChangedAt.IsZero() {
+				info["weight_changed_at"] = weight.ChangedAt
+			}
 		}
+		if bulkhead, exists := stats.Bulkheads[id]; exists {
+			info["max_in_flight"] = bulkhead.MaxInFlight
+			info["in_flight"] = bulkhead.InFlight
+			info["queued"] = bulkhead.Queued
+		}
+		if circuit, exists := stats.Circuits[id]; exists {
+			info["circuit"] = circuit
+		}
+		analyzers[id] = info
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"analyzers":    analyzers,
 		"total_count":  len(stats.AnalyzerStats),
 		"active_count": stats.ActiveAnalyzers,
-		"timestamp":    time.Now(),
+		"timestamp":    now,
 	})
 }
 
-// GetDeadLetterPackets returns permanently failed packets
-func (h *Handler) GetDeadLetterPackets(c *gin.Context) {
-	deadLetterFile := config.DeadLetterFile
-
-	if _, err := os.Stat(deadLetterFile); os.IsNotExist(err) {
-		c.JSON(http.StatusOK, gin.H{
-			"message":   "No dead letter file found",
-			"count":     0,
-			"packets":   []interface{}{},
-			"timestamp": time.Now(),
+// GetPacketStatus returns the lifecycle status of a single packet.
+// Packets are looked up within the caller's tenant, so other tenants' packets are reported as not found.
+func (h *Handler) GetPacketStatus(c *gin.Context) {
+	packetID := c.Param("id")
+
+	status, found := h.distributor.GetPacketStatus(tenantID(c), packetID)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "Packet not found - unknown ID or evicted from the lifecycle store",
+			"packet_id": packetID,
 		})
 		return
 	}
 
-	data, err := ioutil.ReadFile(deadLetterFile)
+	c.JSON(http.StatusOK, status)
+}
+
+// GetDeadLetterPackets returns a page of permanently failed packets, newest first.
+// Pass the returned next_cursor as cursor to fetch the following page.
+func (h *Handler) GetDeadLetterPackets(c *gin.Context) {
+	filter, err := deadLetterFilterFromQuery(c)
 	if err != nil {
-		h.logger.Error("Failed to read dead letter file", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to read dead letter file",
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
+	// Empty when authentication is disabled, which lists every tenant's packets
+	filter.TenantID = tenantID(c)
+
+	limit := config.DefaultDeadLetterPageSize
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > config.MaxDeadLetterPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("limit must be between 1 and %d", config.MaxDeadLetterPageSize),
+			})
+			return
+		}
+	}
 
-	var deadLetterEntries []interface{}
-	if err := json.Unmarshal(data, &deadLetterEntries); err != nil {
-		h.logger.Error("Failed to parse dead letter file", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to parse dead letter file",
+	page, err := h.distributor.ListDeadLetters(filter, c.Query("cursor"), limit)
+	if errors.Is(err, interfaces.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
-
-	// Limit response size
-	maxEntries := 100
-	if len(deadLetterEntries) > maxEntries {
-		deadLetterEntries = deadLetterEntries[len(deadLetterEntries)-maxEntries:]
+	if err != nil {
+		h.logger.Error("Failed to list dead letter packets", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to read dead letter store",
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":   "Dead letter packets retrieved successfully",
-		"count":     len(deadLetterEntries),
-		"packets":   deadLetterEntries,
-		"file":      deadLetterFile,
-		"timestamp": time.Now(),
-		"note":      fmt.Sprintf("Showing last %d entries", len(deadLetterEntries)),
+		"message":     "Dead letter packets retrieved successfully",
+		"count":       len(page.Entries),
+		"packets":     page.Entries,
+		"next_cursor": page.NextCursor,
+		"timestamp":   time.Now(),
 	})
 }
 
-// SetAnalyzerHealth manually sets an analyzer's health status (for testing)
-func (h *Handler) SetAnalyzerHealth(c *gin.Context) {
-	analyzerID := c.Param("id")
-	if analyzerID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Analyzer ID is required",
-		})
-		return
+// deadLetterFilterFromQuery builds a dead letter filter from the since, until, analyzer_id,
+// error, packet_id (repeatable) and replayed query parameters
+func deadLetterFilterFromQuery(c *gin.Context) (models.DeadLetterFilter, error) {
+	filter := models.DeadLetterFilter{
+		PacketIDs:     c.QueryArray("packet_id"),
+		ErrorContains: c.Query("error"),
+		AnalyzerID:    c.Query("analyzer_id"),
 	}
 
-	var req struct {
-		Healthy bool `json:"healthy"`
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s %q - expected an RFC 3339 timestamp", param.name, raw)
+		}
+		*param.target = &parsed
 	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
-		return
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return filter, errors.New("since must be before until")
 	}
 
-	h.logger.Info("Analyzer health manually set",
-		zap.String("analyzer_id", analyzerID),
-		zap.Bool("healthy", req.Healthy),
-		zap.String("client_ip", c.ClientIP()),
-	)
+	if raw := c.Query("replayed"); raw != "" {
+		replayed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid replayed filter %q - expected true or false", raw)
+		}
+		filter.Replayed = &replayed
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":     "Analyzer health status updated",
-		"analyzer_id": analyzerID,
-		"healthy":     req.Healthy,
-		"timestamp":   time.Now(),
-	})
+	return filter, nil
 }
 
 // loggingMiddleware logs HTTP requests
@@ -295,9 +470,13 @@ func (h *Handler) loggingMiddleware() gin.HandlerFunc {
 
 		c.Next()
 
-		// Skip logging for health checks to avoid spam
-		if path != "/api/v1/health" {
-			latency := time.Since(start)
+		latency := time.Since(start)
+		if h.metrics != nil {
+			h.metrics.ObserveHTTPRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), latency)
+		}
+
+		// Skip logging for health checks and scrapes to avoid spam
+		if path != "/api/v1/health" && path != "/metrics" {
 
 			logLevel := zap.InfoLevel
 			if c.Writer.Status() >= 400 {
@@ -320,13 +499,16 @@ func (h *Handler) loggingMiddleware() gin.HandlerFunc {
 	}
 }
 
-// corsMiddleware handles CORS headers
+// corsMiddleware handles CORS headers for the configured allowed origins
 func (h *Handler) corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Header("Access-Control-Max-Age", "86400")
+		if origin := c.GetHeader("Origin"); origin != "" && h.originAllowed(origin) {
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Encoding, Authorization, X-API-Key, Idempotency-Key")
+			c.Header("Access-Control-Max-Age", "86400")
+		}
+		c.Header("Vary", "Origin")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
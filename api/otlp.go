@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/ingestion"
+	"logs-distributor/tracing"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"go.uber.org/zap"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// OTLP/HTTP content types
+const (
+	otlpContentTypeProtobuf = "application/x-protobuf"
+	otlpContentTypeJSON     = "application/json"
+)
+
+// ExportOTLPLogs implements the OTLP/HTTP logs receiver (POST /v1/logs).
+// Both protobuf and JSON encodings are accepted, and the response uses the request's encoding.
+func (h *Handler) ExportOTLPLogs(c *gin.Context) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	isJSON := mediaType == otlpContentTypeJSON
+	if !isJSON && mediaType != otlpContentTypeProtobuf {
+		h.writeOTLPResponse(c, http.StatusUnsupportedMediaType, false, &statuspb.Status{
+			Code:    int32(codes.InvalidArgument),
+			Message: fmt.Sprintf("unsupported content type %q", c.ContentType()),
+		})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, config.OTLPMaxRequestBytes+1))
+	if err != nil && !isBodyTooLarge(err) {
+		h.writeOTLPResponse(c, http.StatusBadRequest, isJSON, &statuspb.Status{
+			Code:    int32(codes.InvalidArgument),
+			Message: "failed to read request body",
+		})
+		return
+	}
+	if len(body) > config.OTLPMaxRequestBytes || isBodyTooLarge(err) {
+		h.writeOTLPResponse(c, http.StatusRequestEntityTooLarge, isJSON, &statuspb.Status{
+			Code:    int32(codes.InvalidArgument),
+			Message: fmt.Sprintf("request exceeds maximum of %d bytes", config.OTLPMaxRequestBytes),
+		})
+		return
+	}
+
+	req, err := ingestion.DecodeOTLPLogsRequest(body, isJSON)
+	if err != nil {
+		h.logger.Error("Invalid OTLP logs request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		h.writeOTLPResponse(c, http.StatusBadRequest, isJSON, &statuspb.Status{
+			Code:    int32(codes.InvalidArgument),
+			Message: err.Error(),
+		})
+		return
+	}
+
+	packets := ingestion.TranslateOTLPLogs(req)
+	if decision := h.checkRateLimit(c, len(packets), countMessages(packets)); !decision.Allowed {
+		// OTLP exporters honor Retry-After on 429 and back off
+		setRetryAfter(c, decision.RetryAfter)
+		h.writeOTLPResponse(c, http.StatusTooManyRequests, isJSON, &statuspb.Status{
+			Code:    int32(codes.ResourceExhausted),
+			Message: fmt.Sprintf("rate limit exceeded for %s", decision.Key),
+		})
+		return
+	}
+
+	var accepted, rejected, retryable int64
+	var lastErr error
+	tenant := tenantID(c)
+	traceParent := tracing.TraceParent(c.Request.Context())
+	for _, packet := range packets {
+		packet.TenantID = tenant
+		packet.TraceParent = traceParent
+		err := h.distributor.SubmitPacket(packet)
+		switch {
+		case err == nil:
+			accepted += int64(len(packet.Messages))
+		case errors.Is(err, interfaces.ErrInvalidPacket):
+			rejected += int64(len(packet.Messages))
+			lastErr = err
+		default:
+			retryable += int64(len(packet.Messages))
+			lastErr = err
+		}
+		if err != nil {
+			h.logger.Error("Failed to submit OTLP packet",
+				zap.String("packet_id", packet.ID),
+				zap.Error(err),
+				zap.String("client_ip", c.ClientIP()),
+			)
+		}
+	}
+
+	h.logger.Info("OTLP logs processed",
+		zap.Int64("accepted", accepted),
+		zap.Int64("rejected", rejected+retryable),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	// Nothing was accepted and the failures were transient, so ask the exporter to retry
+	if accepted == 0 && retryable > 0 {
+		c.Header("Retry-After", fmt.Sprintf("%d", int(config.SubmissionTimeout.Seconds())))
+		h.writeOTLPResponse(c, http.StatusServiceUnavailable, isJSON, &statuspb.Status{
+			Code:    int32(codes.Unavailable),
+			Message: lastErr.Error(),
+		})
+		return
+	}
+
+	resp := &collogspb.ExportLogsServiceResponse{}
+	if rejected+retryable > 0 {
+		resp.PartialSuccess = &collogspb.ExportLogsPartialSuccess{
+			RejectedLogRecords: rejected + retryable,
+			ErrorMessage:       lastErr.Error(),
+		}
+	}
+	h.writeOTLPResponse(c, http.StatusOK, isJSON, resp)
+}
+
+// writeOTLPResponse encodes an OTLP response or status message in the request's encoding
+func (h *Handler) writeOTLPResponse(c *gin.Context, status int, isJSON bool, msg proto.Message) {
+	if isJSON {
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			h.logger.Error("Failed to encode OTLP response", zap.Error(err))
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Data(status, otlpContentTypeJSON, data)
+		return
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		h.logger.Error("Failed to encode OTLP response", zap.Error(err))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, otlpContentTypeProtobuf, data)
+}
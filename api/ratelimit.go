@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"logs-distributor/auth"
+	"logs-distributor/models"
+	"logs-distributor/ratelimit"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// checkRateLimit counts a submission against the caller's rate limits.
+// Every submission is allowed when no limiter is configured.
+func (h *Handler) checkRateLimit(c *gin.Context, packets, messages int) ratelimit.Decision {
+	if h.rateLimiter == nil {
+		return ratelimit.Decision{Allowed: true}
+	}
+
+	decision := h.rateLimiter.Allow(rateLimitCaller(c), packets, messages)
+	if !decision.Allowed {
+		h.logger.Warn("Submission rate limited",
+			zap.String("rule", string(decision.Rule)),
+			zap.String("key", decision.Key),
+			zap.Int("packets", packets),
+			zap.Int("messages", messages),
+			zap.Duration("retry_after", decision.RetryAfter),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	}
+	return decision
+}
+
+// allowSubmission applies rate limits to a whole request, responding 429 when the caller is over its limit
+func (h *Handler) allowSubmission(c *gin.Context, packets, messages int) bool {
+	decision := h.checkRateLimit(c, packets, messages)
+	if decision.Allowed {
+		return true
+	}
+
+	setRetryAfter(c, decision.RetryAfter)
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       fmt.Sprintf("Rate limit exceeded for %s", decision.Key),
+		"retry_after": retryAfterSeconds(decision.RetryAfter),
+	})
+	return false
+}
+
+// rateLimitCaller identifies the request for rate limiting
+func rateLimitCaller(c *gin.Context) ratelimit.Caller {
+	caller := ratelimit.Caller{ClientIP: c.ClientIP()}
+	if principal, ok := auth.FromContext(c.Request.Context()); ok {
+		caller.KeyName = principal.KeyName
+		caller.TenantID = principal.TenantID
+	}
+	return caller
+}
+
+// setRetryAfter sets the Retry-After header in whole seconds
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", fmt.Sprintf("%d", retryAfterSeconds(wait)))
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds, never less than one
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Max(1, math.Ceil(wait.Seconds())))
+}
+
+// countMessages returns the total number of messages across packets
+func countMessages(packets []models.LogPacket) int {
+	total := 0
+	for _, packet := range packets {
+		total += len(packet.Messages)
+	}
+	return total
+}
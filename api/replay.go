@@ -0,0 +1,53 @@
+package api
+
+import (
+	"logs-distributor/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReplayDeadLetters resubmits dead-lettered packets matching the request body
+func (h *Handler) ReplayDeadLetters(c *gin.Context) {
+	var req models.ReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	if req.RatePerSecond < 0 || req.Limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "rate_per_second and limit cannot be negative",
+		})
+		return
+	}
+	if req.Since != nil && req.Until != nil && !req.Since.Before(*req.Until) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "since must be before until",
+		})
+		return
+	}
+
+	result, err := h.distributor.ReplayDeadLetters(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Dead letter replay failed", zap.Error(err), zap.String("client_ip", c.ClientIP()))
+		body := gin.H{"error": "Dead letter replay failed: " + err.Error()}
+		if result != nil {
+			// Some packets were resubmitted before the failure
+			body["result"] = result
+		}
+		c.JSON(http.StatusInternalServerError, body)
+		return
+	}
+
+	h.logger.Info("Dead letter replay requested",
+		zap.Bool("dry_run", result.DryRun),
+		zap.Int("matched", result.Matched),
+		zap.Int("replayed", result.Replayed),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.JSON(http.StatusOK, result)
+}
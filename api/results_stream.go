@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// StreamResults streams analysis results as Server-Sent Events
+func (h *Handler) StreamResults(c *gin.Context) {
+	results, unsubscribe, ok := h.subscribeResults(c)
+	if !ok {
+		return
+	}
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// The server's WriteTimeout would cut the stream off; use a deadline per write instead
+	controller := http.NewResponseController(c.Writer)
+	writeEvent := func(event string) error {
+		if err := controller.SetWriteDeadline(time.Now().Add(config.ResultStreamWriteTimeout)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(c.Writer, event); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+
+	if err := writeEvent(": connected\n\n"); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(config.ResultStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case result, open := <-results:
+			if !open {
+				return
+			}
+			data, err := json.Marshal(result)
+			if err != nil {
+				h.logger.Error("Failed to encode streamed result", zap.Error(err))
+				continue
+			}
+			if err := writeEvent(fmt.Sprintf("event: result\ndata: %s\n\n", data)); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			// SSE comments keep idle connections open through proxies
+			if err := writeEvent(": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		case <-h.streamsDone:
+			return
+		}
+	}
+}
+
+// StreamResultsWebSocket streams analysis results as JSON WebSocket messages
+func (h *Handler) StreamResultsWebSocket(c *gin.Context) {
+	results, unsubscribe, ok := h.subscribeResults(c)
+	if !ok {
+		return
+	}
+	defer unsubscribe()
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		h.logger.Error("Failed to upgrade result stream", zap.Error(err), zap.String("client_ip", c.ClientIP()))
+		return
+	}
+	defer conn.Close()
+
+	// Clients only receive; the read loop processes pongs and notices disconnects
+	clientGone := make(chan struct{})
+	go func() {
+		defer close(clientGone)
+		conn.SetReadDeadline(time.Now().Add(2 * config.ResultStreamHeartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * config.ResultStreamHeartbeat))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(config.ResultStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	closeWith := func(code int, reason string) {
+		message := websocket.FormatCloseMessage(code, reason)
+		conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(config.ResultStreamWriteTimeout))
+	}
+
+	for {
+		select {
+		case result, open := <-results:
+			if !open {
+				closeWith(websocket.CloseGoingAway, "distributor shutting down")
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(config.ResultStreamWriteTimeout))
+			if err := conn.WriteJSON(result); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(config.ResultStreamWriteTimeout)); err != nil {
+				return
+			}
+		case <-clientGone:
+			return
+		case <-h.streamsDone:
+			closeWith(websocket.CloseGoingAway, "server shutting down")
+			return
+		}
+	}
+}
+
+// CloseStreams ends all live result streams; called when the HTTP server shuts down
+func (h *Handler) CloseStreams() {
+	h.closeStreamsOnce.Do(func() { close(h.streamsDone) })
+}
+
+// subscribeResults subscribes with the request's filter, writing an error response on failure
+func (h *Handler) subscribeResults(c *gin.Context) (<-chan models.AnalysisResult, func(), bool) {
+	filter, err := resultFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, nil, false
+	}
+	// Empty when authentication is disabled, which streams every tenant's results
+	filter.TenantID = tenantID(c)
+
+	results, unsubscribe, err := h.distributor.SubscribeResults(filter)
+	if err != nil {
+		status := http.StatusServiceUnavailable
+		if errors.Is(err, interfaces.ErrTooManySubscribers) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return nil, nil, false
+	}
+
+	h.logger.Info("Result stream subscribed",
+		zap.String("path", c.FullPath()),
+		zap.String("analyzer_id", filter.AnalyzerID),
+		zap.String("packet_id", filter.PacketID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	return results, unsubscribe, true
+}
+
+// resultFilterFromQuery builds a result filter from the analyzer_id, packet_id and success query parameters
+func resultFilterFromQuery(c *gin.Context) (models.ResultFilter, error) {
+	filter := models.ResultFilter{
+		AnalyzerID: c.Query("analyzer_id"),
+		PacketID:   c.Query("packet_id"),
+	}
+
+	if raw := c.Query("success"); raw != "" {
+		success, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid success filter %q - expected true or false", raw)
+		}
+		filter.Success = &success
+	}
+
+	return filter, nil
+}
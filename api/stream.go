@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"logs-distributor/tracing"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Per-line outcomes reported by the NDJSON streaming endpoint
+const (
+	lineStatusAccepted    = "accepted"
+	lineStatusDuplicate   = "duplicate"
+	lineStatusRejected    = "rejected"
+	lineStatusRateLimited = "rate_limited"
+	lineStatusTimedOut    = "timed_out"
+	lineStatusFailed      = "failed"
+)
+
+// lineResult describes what happened to a single NDJSON line
+type lineResult struct {
+	Line     int    `json:"line"`
+	PacketID string `json:"packet_id,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+
+	retryAfter time.Duration // set for rate limited lines
+}
+
+// SubmitLogStream handles newline-delimited JSON packet submission.
+// Each non-empty line is decoded and submitted on its own, so a malformed or
+// invalid packet only rejects its own line instead of the whole request.
+func (h *Handler) SubmitLogStream(c *gin.Context) {
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), config.MaxStreamLineBytes)
+
+	var results []lineResult
+	counts := make(map[string]int)
+	lineNumber := 0
+	idempotencyKey := c.GetHeader(idempotencyKeyHeader)
+	tenant := tenantID(c)
+	traceParent := tracing.TraceParent(c.Request.Context())
+	var retryAfter time.Duration
+
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		result := h.submitStreamLine(c, line, lineNumber, tenant, idempotencyKey, traceParent)
+		counts[result.Status]++
+		results = append(results, result)
+		if result.retryAfter > retryAfter {
+			retryAfter = result.retryAfter
+		}
+	}
+
+	truncated := false
+	if err := scanner.Err(); err != nil {
+		// The line boundary is lost once the scanner fails, so stop here
+		truncated = true
+		errMsg := fmt.Sprintf("failed to read line: %v", err)
+		if errors.Is(err, bufio.ErrTooLong) {
+			errMsg = fmt.Sprintf("line exceeds maximum of %d bytes", config.MaxStreamLineBytes)
+		} else if isBodyTooLarge(err) {
+			errMsg = fmt.Sprintf("decompressed body exceeds maximum of %d bytes", config.MaxDecompressedBodyBytes)
+		}
+		results = append(results, lineResult{
+			Line:   lineNumber + 1,
+			Status: lineStatusRejected,
+			Error:  errMsg,
+		})
+		counts[lineStatusRejected]++
+	}
+
+	if len(results) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Stream must contain at least one packet",
+		})
+		return
+	}
+
+	accepted := counts[lineStatusAccepted]
+	failed := len(results) - accepted - counts[lineStatusDuplicate]
+
+	status := http.StatusAccepted
+	if counts[lineStatusRateLimited] == len(results) {
+		status = http.StatusTooManyRequests
+	} else if failed > 0 && accepted+counts[lineStatusDuplicate] == 0 {
+		status = http.StatusServiceUnavailable
+	} else if failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	if counts[lineStatusRateLimited] > 0 {
+		setRetryAfter(c, retryAfter)
+	}
+
+	h.logger.Info("Log stream processed",
+		zap.Int("total", len(results)),
+		zap.Int("accepted", accepted),
+		zap.Int("rejected", counts[lineStatusRejected]),
+		zap.Int("rate_limited", counts[lineStatusRateLimited]),
+		zap.Int("timed_out", counts[lineStatusTimedOut]),
+		zap.Bool("truncated", truncated),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.JSON(status, gin.H{
+		"total_lines":  len(results),
+		"accepted":     accepted,
+		"duplicates":   counts[lineStatusDuplicate],
+		"rejected":     counts[lineStatusRejected],
+		"rate_limited": counts[lineStatusRateLimited],
+		"timed_out":    counts[lineStatusTimedOut],
+		"failed":       counts[lineStatusFailed],
+		"truncated":    truncated,
+		"results":      results,
+	})
+}
+
+// submitStreamLine decodes a single NDJSON line and submits it to the distributor.
+// The request's idempotency key is scoped per packet by line number.
+// Rate limits are checked per line, so lines past the limit are reported without being submitted.
+func (h *Handler) submitStreamLine(c *gin.Context, line []byte, lineNumber int, tenantID, idempotencyKey, traceParent string) lineResult {
+	var packet models.LogPacket
+	if err := json.Unmarshal(line, &packet); err != nil {
+		return lineResult{
+			Line:   lineNumber,
+			Status: lineStatusRejected,
+			Error:  fmt.Sprintf("invalid JSON: %v", err),
+		}
+	}
+
+	packet = preparePacket(packet, tenantID, idempotencyKey, traceParent, lineNumber)
+
+	result := lineResult{Line: lineNumber, PacketID: packet.ID}
+
+	if decision := h.checkRateLimit(c, 1, len(packet.Messages)); !decision.Allowed {
+		result.Status = lineStatusRateLimited
+		result.Error = fmt.Sprintf("rate limit exceeded for %s", decision.Key)
+		result.retryAfter = decision.RetryAfter
+		return result
+	}
+
+	err := h.distributor.SubmitPacket(packet)
+	switch {
+	case err == nil:
+		result.Status = lineStatusAccepted
+	case errors.Is(err, interfaces.ErrDuplicatePacket):
+		result.Status = lineStatusDuplicate
+		return result
+	case errors.Is(err, interfaces.ErrInvalidPacket):
+		result.Status = lineStatusRejected
+		result.Error = err.Error()
+	case errors.Is(err, interfaces.ErrSubmissionTimeout):
+		result.Status = lineStatusTimedOut
+		result.Error = err.Error()
+	default:
+		result.Status = lineStatusFailed
+		result.Error = err.Error()
+	}
+
+	if err != nil {
+		h.logger.Error("Failed to submit streamed packet",
+			zap.String("packet_id", packet.ID),
+			zap.Int("line", lineNumber),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	}
+
+	return result
+}
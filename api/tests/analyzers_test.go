@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"fmt"
+	"logs-distributor/api"
+	"logs-distributor/distributor/interfaces"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// removalDistributor knows a single analyzer
+type removalDistributor struct {
+	*stubDistributor
+}
+
+func (d *removalDistributor) RemoveAnalyzer(analyzerID string) error {
+	if analyzerID != "analyzer-a1" {
+		return fmt.Errorf("%w: %s", interfaces.ErrAnalyzerNotFound, analyzerID)
+	}
+	return nil
+}
+
+func TestRemoveAnalyzer_AcceptedWhileDraining(t *testing.T) {
+	server := newTestServer(&removalDistributor{newStubDistributor()}, &api.HandlerConfig{})
+	defer server.Close()
+
+	remove := func(analyzerID string) int {
+		req, err := http.NewRequest(http.MethodDelete, server.URL+"/api/v1/analyzers/"+analyzerID, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusAccepted, remove("analyzer-a1"), "The in-flight packets drain after the response")
+	assert.Equal(t, http.StatusNotFound, remove("missing"))
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"logs-distributor/api"
+	"logs-distributor/config"
+	"logs-distributor/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compressors encode a request body for each supported Content-Encoding
+var compressors = map[string]func(t *testing.T, data []byte) []byte{
+	"gzip": func(t *testing.T, data []byte) []byte {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		_, err := writer.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		return buf.Bytes()
+	},
+	"zstd": func(t *testing.T, data []byte) []byte {
+		encoder, err := zstd.NewWriter(nil)
+		require.NoError(t, err)
+		defer encoder.Close()
+		return encoder.EncodeAll(data, nil)
+	},
+	"snappy": func(t *testing.T, data []byte) []byte {
+		return snappy.Encode(nil, data)
+	},
+	"x-snappy-framed": func(t *testing.T, data []byte) []byte {
+		var buf bytes.Buffer
+		writer := snappy.NewBufferedWriter(&buf)
+		_, err := writer.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		return buf.Bytes()
+	},
+}
+
+// postEncoded sends an encoded body to the packet submission endpoint
+func postEncoded(t *testing.T, server *httptest.Server, encoding string, body []byte) (int, string) {
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/logs", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", encoding)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestDecompression_SupportedEncodings(t *testing.T) {
+	body, err := json.Marshal([]models.LogPacket{{
+		Messages: []models.LogMessage{models.NewLogMessage("INFO", "compressed", "test-service", nil)},
+	}})
+	require.NoError(t, err)
+
+	for encoding, compress := range compressors {
+		t.Run(encoding, func(t *testing.T) {
+			d := newStubDistributor()
+			server := newTestServer(d, &api.HandlerConfig{})
+			defer server.Close()
+
+			status, response := postEncoded(t, server, encoding, compress(t, body))
+			require.Equal(t, http.StatusAccepted, status, response)
+			packets := d.packets()
+			require.Len(t, packets, 1)
+			assert.Equal(t, "compressed", packets[0].Messages[0].Message)
+		})
+	}
+}
+
+func TestDecompression_UnknownEncoding(t *testing.T) {
+	server := newTestServer(newStubDistributor(), &api.HandlerConfig{})
+	defer server.Close()
+
+	status, response := postEncoded(t, server, "br", []byte("[]"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, status)
+	assert.Contains(t, response, "unsupported content encoding")
+}
+
+func TestDecompression_CorruptBody(t *testing.T) {
+	server := newTestServer(newStubDistributor(), &api.HandlerConfig{})
+	defer server.Close()
+
+	valid := compressors["gzip"](t, []byte(`[{"messages": []}]`))
+	tests := map[string]struct {
+		encoding string
+		body     []byte
+	}{
+		"gzip header":    {"gzip", []byte("not gzip at all")},
+		"gzip truncated": {"gzip", valid[:len(valid)/2]},
+		"zstd":           {"zstd", []byte("not zstd at all")},
+		"snappy":         {"snappy", []byte{0x0a, 0xff, 0xff}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			status, response := postEncoded(t, server, tt.encoding, tt.body)
+			assert.Equal(t, http.StatusBadRequest, status, response)
+		})
+	}
+}
+
+func TestDecompression_BombIsCutOff(t *testing.T) {
+	d := newStubDistributor()
+	server := newTestServer(d, &api.HandlerConfig{})
+	defer server.Close()
+
+	// Valid JSON that only exceeds the cap once decompressed
+	bomb := []byte("[" + strings.Repeat(" ", config.MaxDecompressedBodyBytes) + "]")
+
+	for encoding, compress := range compressors {
+		t.Run(encoding, func(t *testing.T) {
+			compressed := compress(t, bomb)
+			require.Less(t, len(compressed), config.MaxDecompressedBodyBytes/10, "The bomb should be small on the wire")
+
+			status, response := postEncoded(t, server, encoding, compressed)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, status, response)
+		})
+	}
+	assert.Empty(t, d.packets())
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"logs-distributor/api"
+	"logs-distributor/auth"
+	"logs-distributor/models"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+)
+
+func TestSubmit_IdempotencyKeyKeepsTenantAndTrace(t *testing.T) {
+	keyStore, err := auth.NewKeyStore([]auth.KeyEntry{
+		{Name: "shipper", KeyHash: auth.HashKey("key-a"), TenantID: "tenant-a", Scopes: []auth.Scope{auth.ScopeIngest}},
+	})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		path        string
+		contentType string
+		body        string
+	}{
+		"batch":  {"/api/v1/logs", "application/json", "[" + packetLine(t, "keyed") + "]"},
+		"ndjson": {"/api/v1/logs/stream", "application/x-ndjson", packetLine(t, "keyed")},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := newStubDistributor()
+			server := newTestServer(d, &api.HandlerConfig{KeyStore: keyStore})
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("X-API-Key", "key-a")
+			req.Header.Set("Idempotency-Key", "client-key")
+			req.Header.Set("traceparent", testTraceParent)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Less(t, resp.StatusCode, http.StatusMultipleChoices)
+
+			packets := d.packets()
+			require.Len(t, packets, 1)
+			packet := packets[0]
+			assert.NotEmpty(t, packet.IdempotencyKey)
+			assert.Equal(t, models.NewIdempotentLogPacket(packet.Messages, "tenant-a", packet.IdempotencyKey).ID, packet.ID,
+				"Keyed packets should get the ID derived from their tenant and key")
+			assert.Equal(t, "tenant-a", packet.TenantID)
+			assert.Contains(t, packet.TraceParent, testTraceID, "Keyed packets should join the caller's trace")
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"logs-distributor/api"
+	"logs-distributor/auth"
+	"logs-distributor/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusDistributor serves lifecycle statuses from a fixed map keyed by tenant-scoped packet ID
+type statusDistributor struct {
+	*stubDistributor
+	statuses map[string]*models.PacketStatus
+}
+
+func (d *statusDistributor) GetPacketStatus(tenantID, packetID string) (*models.PacketStatus, bool) {
+	status, found := d.statuses[models.TenantScopedKey(tenantID, packetID)]
+	return status, found
+}
+
+func TestGetPacketStatus_TenantIsolation(t *testing.T) {
+	keyStore, err := auth.NewKeyStore([]auth.KeyEntry{
+		{Name: "reader-a", KeyHash: auth.HashKey("key-a"), TenantID: "tenant-a", Scopes: []auth.Scope{auth.ScopeRead}},
+		{Name: "reader-b", KeyHash: auth.HashKey("key-b"), TenantID: "tenant-b", Scopes: []auth.Scope{auth.ScopeRead}},
+	})
+	require.NoError(t, err)
+
+	d := &statusDistributor{
+		stubDistributor: newStubDistributor(),
+		statuses: map[string]*models.PacketStatus{
+			"tenant-a/packet-a": {PacketID: "packet-a", TenantID: "tenant-a", State: models.PacketStateQueued},
+		},
+	}
+	server := newTestServer(d, &api.HandlerConfig{KeyStore: keyStore})
+	defer server.Close()
+
+	getStatus := func(packetID, key string) int {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/packets/"+packetID, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-API-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, getStatus("packet-a", "key-a"), "The owning tenant should see its packet")
+	assert.Equal(t, http.StatusNotFound, getStatus("packet-a", "key-b"),
+		"Another tenant's packet should be indistinguishable from an unknown ID")
+	assert.Equal(t, http.StatusNotFound, getStatus("unknown", "key-a"))
+}
+
+func TestGetPacketStatus_WithoutAuthentication(t *testing.T) {
+	d := &statusDistributor{
+		stubDistributor: newStubDistributor(),
+		statuses: map[string]*models.PacketStatus{
+			"packet-a": {PacketID: "packet-a", State: models.PacketStateQueued},
+		},
+	}
+	server := newTestServer(d, &api.HandlerConfig{})
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/packets/packet-a")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Without API keys there are no tenants to isolate")
+}
@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"logs-distributor/api"
+	"logs-distributor/config"
+	"logs-distributor/distributor/implementations"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"logs-distributor/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubDistributor records submitted packets and validates them like the distributor does.
+// Packets whose first message has an error registered in errors fail with that error instead.
+type stubDistributor struct {
+	interfaces.Distributor
+	validator interfaces.PacketValidator
+	errors    map[string]error // by first message text
+	submitted []models.LogPacket
+	mu        sync.Mutex
+}
+
+func newStubDistributor() *stubDistributor {
+	return &stubDistributor{validator: implementations.NewPacketValidator(), errors: make(map[string]error)}
+}
+
+func (d *stubDistributor) SubmitPacket(packet models.LogPacket) error {
+	if err := d.validator.ValidatePacket(packet); err != nil {
+		return fmt.Errorf("%w: %v", interfaces.ErrInvalidPacket, err)
+	}
+	if err := d.errors[packet.Messages[0].Message]; err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.submitted = append(d.submitted, packet)
+	return nil
+}
+
+// packets returns the packets accepted so far
+func (d *stubDistributor) packets() []models.LogPacket {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]models.LogPacket(nil), d.submitted...)
+}
+
+// newTestServer serves the API in front of the distributor
+func newTestServer(d interfaces.Distributor, cfg *api.HandlerConfig) *httptest.Server {
+	return httptest.NewServer(api.NewHandler(d, zap.NewNop(), cfg).SetupRoutes())
+}
+
+// packetLine encodes a packet with one message as an NDJSON line
+func packetLine(t *testing.T, message string) string {
+	data, err := json.Marshal(models.LogPacket{
+		Messages: []models.LogMessage{models.NewLogMessage("INFO", message, "test-service", nil)},
+	})
+	require.NoError(t, err)
+	return string(data)
+}
+
+// streamResponse is the body returned by the NDJSON streaming endpoint
+type streamResponse struct {
+	TotalLines  int  `json:"total_lines"`
+	Accepted    int  `json:"accepted"`
+	Duplicates  int  `json:"duplicates"`
+	Rejected    int  `json:"rejected"`
+	RateLimited int  `json:"rate_limited"`
+	TimedOut    int  `json:"timed_out"`
+	Truncated   bool `json:"truncated"`
+	Results     []struct {
+		Line     int    `json:"line"`
+		PacketID string `json:"packet_id"`
+		Status   string `json:"status"`
+		Error    string `json:"error"`
+	} `json:"results"`
+}
+
+// postStream sends lines to the streaming endpoint and decodes the response
+func postStream(t *testing.T, server *httptest.Server, body string) (*http.Response, streamResponse) {
+	resp, err := http.Post(server.URL+"/api/v1/logs/stream", "application/x-ndjson", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded streamResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return resp, decoded
+}
+
+func TestSubmitLogStream_PerLineOutcomes(t *testing.T) {
+	d := newStubDistributor()
+	d.errors["seen before"] = interfaces.ErrDuplicatePacket
+	d.errors["queue full"] = interfaces.ErrSubmissionTimeout
+	server := newTestServer(d, &api.HandlerConfig{})
+	defer server.Close()
+
+	empty, err := json.Marshal(models.LogPacket{Messages: []models.LogMessage{}})
+	require.NoError(t, err)
+	body := strings.Join([]string{
+		packetLine(t, "first"),
+		"",
+		`{"messages": [`,
+		string(empty),
+		packetLine(t, "seen before"),
+		packetLine(t, "queue full"),
+	}, "\n")
+
+	resp, decoded := postStream(t, server, body)
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	require.Len(t, decoded.Results, 5, "Blank lines should be skipped")
+
+	statuses := make(map[int]string)
+	for _, result := range decoded.Results {
+		statuses[result.Line] = result.Status
+	}
+	assert.Equal(t, map[int]string{1: "accepted", 3: "rejected", 4: "rejected", 5: "duplicate", 6: "timed_out"}, statuses)
+	assert.Contains(t, decoded.Results[1].Error, "invalid JSON")
+	assert.Contains(t, decoded.Results[2].Error, interfaces.ErrInvalidPacket.Error(), "Validator errors should be reported on their line")
+	assert.Contains(t, decoded.Results[2].Error, "at least one message")
+	assert.Equal(t, 1, decoded.Accepted)
+	assert.Equal(t, 1, decoded.Duplicates)
+	assert.Equal(t, 2, decoded.Rejected)
+	assert.Equal(t, 1, decoded.TimedOut)
+	assert.False(t, decoded.Truncated)
+
+	packets := d.packets()
+	require.Len(t, packets, 1)
+	assert.Equal(t, decoded.Results[0].PacketID, packets[0].ID)
+}
+
+func TestSubmitLogStream_OverlongLineTruncates(t *testing.T) {
+	d := newStubDistributor()
+	server := newTestServer(d, &api.HandlerConfig{})
+	defer server.Close()
+
+	overlong := `{"messages": [{"message": "` + strings.Repeat("x", config.MaxStreamLineBytes) + `"}]}`
+	body := packetLine(t, "first") + "\n" + overlong + "\n" + packetLine(t, "never read")
+
+	resp, decoded := postStream(t, server, body)
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	assert.True(t, decoded.Truncated)
+	require.Len(t, decoded.Results, 2, "Lines after the over-long one should not be read")
+	assert.Equal(t, "accepted", decoded.Results[0].Status)
+	assert.Equal(t, 2, decoded.Results[1].Line)
+	assert.Equal(t, "rejected", decoded.Results[1].Status)
+	assert.Contains(t, decoded.Results[1].Error, "line exceeds maximum")
+	assert.Len(t, d.packets(), 1)
+}
+
+func TestSubmitLogStream_StatusSelection(t *testing.T) {
+	d := newStubDistributor()
+	d.errors["queue full"] = interfaces.ErrSubmissionTimeout
+	d.errors["seen before"] = interfaces.ErrDuplicatePacket
+	server := newTestServer(d, &api.HandlerConfig{})
+	defer server.Close()
+
+	tests := map[string]struct {
+		lines    []string
+		expected int
+	}{
+		"all accepted":         {[]string{packetLine(t, "a"), packetLine(t, "b")}, http.StatusAccepted},
+		"duplicates only":      {[]string{packetLine(t, "seen before")}, http.StatusAccepted},
+		"some failed":          {[]string{packetLine(t, "a"), "not json"}, http.StatusMultiStatus},
+		"all failed":           {[]string{"not json", packetLine(t, "queue full")}, http.StatusServiceUnavailable},
+		"duplicate and failed": {[]string{packetLine(t, "seen before"), packetLine(t, "queue full")}, http.StatusMultiStatus},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, _ := postStream(t, server, strings.Join(tt.lines, "\n"))
+			assert.Equal(t, tt.expected, resp.StatusCode)
+		})
+	}
+
+	resp, err := http.Post(server.URL+"/api/v1/logs/stream", "application/x-ndjson", strings.NewReader("\n\n"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "A stream without packets should be rejected")
+}
+
+func TestSubmitLogStream_RateLimited(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter([]ratelimit.Rule{
+		{Key: ratelimit.KeyClientIP, PacketsPerSecond: 0.01, PacketBurst: 1},
+	}, time.Minute, 10)
+	require.NoError(t, err)
+
+	d := newStubDistributor()
+	server := newTestServer(d, &api.HandlerConfig{RateLimiter: limiter})
+	defer server.Close()
+
+	// The burst admits the first line only
+	resp, decoded := postStream(t, server, packetLine(t, "a")+"\n"+packetLine(t, "b"))
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	assert.Equal(t, 1, decoded.Accepted)
+	assert.Equal(t, 1, decoded.RateLimited)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	resp, decoded = postStream(t, server, packetLine(t, "c")+"\n"+packetLine(t, "d"))
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, 2, decoded.RateLimited)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	assert.Len(t, d.packets(), 1)
+}
+
+func TestRateLimit_IgnoresForwardedForFromUntrustedClients(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter([]ratelimit.Rule{
+		{Key: ratelimit.KeyClientIP, PacketsPerSecond: 0.01, PacketBurst: 1},
+	}, time.Minute, 10)
+	require.NoError(t, err)
+
+	submit := func(server *httptest.Server, forwardedFor string) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/logs", strings.NewReader("["+packetLine(t, "a")+"]"))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	server := newTestServer(newStubDistributor(), &api.HandlerConfig{RateLimiter: limiter})
+	defer server.Close()
+
+	assert.Equal(t, http.StatusAccepted, submit(server, "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, submit(server, "203.0.113.2"),
+		"A new X-Forwarded-For value should not get a fresh bucket")
+
+	// Behind a trusted proxy each forwarded client has its own bucket
+	limiter, err = ratelimit.NewLimiter([]ratelimit.Rule{
+		{Key: ratelimit.KeyClientIP, PacketsPerSecond: 0.01, PacketBurst: 1},
+	}, time.Minute, 10)
+	require.NoError(t, err)
+	proxied := newTestServer(newStubDistributor(), &api.HandlerConfig{RateLimiter: limiter, TrustedProxies: []string{"127.0.0.1"}})
+	defer proxied.Close()
+
+	assert.Equal(t, http.StatusAccepted, submit(proxied, "203.0.113.1"))
+	assert.Equal(t, http.StatusAccepted, submit(proxied, "203.0.113.2"))
+	assert.Equal(t, http.StatusTooManyRequests, submit(proxied, "203.0.113.1"))
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"logs-distributor/api"
+	"logs-distributor/auth"
+	"logs-distributor/models"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// filterDistributor records the filters the handlers query dead letters and results with
+type filterDistributor struct {
+	*stubDistributor
+	mu                sync.Mutex
+	deadLetterFilters []models.DeadLetterFilter
+	resultFilters     []models.ResultFilter
+}
+
+func (d *filterDistributor) ListDeadLetters(filter models.DeadLetterFilter, cursor string, limit int) (*models.DeadLetterPage, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deadLetterFilters = append(d.deadLetterFilters, filter)
+	return &models.DeadLetterPage{}, nil
+}
+
+func (d *filterDistributor) SubscribeResults(filter models.ResultFilter) (<-chan models.AnalysisResult, func(), error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.resultFilters = append(d.resultFilters, filter)
+
+	// A closed channel ends the stream right away
+	results := make(chan models.AnalysisResult)
+	close(results)
+	return results, func() {}, nil
+}
+
+func TestReadEndpoints_ScopedToCallerTenant(t *testing.T) {
+	keyStore, err := auth.NewKeyStore([]auth.KeyEntry{
+		{Name: "reader-a", KeyHash: auth.HashKey("key-a"), TenantID: "tenant-a", Scopes: []auth.Scope{auth.ScopeRead}},
+	})
+	require.NoError(t, err)
+
+	d := &filterDistributor{stubDistributor: newStubDistributor()}
+	server := newTestServer(d, &api.HandlerConfig{KeyStore: keyStore})
+	defer server.Close()
+
+	for _, path := range []string{"/api/v1/dead-letter", "/api/v1/results/stream"} {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path+"?analyzer_id=analyzer-a1", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-API-Key", "key-a")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	require.Len(t, d.deadLetterFilters, 1)
+	assert.Equal(t, "tenant-a", d.deadLetterFilters[0].TenantID, "Dead letters should be listed within the caller's tenant")
+	assert.Equal(t, "analyzer-a1", d.deadLetterFilters[0].AnalyzerID)
+	require.Len(t, d.resultFilters, 1)
+	assert.Equal(t, "tenant-a", d.resultFilters[0].TenantID, "Results should be streamed within the caller's tenant")
+	assert.Equal(t, "analyzer-a1", d.resultFilters[0].AnalyzerID)
+}
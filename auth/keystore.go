@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Scope is a permission granted to an API key
+type Scope string
+
+// API key scopes; admin implies every other scope
+const (
+	ScopeIngest Scope = "ingest" // submit logs
+	ScopeRead   Scope = "read"   // stats, packet status, result streams, dead letters
+	ScopeAdmin  Scope = "admin"  // analyzer management
+)
+
+// Principal is the identity behind an authenticated API key
+type Principal struct {
+	KeyName  string
+	TenantID string
+	Scopes   []Scope
+}
+
+// HasScope reports whether the principal was granted the scope
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyEntry is a single API key in the keys file. Only the key's SHA-256 hash is stored.
+type KeyEntry struct {
+	Name     string  `json:"name"`
+	KeyHash  string  `json:"key_hash"` // hex-encoded SHA-256 of the key, see HashKey
+	TenantID string  `json:"tenant_id"`
+	Scopes   []Scope `json:"scopes"`
+}
+
+// keysFile is the on-disk format of the API keys file
+type keysFile struct {
+	Keys []KeyEntry `json:"keys"`
+}
+
+// KeyStore authenticates API keys against their stored hashes
+type KeyStore struct {
+	principals map[string]*Principal // keyed by key hash
+}
+
+// HashKey returns the hex-encoded SHA-256 hash stored in the keys file for an API key
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadKeyStore reads and validates an API keys file
+func LoadKeyStore(path string) (*KeyStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys file: %w", err)
+	}
+
+	var file keysFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys file: %w", err)
+	}
+
+	return NewKeyStore(file.Keys)
+}
+
+// NewKeyStore builds a key store from key entries
+func NewKeyStore(entries []KeyEntry) (*KeyStore, error) {
+	store := &KeyStore{principals: make(map[string]*Principal, len(entries))}
+
+	for i, entry := range entries {
+		hash := strings.ToLower(strings.TrimSpace(entry.KeyHash))
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("key %d (%s): key_hash must be a hex-encoded SHA-256 hash", i, entry.Name)
+		}
+		if entry.TenantID == "" {
+			return nil, fmt.Errorf("key %d (%s): tenant_id is required", i, entry.Name)
+		}
+		if len(entry.Scopes) == 0 {
+			return nil, fmt.Errorf("key %d (%s): at least one scope is required", i, entry.Name)
+		}
+		for _, scope := range entry.Scopes {
+			if scope != ScopeIngest && scope != ScopeRead && scope != ScopeAdmin {
+				return nil, fmt.Errorf("key %d (%s): unknown scope %q", i, entry.Name, scope)
+			}
+		}
+		if _, exists := store.principals[hash]; exists {
+			return nil, fmt.Errorf("key %d (%s): duplicate key_hash", i, entry.Name)
+		}
+
+		store.principals[hash] = &Principal{
+			KeyName:  entry.Name,
+			TenantID: entry.TenantID,
+			Scopes:   entry.Scopes,
+		}
+	}
+
+	return store, nil
+}
+
+// Authenticate returns the principal for an API key
+func (s *KeyStore) Authenticate(key string) (*Principal, bool) {
+	if key == "" {
+		return nil, false
+	}
+	principal, ok := s.principals[HashKey(key)]
+	return principal, ok
+}
+
+// Len returns the number of configured keys
+func (s *KeyStore) Len() int {
+	return len(s.principals)
+}
+
+// principalKey is the context key for the authenticated principal
+type principalKey struct{}
+
+// NewContext returns a context carrying the authenticated principal
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the authenticated principal, if any
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// TenantFromContext returns the authenticated tenant ID, or "" when the request was not authenticated
+func TenantFromContext(ctx context.Context) string {
+	if principal, ok := FromContext(ctx); ok {
+		return principal.TenantID
+	}
+	return ""
+}
+
+// KeyFromBearer extracts the key from an "Authorization: Bearer <key>" value
+func KeyFromBearer(authorization string) string {
+	const prefix = "bearer "
+	if len(authorization) > len(prefix) && strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return strings.TrimSpace(authorization[len(prefix):])
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"logs-distributor/auth"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyStore_LoadAndAuthenticate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_keys.json")
+	keysFile := `{"keys": [
+		{"name": "shipper", "key_hash": "` + auth.HashKey("secret-ingest") + `", "tenant_id": "acme", "scopes": ["ingest"]},
+		{"name": "ops", "key_hash": "` + auth.HashKey("secret-admin") + `", "tenant_id": "acme", "scopes": ["admin"]}
+	]}`
+	require.NoError(t, os.WriteFile(path, []byte(keysFile), 0600))
+
+	store, err := auth.LoadKeyStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.Len())
+
+	principal, ok := store.Authenticate("secret-ingest")
+	require.True(t, ok)
+	assert.Equal(t, "shipper", principal.KeyName)
+	assert.Equal(t, "acme", principal.TenantID)
+	assert.True(t, principal.HasScope(auth.ScopeIngest))
+	assert.False(t, principal.HasScope(auth.ScopeRead))
+
+	admin, ok := store.Authenticate("secret-admin")
+	require.True(t, ok)
+	assert.True(t, admin.HasScope(auth.ScopeRead), "Admin should imply every scope")
+
+	_, ok = store.Authenticate("wrong-key")
+	assert.False(t, ok)
+	_, ok = store.Authenticate("")
+	assert.False(t, ok)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-ingest", "Keys file should only contain hashes")
+}
+
+func TestKeyStore_InvalidEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry auth.KeyEntry
+	}{
+		{"plaintext key", auth.KeyEntry{Name: "k", KeyHash: "not-a-hash", TenantID: "t", Scopes: []auth.Scope{auth.ScopeRead}}},
+		{"missing tenant", auth.KeyEntry{Name: "k", KeyHash: auth.HashKey("k"), Scopes: []auth.Scope{auth.ScopeRead}}},
+		{"no scopes", auth.KeyEntry{Name: "k", KeyHash: auth.HashKey("k"), TenantID: "t"}},
+		{"unknown scope", auth.KeyEntry{Name: "k", KeyHash: auth.HashKey("k"), TenantID: "t", Scopes: []auth.Scope{"write"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.NewKeyStore([]auth.KeyEntry{tt.entry})
+			assert.Error(t, err)
+		})
+	}
+
+	duplicate := auth.KeyEntry{Name: "k", KeyHash: auth.HashKey("k"), TenantID: "t", Scopes: []auth.Scope{auth.ScopeRead}}
+	_, err := auth.NewKeyStore([]auth.KeyEntry{duplicate, duplicate})
+	assert.Error(t, err, "Duplicate key hashes should be rejected")
+}
+
+func TestKeyStore_Context(t *testing.T) {
+	assert.Equal(t, "", auth.TenantFromContext(context.Background()))
+
+	ctx := auth.NewContext(context.Background(), &auth.Principal{TenantID: "acme"})
+	assert.Equal(t, "acme", auth.TenantFromContext(ctx))
+
+	assert.Equal(t, "abc", auth.KeyFromBearer("Bearer abc"))
+	assert.Equal(t, "abc", auth.KeyFromBearer("bearer abc"))
+	assert.Equal(t, "", auth.KeyFromBearer("Basic abc"))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"logs-distributor/config"
+	"logs-distributor/models"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// replayClientTimeout leaves room for the server to spend MaxReplayDuration on one call
+const replayClientTimeout = config.MaxReplayDuration + 30*time.Second
+
+// runCommand runs a CLI subcommand and returns the process exit code
+func runCommand(args []string) int {
+	switch args[0] {
+	case "dlq":
+		return runDLQCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\nUsage:\n  %s              start the service\n  %s dlq replay   replay dead-lettered packets\n",
+			args[0], ServiceName, ServiceName)
+		return 2
+	}
+}
+
+// runDLQCommand runs a dead letter subcommand
+func runDLQCommand(args []string) int {
+	if len(args) == 0 || args[0] != "replay" {
+		fmt.Fprintf(os.Stderr, "Usage: %s dlq replay [flags]\n", ServiceName)
+		return 2
+	}
+	return runDLQReplay(args[1:])
+}
+
+// runDLQReplay replays dead letters through a running service's replay endpoint.
+// Without -limit or -dry-run it keeps calling until every matching entry has been replayed.
+func runDLQReplay(args []string) int {
+	flags := flag.NewFlagSet("dlq replay", flag.ContinueOnError)
+	url := flags.String("url", "http://localhost:"+getEnv("PORT", config.DefaultPort), "base URL of the running service")
+	apiKey := flags.String("api-key", os.Getenv("LOGS_DISTRIBUTOR_API_KEY"), "admin API key (default $LOGS_DISTRIBUTOR_API_KEY)")
+	ids := flags.String("ids", "", "comma-separated packet IDs")
+	since := flags.String("since", "", "only entries that failed at or after this RFC 3339 time")
+	until := flags.String("until", "", "only entries that failed before this RFC 3339 time")
+	errorContains := flags.String("error", "", "only entries whose final error contains this text")
+	analyzerID := flags.String("analyzer", "", "only entries whose final attempt was on this analyzer")
+	dryRun := flags.Bool("dry-run", false, "list matching entries without replaying them")
+	rate := flags.Float64("rate", config.DefaultReplayRate, "maximum packets replayed per second")
+	limit := flags.Int("limit", 0, "replay at most this many entries (0 for all)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	req := models.ReplayRequest{
+		DeadLetterFilter: models.DeadLetterFilter{
+			PacketIDs:     splitList(*ids),
+			ErrorContains: *errorContains,
+			AnalyzerID:    *analyzerID,
+		},
+		DryRun:        *dryRun,
+		RatePerSecond: *rate,
+		Limit:         *limit,
+	}
+	var err error
+	if req.Since, err = parseTimeFlag("since", *since); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if req.Until, err = parseTimeFlag("until", *until); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	client := &http.Client{Timeout: replayClientTimeout}
+	for {
+		result, err := postReplay(client, strings.TrimRight(*url, "/"), *apiKey, req)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		output, _ := json.Marshal(result)
+		fmt.Println(string(output))
+
+		if result.Failed > 0 {
+			return 1
+		}
+		// Each call replays what fits in the server's time budget; stop when nothing is left or nothing moved
+		if req.DryRun || req.Limit > 0 || result.Remaining == 0 || result.Replayed == 0 {
+			return 0
+		}
+	}
+}
+
+// parseTimeFlag parses an optional RFC 3339 flag value
+func parseTimeFlag(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -%s: %w", name, err)
+	}
+	return &parsed, nil
+}
+
+// postReplay sends one replay request
+func postReplay(client *http.Client, baseURL, apiKey string, req models.ReplayRequest) (*models.ReplayResult, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, baseURL+"/api/v1/dead-letter/replay", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("X-API-Key", apiKey)
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("replay request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("replay failed with %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var result models.ReplayResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse replay response: %w", err)
+	}
+	return &result, nil
+}
@@ -12,8 +12,31 @@ const (
 	IdleTimeout     = 120 * time.Second
 	ShutdownTimeout = 30 * time.Second
 
+	// Access Control Configuration
+	DefaultAPIKeysFile = "api_keys.json" // authentication is disabled when this default file is absent
+
+	// Rate Limiting Configuration
+	DefaultRateLimitsFile      = "rate_limits.json" // rate limiting is disabled when this default file is absent
+	RateLimitIdleTimeout       = 10 * time.Minute   // per-key buckets idle this long are forgotten
+	MaxRateLimitedKeysReported = 20                 // limited keys listed per rule in /api/v1/stats
+
+	// gRPC Ingestion Configuration
+	DefaultGRPCPort     = "9090"
+	GRPCMaxRecvMsgBytes = 16 * 1024 * 1024
+
+	// OTLP Ingestion Configuration
+	OTLPMaxRequestBytes = 16 * 1024 * 1024
+
+	// Syslog Ingestion Configuration
+	DefaultSyslogUDPPort = "5514"
+	DefaultSyslogTCPPort = "5514"
+	SyslogBatchSize      = 100  // messages per submitted packet
+	SyslogBufferSize     = 5000 // messages buffered before listeners block
+	SyslogFlushInterval  = 1 * time.Second
+	SyslogMaxFrameBytes  = 64 * 1024
+
 	// Distributor Configuration
-	PacketChannelBuffer = 2000
+	PacketChannelBuffer = 2000 // divided across the priority lanes
 	ResultChannelBuffer = 2000
 	RetryChannelBuffer  = 1000
 	PacketWorkers       = 100
@@ -22,18 +45,95 @@ const (
 	SubmissionTimeout   = 5 * time.Second
 	ResultTimeout       = 1 * time.Second
 
+	// Load Balancing Configuration
+	LatencyDecayTime       = 2 * time.Second // how fast an idle analyzer's latency estimate fades, so a recovered analyzer is tried again
+	HashRingNodesPerWeight = 400             // virtual nodes of an analyzer with weight 1.0
+	HashLoadFactor         = 1.25            // an analyzer takes at most this multiple of its share of outstanding packets
+	DefaultAffinityKey     = "source"        // message field consistent hashing keeps on one analyzer
+
+	// Adaptive Weight Configuration (weights are multiples of the configured weight)
+	AdaptiveWeightWindow       = 1 * time.Minute // error rates are measured over this sliding window
+	AdaptiveWeightInterval     = 5 * time.Second
+	AdaptiveWeightMinResults   = 20   // fewer results in the window leave the weight unchanged
+	AdaptiveTolerableErrorRate = 0.10 // above the simulated failure rate, so normal failures keep the full weight
+	AdaptiveWeightFloor        = 0.1
+	AdaptiveWeightCeiling      = 1.0
+	AdaptiveWeightRecoveryStep = 0.1 // per interval; weights fall at once
+
+	// Priority Lane Configuration
+	// While lanes are backlogged, workers take packets from them in proportion to their weights
+	HighPriorityLaneWeight   = 6
+	NormalPriorityLaneWeight = 3
+	LowPriorityLaneWeight    = 1
+
+	// Bulkhead Configuration
+	DefaultAnalyzerMaxInFlight = 64 // packets an analyzer processes at once unless configured
+	MaxAnalyzerMaxInFlight     = 10000
+	AnalyzerQueueSize          = 32 // packets waiting per analyzer once its in-flight limit is reached
+
+	// Circuit Breaker Configuration
+	CircuitConsecutiveFailures = 5
+	CircuitFailureRatio        = 0.5 // of the last CircuitWindowSize results
+	CircuitWindowSize          = 20
+	CircuitOpenTimeout         = 15 * time.Second // before half-open probing
+	CircuitHalfOpenProbes      = 3                // probes in flight at once; this many successes close the circuit
+
+	// Hedging Configuration (defaults for groups listed under "hedging" in the routing rules)
+	HedgePercentile    = 95.0
+	HedgeMinDelay      = 20 * time.Millisecond
+	HedgeMaxRate       = 0.1 // share of a route's packets
+	HedgeBudgetBurst   = 10  // hedges a route may save up while its packets are fast
+	HedgeLatencyWindow = 200 // recent latencies per route the percentile is taken over
+	HedgeMinLatencies  = 20  // packets are not hedged until a route has this many latencies
+
+	// Routing Configuration
+	DefaultRoutingRulesFile = "routing_rules.json" // packets are balanced across all analyzers when this default file is absent
+
+	// Analyzer Registry Configuration
+	AnalyzerDrainTimeout = 30 * time.Second // how long removing an analyzer waits for its in-flight packets
+
+	// Deduplication Configuration
+	DeduplicationWindow          = 10 * time.Minute // duplicates inside this window are acknowledged, not re-dispatched
+	MaxDeduplicationEntries      = 100000
+	DeduplicationCleanupInterval = 30 * time.Second
+
+	// Packet Lifecycle Configuration
+	MaxTrackedPacketStatuses = 10000 // least recently updated packets are evicted first
+	MaxPacketTransitions     = 20    // per packet; the first transition is always kept
+
+	// Result Streaming Configuration
+	MaxResultSubscribers     = 100
+	ResultSubscriberBuffer   = 256 // results buffered per subscriber before new ones are dropped
+	ResultStreamHeartbeat    = 15 * time.Second
+	ResultStreamWriteTimeout = 10 * time.Second
+
 	// Retry Configuration
 	MaxRetries         = 3
 	BaseRetryDelay     = 2 * time.Second
 	RetryBackoffFactor = 2
 
+	// Dead Letter Replay Configuration
+	DefaultReplayRate = 100.0            // packets per second when the request sets no rate
+	MaxReplayDuration = 20 * time.Second // a single replay call stops here; the rest remain for the next call
+
+	// Dead Letter Store Configuration
+	DeadLetterDir             = "dead_letters"
+	DeadLetterSegmentMaxBytes = 8 * 1024 * 1024 // the active segment rotates at this size
+	DeadLetterSegmentMaxAge   = 24 * time.Hour  // or once it is this old
+	DeadLetterMaxSegments     = 30              // oldest segments are deleted beyond this
+	DefaultDeadLetterPageSize = 100
+	MaxDeadLetterPageSize     = 1000
+
+	// Tracing Configuration
+	DefaultTracesFile = "traces.jsonl" // file exporter output, one JSON span per line
+
 	// Failure Simulation
 	AnalyzerFailureRate = 0.05 // 5% failure rate
 	HealthFailureRate   = 0.05 // 5% unhealthy rate
 
 	// File Paths
 	StateFilePath  = "distributor_state.json"
-	DeadLetterFile = "failed_packets.json"
+	DeadLetterFile = "failed_packets.json" // legacy JSON array, imported into DeadLetterDir on startup
 
 	// Validation
 	MaxPacketSizeBytes    = 1024 * 1024 // 1MB per packet
@@ -41,7 +141,12 @@ const (
 	MinWeight             = 0.0
 	MaxWeight             = 1.0
 	MaxAnalyzerNameLength = 100
+	MaxAnalyzerIDLength   = 64
 	MaxLogMessageLength   = 10000
+	MaxStreamLineBytes    = 2 * MaxPacketSizeBytes // one NDJSON packet, allowing for JSON overhead
+
+	// Decompressed request bodies are capped to stop compression bombs
+	MaxDecompressedBodyBytes = 16 * MaxPacketSizeBytes
 )
 
 // AnalyzerConfig represents default analyzer configurations
@@ -50,6 +155,7 @@ type AnalyzerConfig struct {
 	Name             string
 	Weight           float64
 	ProcessingTimeMs int
+	MaxInFlight      int // packets processed at once; 0 uses DefaultAnalyzerMaxInFlight
 }
 
 // GetDefaultAnalyzers returns the default analyzer configurations
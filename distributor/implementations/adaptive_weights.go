@@ -0,0 +1,141 @@
+package implementations
+
+import (
+	"fmt"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AdaptiveWeightConfig controls how adaptive weighted round robin scales analyzer weights by error rate.
+// Floor, Ceiling and RecoveryStep are multiples of an analyzer's configured weight.
+type AdaptiveWeightConfig struct {
+	Window             time.Duration // error rates are measured over this sliding window
+	Interval           time.Duration // weights are adjusted at most this often
+	MinResults         int64         // fewer results in the window leave the weight unchanged
+	TolerableErrorRate float64       // error rates up to this keep the full weight
+	Floor              float64
+	Ceiling            float64
+	RecoveryStep       float64 // the most a weight rises per adjustment; it falls at once
+}
+
+// weightSample is an analyzer's cumulative result counters at one point in time
+type weightSample struct {
+	at        time.Time
+	processed int64
+	errors    int64
+}
+
+// adaptiveWeight is the sliding window behind an analyzer's effective weight and the last change to it
+type adaptiveWeight struct {
+	samples   []weightSample
+	errorRate float64
+	reason    string
+	changedAt time.Time
+}
+
+// reasonConfigured explains an effective weight that has not been adjusted
+const reasonConfigured = "configured weight"
+
+// NewAdaptiveLoadBalancer creates a weighted round robin load balancer whose effective weights
+// fall as an analyzer's error rate rises and recover gradually as it succeeds again
+func NewAdaptiveLoadBalancer(cfg AdaptiveWeightConfig, logger *zap.Logger) interfaces.LoadBalancer {
+	lb := newWeightedLoadBalancer(logger)
+	lb.adaptive = &cfg
+	lb.adaptiveWeights = make(map[string]*adaptiveWeight)
+	return lb
+}
+
+// adjustWeights samples every analyzer's counters and moves its effective weight towards the one its
+// error rate earns, once per interval; callers hold lb.mu
+func (lb *WeightedLoadBalancer) adjustWeights(now time.Time) {
+	if lb.adaptive == nil || now.Sub(lb.lastAdjustment) < lb.adaptive.Interval {
+		return
+	}
+	lb.lastAdjustment = now
+
+	for id, analyzer := range lb.analyzers {
+		lb.adjustWeight(id, analyzer, now)
+	}
+}
+
+// adjustWeight updates one analyzer's error rate and effective weight; callers hold lb.mu
+func (lb *WeightedLoadBalancer) adjustWeight(id string, analyzer *models.Analyzer, now time.Time) {
+	cfg := lb.adaptive
+	state := lb.adaptiveWeights[id]
+
+	// The oldest kept sample is the newest one at least a window old, so the window is always covered
+	latest := weightSample{at: now, processed: analyzer.GetProcessedCount(), errors: analyzer.GetErrorCount()}
+	state.samples = append(state.samples, latest)
+	for len(state.samples) > 1 && now.Sub(state.samples[1].at) >= cfg.Window {
+		state.samples = state.samples[1:]
+	}
+
+	// ProcessedCount only counts successes, so the window's results are both counters together
+	baseline := state.samples[0]
+	succeeded := latest.processed - baseline.processed
+	failed := latest.errors - baseline.errors
+	if succeeded+failed < cfg.MinResults {
+		return
+	}
+	state.errorRate = float64(failed) / float64(succeeded+failed)
+
+	configured := lb.originalWeights[id]
+	excess := math.Max(0, state.errorRate-cfg.TolerableErrorRate) / (1 - cfg.TolerableErrorRate)
+	target := math.Min(math.Max(configured*(1-excess), configured*cfg.Floor), configured*cfg.Ceiling)
+
+	current := lb.effectiveWeights[id]
+	next := target
+	if target > current {
+		next = math.Min(target, current+configured*cfg.RecoveryStep)
+	}
+	if next == current {
+		return
+	}
+
+	errors := fmt.Sprintf("%.0f%% errors over %s", state.errorRate*100, cfg.Window)
+	switch {
+	case next < current && next == configured*cfg.Floor:
+		state.reason = "lowered to floor: " + errors
+	case next < current:
+		state.reason = "lowered: " + errors
+	case next == configured*cfg.Ceiling:
+		state.reason = "recovered: " + errors
+	default:
+		state.reason = "recovering: " + errors
+	}
+	state.changedAt = now
+	lb.effectiveWeights[id] = next
+
+	lb.logger.Info("Analyzer weight adjusted",
+		zap.String("analyzer", id),
+		zap.Float64("configured_weight", configured),
+		zap.Float64("effective_weight", next),
+		zap.String("reason", state.reason),
+	)
+}
+
+// EffectiveWeights returns the weight each analyzer is balanced by and why, or nil unless weights are adaptive
+func (lb *WeightedLoadBalancer) EffectiveWeights() map[string]models.EffectiveWeight {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if lb.adaptive == nil {
+		return nil
+	}
+	lb.adjustWeights(time.Now())
+
+	weights := make(map[string]models.EffectiveWeight, len(lb.adaptiveWeights))
+	for id, state := range lb.adaptiveWeights {
+		weights[id] = models.EffectiveWeight{
+			Weight:    lb.effectiveWeights[id],
+			ErrorRate: state.errorRate,
+			Reason:    state.reason,
+			ChangedAt: state.changedAt,
+		}
+	}
+	return weights
+}
@@ -0,0 +1,120 @@
+package implementations
+
+import (
+	"context"
+	"logs-distributor/models"
+	"sync"
+)
+
+// bulkhead bounds the packets one analyzer processes at once and the packets waiting for it.
+// Packets are admitted at selection and hold their admission until they finish, so a full
+// bulkhead is skipped by selection rather than piling up goroutines.
+type bulkhead struct {
+	limit     int // packets processing at once
+	queueSize int // packets waiting once the limit is reached
+	admitted  int // processing plus waiting
+	active    int
+	waiters   []chan struct{} // FIFO; closed when handed a slot
+	mu        sync.Mutex
+}
+
+// newBulkhead creates a bulkhead for an analyzer
+func newBulkhead(limit, queueSize int) *bulkhead {
+	return &bulkhead{limit: limit, queueSize: queueSize}
+}
+
+// hasSlot reports whether an admitted packet would start processing at once
+func (b *bulkhead) hasSlot() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.admitted < b.limit
+}
+
+// hasRoom reports whether an admitted packet would at least fit in the queue
+func (b *bulkhead) hasRoom() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.admitted < b.limit+b.queueSize
+}
+
+// admit counts a selected packet against the bulkhead until it is released or gives up waiting
+func (b *bulkhead) admit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.admitted++
+}
+
+// acquire waits for a processing slot in arrival order. It returns false, giving up the
+// admission, if ctx is done first.
+func (b *bulkhead) acquire(ctx context.Context) bool {
+	b.mu.Lock()
+	if b.active < b.limit && len(b.waiters) == 0 {
+		b.active++
+		b.mu.Unlock()
+		return true
+	}
+	slot := make(chan struct{})
+	b.waiters = append(b.waiters, slot)
+	b.mu.Unlock()
+
+	select {
+	case <-slot:
+		return true
+	case <-ctx.Done():
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, waiter := range b.waiters {
+		if waiter == slot {
+			b.waiters = append(b.waiters[:i], b.waiters[i+1:]...)
+			b.admitted--
+			return false
+		}
+	}
+	// Handed a slot while giving up; pass it on
+	b.releaseLocked()
+	return false
+}
+
+// release frees a packet's slot and admission, handing the slot to the longest waiting packet
+func (b *bulkhead) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.releaseLocked()
+}
+
+func (b *bulkhead) releaseLocked() {
+	b.admitted--
+	b.active--
+	b.wakeLocked()
+}
+
+// wakeLocked hands free slots to waiting packets
+func (b *bulkhead) wakeLocked() {
+	for b.active < b.limit && len(b.waiters) > 0 {
+		close(b.waiters[0])
+		b.waiters = b.waiters[1:]
+		b.active++
+	}
+}
+
+// resize changes the in-flight limit; packets already processing beyond a lower limit finish
+func (b *bulkhead) resize(limit int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.limit = limit
+	b.wakeLocked()
+}
+
+// stats returns a snapshot of the bulkhead
+func (b *bulkhead) stats() models.BulkheadStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return models.BulkheadStats{
+		InFlight:    b.active,
+		Queued:      len(b.waiters),
+		MaxInFlight: b.limit,
+		QueueSize:   b.queueSize,
+	}
+}
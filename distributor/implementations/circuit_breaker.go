@@ -0,0 +1,183 @@
+package implementations
+
+import (
+	"fmt"
+	"logs-distributor/config"
+	"logs-distributor/models"
+	"time"
+)
+
+// CircuitBreakerConfig controls when an analyzer's circuit opens and how it is probed before closing again
+type CircuitBreakerConfig struct {
+	ConsecutiveFailures int           // failures in a row that open the circuit
+	FailureRatio        float64       // share of failures in a full window that opens the circuit
+	WindowSize          int           // recent results the failure ratio is measured over
+	OpenTimeout         time.Duration // how long an open circuit rejects packets before probing
+	HalfOpenProbes      int           // probe packets in flight at once; this many successes close the circuit
+}
+
+// DefaultCircuitBreakerConfig returns the circuit breaker settings from the config package
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		ConsecutiveFailures: config.CircuitConsecutiveFailures,
+		FailureRatio:        config.CircuitFailureRatio,
+		WindowSize:          config.CircuitWindowSize,
+		OpenTimeout:         config.CircuitOpenTimeout,
+		HalfOpenProbes:      config.CircuitHalfOpenProbes,
+	}
+}
+
+// circuitBreaker is the state machine of one analyzer's circuit; the HealthMonitor's lock guards it
+type circuitBreaker struct {
+	cfg   CircuitBreakerConfig
+	state models.CircuitState
+
+	consecutiveFailures int
+	window              []bool // recent results, true for failures; a ring once full
+	next                int
+	failures            int
+
+	probesInFlight int
+	probeSuccesses int
+
+	trips     int64
+	reason    string
+	changedAt time.Time
+	reopen    *time.Timer // moves an open circuit to half-open
+}
+
+// newCircuitBreaker creates a closed circuit
+func newCircuitBreaker(cfg CircuitBreakerConfig) *circuitBreaker {
+	return &circuitBreaker{cfg: cfg, state: models.CircuitClosed}
+}
+
+// allows reports whether a packet may be dispatched: always when closed, while probe slots are free when half-open
+func (c *circuitBreaker) allows() bool {
+	switch c.state {
+	case models.CircuitClosed:
+		return true
+	case models.CircuitHalfOpen:
+		return c.probesInFlight < c.cfg.HalfOpenProbes
+	default:
+		return false
+	}
+}
+
+// dispatched counts a packet sent through a half-open circuit as a probe
+func (c *circuitBreaker) dispatched() {
+	if c.state == models.CircuitHalfOpen {
+		c.probesInFlight++
+	}
+}
+
+// cancelled frees the probe slot of a packet dispatched through a half-open circuit that will have no result
+func (c *circuitBreaker) cancelled() {
+	if c.state == models.CircuitHalfOpen && c.probesInFlight > 0 {
+		c.probesInFlight--
+	}
+}
+
+// record folds in a result and reports whether it changed the circuit's state.
+// Results arriving while open came from packets dispatched before it opened and are ignored.
+func (c *circuitBreaker) record(success bool, now time.Time) bool {
+	switch c.state {
+	case models.CircuitClosed:
+		c.observe(success)
+		switch {
+		case c.consecutiveFailures >= c.cfg.ConsecutiveFailures:
+			c.open(fmt.Sprintf("%d consecutive failures", c.consecutiveFailures), now)
+		case len(c.window) == c.cfg.WindowSize && c.failureRatio() >= c.cfg.FailureRatio:
+			c.open(fmt.Sprintf("%.0f%% of the last %d results failed", c.failureRatio()*100, c.cfg.WindowSize), now)
+		default:
+			return false
+		}
+		return true
+	case models.CircuitHalfOpen:
+		if c.probesInFlight > 0 {
+			c.probesInFlight--
+		}
+		if !success {
+			c.open("probe failed", now)
+			return true
+		}
+		c.probeSuccesses++
+		if c.probeSuccesses < c.cfg.HalfOpenProbes {
+			return false
+		}
+		c.close(now)
+		return true
+	default:
+		return false
+	}
+}
+
+// observe adds a result to the consecutive failure count and the ratio window
+func (c *circuitBreaker) observe(success bool) {
+	if success {
+		c.consecutiveFailures = 0
+	} else {
+		c.consecutiveFailures++
+	}
+
+	if len(c.window) < c.cfg.WindowSize {
+		c.window = append(c.window, !success)
+	} else {
+		if c.window[c.next] {
+			c.failures--
+		}
+		c.window[c.next] = !success
+		c.next = (c.next + 1) % c.cfg.WindowSize
+	}
+	if !success {
+		c.failures++
+	}
+}
+
+// failureRatio returns the share of failures in the window
+func (c *circuitBreaker) failureRatio() float64 {
+	if len(c.window) == 0 {
+		return 0
+	}
+	return float64(c.failures) / float64(len(c.window))
+}
+
+// open stops dispatching to the analyzer; the caller schedules the move to half-open
+func (c *circuitBreaker) open(reason string, now time.Time) {
+	c.state = models.CircuitOpen
+	c.reason = reason
+	c.changedAt = now
+	c.trips++
+	c.probesInFlight = 0
+	c.probeSuccesses = 0
+}
+
+// halfOpen lets probe packets through
+func (c *circuitBreaker) halfOpen(now time.Time) {
+	c.state = models.CircuitHalfOpen
+	c.changedAt = now
+	c.probesInFlight = 0
+	c.probeSuccesses = 0
+}
+
+// close resumes normal dispatching with a fresh failure history
+func (c *circuitBreaker) close(now time.Time) {
+	c.state = models.CircuitClosed
+	c.changedAt = now
+	c.consecutiveFailures = 0
+	c.window = c.window[:0]
+	c.next = 0
+	c.failures = 0
+}
+
+// stats returns a snapshot of the circuit
+func (c *circuitBreaker) stats() models.CircuitStats {
+	return models.CircuitStats{
+		State:               c.state,
+		ConsecutiveFailures: c.consecutiveFailures,
+		FailureRatio:        c.failureRatio(),
+		ProbesInFlight:      c.probesInFlight,
+		Trips:               c.trips,
+		Reason:              c.reason,
+		ChangedAt:           c.changedAt,
+	}
+}
@@ -0,0 +1,182 @@
+package implementations
+
+import (
+	"fmt"
+	"hash/fnv"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ringNode is one of an analyzer's virtual nodes on the hash ring
+type ringNode struct {
+	hash       uint64
+	analyzerID string
+}
+
+// ConsistentHashLoadBalancer implements the LoadBalancer interface with consistent hashing and bounded loads.
+// Each analyzer owns virtual nodes in proportion to its weight, and a key goes to the first analyzer clockwise
+// from its hash that accepts packets and is below its share of the outstanding packets times the load factor.
+//
+// Unhealthy, draining and ineligible analyzers are skipped rather than taken off the ring, so only their keys
+// move, and they move back once the analyzer recovers. The load bound moves keys of a hot analyzer to its
+// ring successors until it catches up.
+type ConsistentHashLoadBalancer struct {
+	analyzers      map[string]*models.Analyzer
+	weights        map[string]float64
+	outstanding    map[string]int
+	ring           []ringNode // sorted by hash
+	nodesPerWeight int
+	loadFactor     float64
+	logger         *zap.Logger
+	mu             sync.Mutex
+}
+
+// Ensure ConsistentHashLoadBalancer implements LoadBalancer interface
+var _ interfaces.LoadBalancer = (*ConsistentHashLoadBalancer)(nil)
+
+// NewConsistentHashLoadBalancer creates a consistent-hash load balancer. An analyzer of weight 1.0 gets
+// nodesPerWeight virtual nodes; loadFactor bounds each analyzer's outstanding packets relative to its share.
+func NewConsistentHashLoadBalancer(nodesPerWeight int, loadFactor float64, logger *zap.Logger) interfaces.LoadBalancer {
+	return &ConsistentHashLoadBalancer{
+		analyzers:      make(map[string]*models.Analyzer),
+		weights:        make(map[string]float64),
+		outstanding:    make(map[string]int),
+		nodesPerWeight: nodesPerWeight,
+		loadFactor:     loadFactor,
+		logger:         logger,
+	}
+}
+
+// AddAnalyzer places an analyzer's virtual nodes on the ring, or resizes them for a registered analyzer's new weight
+func (lb *ConsistentHashLoadBalancer) AddAnalyzer(analyzer *models.Analyzer) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.analyzers[analyzer.ID] = analyzer
+	lb.weights[analyzer.ID] = analyzer.Weight
+	lb.rebuildRing()
+}
+
+// RemoveAnalyzer takes an analyzer's virtual nodes off the ring; only its keys move to other analyzers
+func (lb *ConsistentHashLoadBalancer) RemoveAnalyzer(analyzerID string) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	delete(lb.analyzers, analyzerID)
+	delete(lb.weights, analyzerID)
+	delete(lb.outstanding, analyzerID)
+	lb.rebuildRing()
+}
+
+// rebuildRing places every analyzer's virtual nodes; an analyzer's nodes do not depend on the others,
+// so adding or removing one leaves every other key where it was
+func (lb *ConsistentHashLoadBalancer) rebuildRing() {
+	lb.ring = lb.ring[:0]
+	for id, weight := range lb.weights {
+		if weight <= 0 {
+			continue
+		}
+		nodes := int(math.Round(weight * float64(lb.nodesPerWeight)))
+		if nodes < 1 {
+			nodes = 1
+		}
+		for i := 0; i < nodes; i++ {
+			lb.ring = append(lb.ring, ringNode{hash: ringHash(fmt.Sprintf("%s#%d", id, i)), analyzerID: id})
+		}
+	}
+	sort.Slice(lb.ring, func(i, j int) bool {
+		if lb.ring[i].hash != lb.ring[j].hash {
+			return lb.ring[i].hash < lb.ring[j].hash
+		}
+		return lb.ring[i].analyzerID < lb.ring[j].analyzerID
+	})
+}
+
+// ringHash hashes a key onto the ring. FNV-1a spreads similar keys such as "a#1" and "a#2" poorly,
+// so its result is mixed with the splitmix64 finalizer.
+func ringHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// SelectAnalyzer returns the analyzer owning the key, skipping analyzers that do not accept packets, fail
+// eligible (nil allows all) or are at their load bound, and counts a packet outstanding to it until RecordCompletion
+func (lb *ConsistentHashLoadBalancer) SelectAnalyzer(key string, eligible func(analyzerID string) bool) *models.Analyzer {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	now := time.Now()
+	accepting := make(map[string]bool)
+	var totalWeight float64
+	var load int
+	for id, analyzer := range lb.analyzers {
+		if lb.weights[id] > 0 && analyzer.AcceptsPackets(now) && (eligible == nil || eligible(id)) {
+			accepting[id] = true
+			totalWeight += lb.weights[id]
+			load += lb.outstanding[id]
+		}
+	}
+	if len(accepting) == 0 {
+		return nil
+	}
+
+	hash := ringHash(key)
+	start := sort.Search(len(lb.ring), func(i int) bool { return lb.ring[i].hash >= hash })
+
+	// The load bounds leave room for one more packet in total, so some accepting analyzer is always below its bound
+	var owner, selected string
+	visited := make(map[string]bool, len(accepting))
+	for i := 0; i < len(lb.ring) && len(visited) < len(accepting); i++ {
+		id := lb.ring[(start+i)%len(lb.ring)].analyzerID
+		if !accepting[id] || visited[id] {
+			continue
+		}
+		visited[id] = true
+		if owner == "" {
+			owner = id
+		}
+
+		bound := math.Ceil(lb.loadFactor * float64(load+1) * lb.weights[id] / totalWeight)
+		if float64(lb.outstanding[id]) < bound {
+			selected = id
+			break
+		}
+	}
+	if selected == "" {
+		selected = owner
+	}
+
+	lb.outstanding[selected]++
+	return lb.analyzers[selected]
+}
+
+// RecordCompletion ends an outstanding packet; the latency is ignored
+func (lb *ConsistentHashLoadBalancer) RecordCompletion(analyzerID string, latency time.Duration) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if lb.outstanding[analyzerID] > 0 {
+		lb.outstanding[analyzerID]--
+	}
+}
+
+// UpdateWeights is a no-op; health and overrides are checked on every selection
+func (lb *ConsistentHashLoadBalancer) UpdateWeights() {}
+
+// EffectiveWeights returns nil; the configured weights are used as they are
+func (lb *ConsistentHashLoadBalancer) EffectiveWeights() map[string]models.EffectiveWeight {
+	return nil
+}
@@ -0,0 +1,524 @@
+package implementations
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	deadLetterSegmentPattern = "segment-%06d.jsonl"
+	deadLetterReplayLog      = "replayed.jsonl"
+)
+
+var errDeadLetterStoreClosed = errors.New("dead letter store closed")
+
+// deadLetterIndexEntry locates a stored entry and holds the fields it is filtered by
+type deadLetterIndexEntry struct {
+	id         string
+	segment    int
+	offset     int64
+	length     int
+	packetID   string
+	tenantID   string
+	analyzerID string
+	finalError string
+	failedAt   time.Time
+	replayedAt *time.Time
+}
+
+// deadLetterSegment is one append-only JSONL file
+type deadLetterSegment struct {
+	seq     int
+	path    string
+	size    int64
+	created time.Time
+}
+
+// replayMarker records that an entry was replayed; markers are appended to the replay log
+type replayMarker struct {
+	ID         string    `json:"id"`
+	ReplayedAt time.Time `json:"replayed_at"`
+}
+
+// SegmentedDeadLetterStore implements the DeadLetterStore interface with append-only JSONL segments.
+// Segments rotate by size and age, and the oldest are deleted beyond maxSegments. Entries are never
+// rewritten: replays are recorded in a separate append-only log, and an in-memory index rebuilt on
+// startup serves filtering and pagination.
+type SegmentedDeadLetterStore struct {
+	dir             string
+	maxSegmentBytes int64
+	maxSegmentAge   time.Duration
+	maxSegments     int
+	logger          *zap.Logger
+
+	mu        sync.Mutex
+	segments  []*deadLetterSegment   // oldest first
+	index     []deadLetterIndexEntry // append order, which is also ID order
+	active    *os.File
+	replayLog *os.File
+	closed    bool
+}
+
+// Ensure SegmentedDeadLetterStore implements DeadLetterStore interface
+var _ interfaces.DeadLetterStore = (*SegmentedDeadLetterStore)(nil)
+
+func NewDeadLetterStore(dir string, maxSegmentBytes int64, maxSegmentAge time.Duration, maxSegments int, logger *zap.Logger) (interfaces.DeadLetterStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create dead letter directory: %w", err)
+	}
+
+	s := &SegmentedDeadLetterStore{
+		dir:             dir,
+		maxSegmentBytes: maxSegmentBytes,
+		maxSegmentAge:   maxSegmentAge,
+		maxSegments:     maxSegments,
+		logger:          logger,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Append writes an entry to the active segment, rotating first if it is too large or too old
+func (s *SegmentedDeadLetterStore) Append(entry models.DeadLetterEntry) error {
+	if entry.FailedAt.IsZero() {
+		entry.FailedAt = time.Now()
+	}
+	entry.ID = ""
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errDeadLetterStoreClosed
+	}
+	if s.needsRotationLocked() {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+	}
+
+	segment := s.segments[len(s.segments)-1]
+	if _, err := s.active.Write(data); err != nil {
+		return fmt.Errorf("failed to write dead letter entry: %w", err)
+	}
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync dead letter segment: %w", err)
+	}
+
+	s.index = append(s.index, newDeadLetterIndexEntry(segment.seq, segment.size, len(data), entry))
+	segment.size += int64(len(data))
+	return nil
+}
+
+// List returns entries matching the filter, newest first, starting after the cursor.
+// An empty cursor starts from the newest entry.
+func (s *SegmentedDeadLetterStore) List(filter models.DeadLetterFilter, cursor string, limit int) (*models.DeadLetterPage, error) {
+	if limit <= 0 {
+		limit = config.DefaultDeadLetterPageSize
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := len(s.index) - 1
+	if cursor != "" {
+		if _, _, err := parseDeadLetterID(cursor); err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(s.index), func(i int) bool { return s.index[i].id >= cursor }) - 1
+	}
+
+	page := &models.DeadLetterPage{Entries: []models.DeadLetterEntry{}}
+	var matched []deadLetterIndexEntry
+	for i := start; i >= 0; i-- {
+		if !filter.Matches(s.index[i].summary()) {
+			continue
+		}
+		if len(matched) == limit {
+			page.NextCursor = matched[len(matched)-1].id
+			break
+		}
+		matched = append(matched, s.index[i])
+	}
+
+	files := make(map[int]*os.File)
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	for _, indexed := range matched {
+		file, ok := files[indexed.segment]
+		if !ok {
+			var err error
+			if file, err = os.Open(s.segmentPath(indexed.segment)); err != nil {
+				return nil, fmt.Errorf("failed to open dead letter segment: %w", err)
+			}
+			files[indexed.segment] = file
+		}
+
+		entry, err := readDeadLetterEntry(file, indexed)
+		if err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+
+	return page, nil
+}
+
+// MarkReplayed appends replay markers for the entries so they are excluded from later replays
+func (s *SegmentedDeadLetterStore) MarkReplayed(entryIDs []string, replayedAt time.Time) error {
+	if len(entryIDs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errDeadLetterStoreClosed
+	}
+	if s.replayLog == nil {
+		file, err := os.OpenFile(filepath.Join(s.dir, deadLetterReplayLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open dead letter replay log: %w", err)
+		}
+		s.replayLog = file
+	}
+
+	var buf strings.Builder
+	for _, id := range entryIDs {
+		data, err := json.Marshal(replayMarker{ID: id, ReplayedAt: replayedAt})
+		if err != nil {
+			return fmt.Errorf("failed to encode replay marker: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	if _, err := s.replayLog.WriteString(buf.String()); err != nil {
+		return fmt.Errorf("failed to write dead letter replay log: %w", err)
+	}
+	if err := s.replayLog.Sync(); err != nil {
+		return fmt.Errorf("failed to sync dead letter replay log: %w", err)
+	}
+
+	for _, id := range entryIDs {
+		s.markReplayedLocked(id, replayedAt)
+	}
+	return nil
+}
+
+// Close closes the active segment and replay log
+func (s *SegmentedDeadLetterStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	var errs []error
+	if s.active != nil {
+		errs = append(errs, s.active.Close())
+		s.active = nil
+	}
+	if s.replayLog != nil {
+		errs = append(errs, s.replayLog.Close())
+		s.replayLog = nil
+	}
+	return errors.Join(errs...)
+}
+
+// load rebuilds the index from the segments and replay log on disk
+func (s *SegmentedDeadLetterStore) load() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "segment-*.jsonl"))
+	if err != nil {
+		return fmt.Errorf("failed to list dead letter segments: %w", err)
+	}
+
+	for _, path := range paths {
+		var seq int
+		if _, err := fmt.Sscanf(filepath.Base(path), deadLetterSegmentPattern, &seq); err != nil {
+			s.logger.Error("Ignoring unrecognized dead letter file", zap.String("file", path))
+			continue
+		}
+		s.segments = append(s.segments, &deadLetterSegment{seq: seq, path: path})
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].seq < s.segments[j].seq })
+
+	for i, segment := range s.segments {
+		if err := s.scanSegment(segment, i == len(s.segments)-1); err != nil {
+			return err
+		}
+	}
+
+	if err := s.loadReplayLog(); err != nil {
+		return err
+	}
+
+	if len(s.segments) > 0 {
+		last := s.segments[len(s.segments)-1]
+		file, err := os.OpenFile(last.path, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open dead letter segment: %w", err)
+		}
+		s.active = file
+	}
+
+	s.logger.Info("Dead letter store loaded",
+		zap.String("dir", s.dir),
+		zap.Int("segments", len(s.segments)),
+		zap.Int("entries", len(s.index)),
+	)
+	return nil
+}
+
+// scanSegment indexes every entry in a segment. A torn final line in the active
+// segment, left by a crash mid-write, is truncated so appends stay line-aligned.
+func (s *SegmentedDeadLetterStore) scanSegment(segment *deadLetterSegment, active bool) error {
+	file, err := os.Open(segment.path)
+	if err != nil {
+		return fmt.Errorf("failed to open dead letter segment: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] != '\n' {
+			// Partial line at end of file
+			break
+		}
+		if len(line) > 0 {
+			var entry models.DeadLetterEntry
+			if jsonErr := json.Unmarshal(line, &entry); jsonErr != nil {
+				s.logger.Error("Skipping corrupt dead letter entry",
+					zap.String("file", segment.path),
+					zap.Int64("offset", offset),
+					zap.Error(jsonErr),
+				)
+			} else {
+				s.index = append(s.index, newDeadLetterIndexEntry(segment.seq, offset, len(line), entry))
+			}
+			offset += int64(len(line))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read dead letter segment: %w", err)
+		}
+	}
+	segment.size = offset
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat dead letter segment: %w", err)
+	}
+	// Creation time is not recorded, so age a reopened segment from its last write.
+	// Entry failure times would not do: imported entries can be far older than their segment.
+	segment.created = info.ModTime()
+
+	if info.Size() > offset {
+		if !active {
+			s.logger.Error("Dead letter segment ends with a partial entry", zap.String("file", segment.path))
+			return nil
+		}
+		s.logger.Error("Truncating partial dead letter entry", zap.String("file", segment.path), zap.Int64("size", offset))
+		if err := os.Truncate(segment.path, offset); err != nil {
+			return fmt.Errorf("failed to truncate dead letter segment: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadReplayLog applies replay markers to the index
+func (s *SegmentedDeadLetterStore) loadReplayLog() error {
+	file, err := os.Open(filepath.Join(s.dir, deadLetterReplayLog))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open dead letter replay log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var marker replayMarker
+		if err := json.Unmarshal(scanner.Bytes(), &marker); err != nil {
+			continue
+		}
+		// Markers for entries in deleted segments simply find nothing
+		s.markReplayedLocked(marker.ID, marker.ReplayedAt)
+	}
+	return scanner.Err()
+}
+
+// needsRotationLocked reports whether the next append needs a new segment
+func (s *SegmentedDeadLetterStore) needsRotationLocked() bool {
+	if s.active == nil {
+		return true
+	}
+	segment := s.segments[len(s.segments)-1]
+	if segment.size >= s.maxSegmentBytes {
+		return true
+	}
+	return !segment.created.IsZero() && time.Since(segment.created) >= s.maxSegmentAge
+}
+
+// rotateLocked starts a new segment and deletes the oldest ones beyond maxSegments
+func (s *SegmentedDeadLetterStore) rotateLocked() error {
+	seq := 1
+	if len(s.segments) > 0 {
+		seq = s.segments[len(s.segments)-1].seq + 1
+	}
+
+	path := s.segmentPath(seq)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create dead letter segment: %w", err)
+	}
+
+	if s.active != nil {
+		s.active.Close()
+	}
+	s.active = file
+	s.segments = append(s.segments, &deadLetterSegment{seq: seq, path: path, created: time.Now()})
+
+	for len(s.segments) > s.maxSegments {
+		oldest := s.segments[0]
+		dropped := 0
+		for dropped < len(s.index) && s.index[dropped].segment == oldest.seq {
+			dropped++
+		}
+		if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+			s.logger.Error("Failed to delete dead letter segment", zap.String("file", oldest.path), zap.Error(err))
+		}
+		s.index = s.index[dropped:]
+		s.segments = s.segments[1:]
+
+		s.logger.Warn("Deleted oldest dead letter segment to stay within retention",
+			zap.String("file", oldest.path),
+			zap.Int("entries_deleted", dropped),
+			zap.Int("max_segments", s.maxSegments),
+		)
+	}
+	return nil
+}
+
+// markReplayedLocked sets the replay time on an indexed entry
+func (s *SegmentedDeadLetterStore) markReplayedLocked(id string, replayedAt time.Time) {
+	i := sort.Search(len(s.index), func(i int) bool { return s.index[i].id >= id })
+	if i < len(s.index) && s.index[i].id == id {
+		at := replayedAt
+		s.index[i].replayedAt = &at
+	}
+}
+
+// segmentPath returns the file path of a segment
+func (s *SegmentedDeadLetterStore) segmentPath(seq int) string {
+	return filepath.Join(s.dir, fmt.Sprintf(deadLetterSegmentPattern, seq))
+}
+
+// newDeadLetterIndexEntry indexes an entry stored at offset in a segment
+func newDeadLetterIndexEntry(segment int, offset int64, length int, entry models.DeadLetterEntry) deadLetterIndexEntry {
+	return deadLetterIndexEntry{
+		// Zero padded so IDs sort in append order
+		id:         fmt.Sprintf("%06d-%012d", segment, offset),
+		segment:    segment,
+		offset:     offset,
+		length:     length,
+		packetID:   entry.Packet.ID,
+		tenantID:   entry.Packet.TenantID,
+		analyzerID: entry.AnalyzerID,
+		finalError: entry.FinalError,
+		failedAt:   entry.FailedAt,
+		replayedAt: entry.ReplayedAt,
+	}
+}
+
+// summary returns the indexed fields as an entry for filtering
+func (e deadLetterIndexEntry) summary() models.DeadLetterEntry {
+	return models.DeadLetterEntry{
+		Packet:     models.LogPacket{ID: e.packetID, TenantID: e.tenantID},
+		FinalError: e.finalError,
+		AnalyzerID: e.analyzerID,
+		FailedAt:   e.failedAt,
+		ReplayedAt: e.replayedAt,
+	}
+}
+
+// parseDeadLetterID validates a cursor or entry ID
+func parseDeadLetterID(id string) (segment int, offset int64, err error) {
+	if _, scanErr := fmt.Sscanf(id, "%06d-%012d", &segment, &offset); scanErr != nil || len(id) != 19 {
+		return 0, 0, fmt.Errorf("%w: %q", interfaces.ErrInvalidCursor, id)
+	}
+	return segment, offset, nil
+}
+
+// readDeadLetterEntry reads an indexed entry from its segment
+func readDeadLetterEntry(file *os.File, indexed deadLetterIndexEntry) (models.DeadLetterEntry, error) {
+	buf := make([]byte, indexed.length)
+	if _, err := file.ReadAt(buf, indexed.offset); err != nil {
+		return models.DeadLetterEntry{}, fmt.Errorf("failed to read dead letter entry %s: %w", indexed.id, err)
+	}
+
+	var entry models.DeadLetterEntry
+	if err := json.Unmarshal(buf, &entry); err != nil {
+		return models.DeadLetterEntry{}, fmt.Errorf("failed to parse dead letter entry %s: %w", indexed.id, err)
+	}
+	entry.ID = indexed.id
+	entry.ReplayedAt = indexed.replayedAt
+	return entry, nil
+}
+
+// ImportDeadLetterFile moves entries from a legacy JSON array dead letter file into the store
+// and renames the file so it is imported only once. A missing file imports nothing.
+func ImportDeadLetterFile(store interfaces.DeadLetterStore, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read legacy dead letter file: %w", err)
+	}
+
+	var entries []models.DeadLetterEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, fmt.Errorf("failed to parse legacy dead letter file: %w", err)
+	}
+
+	for i, entry := range entries {
+		if err := store.Append(entry); err != nil {
+			return i, err
+		}
+	}
+
+	if err := os.Rename(path, path+".imported"); err != nil {
+		return len(entries), fmt.Errorf("failed to rename legacy dead letter file: %w", err)
+	}
+	return len(entries), nil
+}
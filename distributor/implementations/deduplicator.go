@@ -0,0 +1,148 @@
+package implementations
+
+import (
+	"context"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// dedupEntry records when a key was first accepted, in insertion order
+type dedupEntry struct {
+	key        string
+	acceptedAt time.Time
+}
+
+// WindowDeduplicator implements the Deduplicator interface with a sliding time window
+type WindowDeduplicator struct {
+	logger     *zap.Logger
+	window     time.Duration
+	maxEntries int
+	mu         sync.Mutex
+	seen       map[string]time.Time
+	order      []dedupEntry // oldest first; may contain released keys, skipped on expiry
+}
+
+// Ensure WindowDeduplicator implements Deduplicator interface
+var _ interfaces.Deduplicator = (*WindowDeduplicator)(nil)
+
+func NewDeduplicator(window time.Duration, maxEntries int, logger *zap.Logger) interfaces.Deduplicator {
+	return &WindowDeduplicator{
+		logger:     logger,
+		window:     window,
+		maxEntries: maxEntries,
+		seen:       make(map[string]time.Time),
+	}
+}
+
+// Reserve records the key and returns false if it was already seen within the window
+func (d *WindowDeduplicator) Reserve(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	d.expireLocked(now)
+
+	if _, exists := d.seen[key]; exists {
+		return false
+	}
+
+	d.seen[key] = now
+	d.order = append(d.order, dedupEntry{key: key, acceptedAt: now})
+	return true
+}
+
+// Release forgets a key whose submission did not go through, so the client can retry it
+func (d *WindowDeduplicator) Release(key string) {
+	d.mu.Lock()
+	delete(d.seen, key)
+	d.mu.Unlock()
+}
+
+// Snapshot returns a copy of the keys currently inside the window for persistence
+func (d *WindowDeduplicator) Snapshot() map[string]time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expireLocked(time.Now())
+
+	entries := make(map[string]time.Time, len(d.seen))
+	for key, acceptedAt := range d.seen {
+		entries[key] = acceptedAt
+	}
+	return entries
+}
+
+// Restore loads persisted keys, dropping any that have already left the window
+func (d *WindowDeduplicator) Restore(entries map[string]time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cutoff := time.Now().Add(-d.window)
+	for key, acceptedAt := range entries {
+		if acceptedAt.After(cutoff) {
+			d.seen[key] = acceptedAt
+		}
+	}
+
+	// Rebuild insertion order from the restored timestamps
+	d.order = d.order[:0]
+	for key, acceptedAt := range d.seen {
+		d.order = append(d.order, dedupEntry{key: key, acceptedAt: acceptedAt})
+	}
+	sort.Slice(d.order, func(i, j int) bool {
+		return d.order[i].acceptedAt.Before(d.order[j].acceptedAt)
+	})
+
+	d.logger.Info("Deduplication window restored", zap.Int("keys", len(d.seen)))
+}
+
+// StartExpiry periodically drops keys that have left the window
+func (d *WindowDeduplicator) StartExpiry(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(config.DeduplicationCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				d.mu.Lock()
+				d.expireLocked(time.Now())
+				d.mu.Unlock()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// expireLocked drops entries older than the window and evicts the oldest beyond maxEntries
+func (d *WindowDeduplicator) expireLocked(now time.Time) {
+	cutoff := now.Add(-d.window)
+
+	expired := 0
+	for expired < len(d.order) {
+		entry := d.order[expired]
+		acceptedAt, exists := d.seen[entry.key]
+		// Released or re-reserved keys leave stale entries behind; skip them
+		stale := !exists || !acceptedAt.Equal(entry.acceptedAt)
+		if !stale && entry.acceptedAt.After(cutoff) && len(d.seen) <= d.maxEntries {
+			break
+		}
+		if !stale {
+			delete(d.seen, entry.key)
+		}
+		expired++
+	}
+
+	if expired > 0 {
+		d.order = append(d.order[:0], d.order[expired:]...)
+	}
+}
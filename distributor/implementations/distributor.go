@@ -2,57 +2,94 @@ package implementations
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"logs-distributor/config"
 	"logs-distributor/distributor/interfaces"
 	"logs-distributor/models"
+	"logs-distributor/tracing"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 // DistributorConfig holds the configuration for creating a Distributor
 type DistributorConfig struct {
+	Analyzers       []config.AnalyzerConfig // analyzers registered at startup
 	LoadBalancer    interfaces.LoadBalancer
+	Router          interfaces.Router
+	Hedger          interfaces.Hedger
+	Partitioner     interfaces.Partitioner
+	PacketQueue     interfaces.PacketQueue
 	HealthMonitor   interfaces.HealthMonitor
 	PersistenceMgr  interfaces.PersistenceManager
 	RetryHandler    interfaces.RetryHandler
 	PacketProcessor interfaces.PacketProcessor
 	PacketValidator interfaces.PacketValidator
+	Deduplicator    interfaces.Deduplicator
+	LifecycleStore  interfaces.LifecycleStore
+	ResultHub       interfaces.ResultHub
+	DeadLetterStore interfaces.DeadLetterStore
+	Metrics         interfaces.MetricsRecorder
+}
+
+// registeredAnalyzer tracks an analyzer's RunAnalyzer goroutine and the packets in flight to it
+type registeredAnalyzer struct {
+	analyzer *models.Analyzer
+	ctx      context.Context
+	cancel   context.CancelFunc
+	started  bool
+	inFlight sync.WaitGroup // selected packets, including those queued in the bulkhead
+	bulkhead *bulkhead
 }
 
 // Distributor implements the Distributor interface
 type Distributor struct {
-	analyzers map[string]*models.Analyzer
+	analyzers map[string]*registeredAnalyzer
 	logger    *zap.Logger
 	stats     *models.DistributorStats
 	ctx       context.Context
 	cancel    context.CancelFunc
 	wg        sync.WaitGroup
 	mu        sync.RWMutex
+	selectMu  sync.Mutex // serializes selections so half-open circuits admit only their probe limit
 	startTime time.Time
 	isRunning bool
 
 	// Injected components - now using interfaces
 	loadBalancer    interfaces.LoadBalancer
+	router          interfaces.Router
+	hedger          interfaces.Hedger
+	partitioner     interfaces.Partitioner
 	health          interfaces.HealthMonitor
 	persistence     interfaces.PersistenceManager
 	retryHandler    interfaces.RetryHandler
 	packetProcessor interfaces.PacketProcessor
 	validator       interfaces.PacketValidator
+	deduplicator    interfaces.Deduplicator
+	lifecycle       interfaces.LifecycleStore
+	resultHub       interfaces.ResultHub
+	deadLetters     interfaces.DeadLetterStore
+	metrics         interfaces.MetricsRecorder
 
-	// Channels
-	packetChannel chan models.LogPacket
+	// Queues
+	packetQueue   interfaces.PacketQueue
 	resultChannel chan models.AnalysisResult
 	retryChannel  chan models.LogPacket
 
 	// Atomic counters
 	totalPacketsReceived int64
 	totalMessagesRouted  int64
+	duplicatePackets     int64
 }
 
+// errNoHealthyAnalyzers marks selection spans that found no analyzer to dispatch to
+var errNoHealthyAnalyzers = errors.New("no healthy analyzers available")
+
 // Ensure Distributor implements Distributor interface
 var _ interfaces.Distributor = (*Distributor)(nil)
 
@@ -61,54 +98,50 @@ func NewDistributor(logger *zap.Logger, cfg *DistributorConfig) interfaces.Distr
 	ctx, cancel := context.WithCancel(context.Background())
 
 	d := &Distributor{
-		analyzers:     make(map[string]*models.Analyzer),
+		analyzers:     make(map[string]*registeredAnalyzer),
 		logger:        logger,
 		ctx:           ctx,
 		cancel:        cancel,
 		startTime:     time.Now(),
-		packetChannel: make(chan models.LogPacket, config.PacketChannelBuffer),
+		packetQueue:   cfg.PacketQueue,
 		resultChannel: make(chan models.AnalysisResult, config.ResultChannelBuffer),
 		retryChannel:  make(chan models.LogPacket, config.RetryChannelBuffer),
-		stats: &models.DistributorStats{
-			AnalyzerStats: make(map[string]*models.Analyzer),
-		},
+		stats:         &models.DistributorStats{},
 		// Injected dependencies
 		loadBalancer:    cfg.LoadBalancer,
+		router:          cfg.Router,
+		hedger:          cfg.Hedger,
+		partitioner:     cfg.Partitioner,
 		health:          cfg.HealthMonitor,
 		persistence:     cfg.PersistenceMgr,
 		retryHandler:    cfg.RetryHandler,
 		packetProcessor: cfg.PacketProcessor,
 		validator:       cfg.PacketValidator,
+		deduplicator:    cfg.Deduplicator,
+		lifecycle:       cfg.LifecycleStore,
+		resultHub:       cfg.ResultHub,
+		deadLetters:     cfg.DeadLetterStore,
+		metrics:         cfg.Metrics,
 	}
 
 	// Initialize analyzers
-	if err := d.initializeAnalyzers(); err != nil {
+	if err := d.initializeAnalyzers(cfg.Analyzers); err != nil {
 		logger.Fatal("CRITICAL: Failed to initialize analyzers - cannot start service", zap.Error(err))
 	}
 
 	return d
 }
 
-// initializeAnalyzers sets up default analyzers
-func (d *Distributor) initializeAnalyzers() error {
-	defaultConfigs := config.GetDefaultAnalyzers()
+// initializeAnalyzers registers the startup analyzers, falling back to the defaults
+func (d *Distributor) initializeAnalyzers(configs []config.AnalyzerConfig) error {
+	if len(configs) == 0 {
+		configs = config.GetDefaultAnalyzers()
+	}
 
-	for _, cfg := range defaultConfigs {
-		if err := d.validateAnalyzerConfig(cfg); err != nil {
-			return fmt.Errorf("invalid analyzer config %s: %w", cfg.ID, err)
+	for _, cfg := range configs {
+		if err := d.AddAnalyzer(cfg); err != nil {
+			return fmt.Errorf("analyzer %s: %w", cfg.ID, err)
 		}
-
-		analyzer := &models.Analyzer{
-			ID:               cfg.ID,
-			Name:             cfg.Name,
-			Weight:           cfg.Weight,
-			ProcessingTimeMs: cfg.ProcessingTimeMs,
-			IsHealthy:        true,
-			LastHealthCheck:  time.Now(),
-		}
-
-		d.analyzers[analyzer.ID] = analyzer
-		d.stats.AnalyzerStats[analyzer.ID] = analyzer
 	}
 
 	return nil
@@ -116,6 +149,12 @@ func (d *Distributor) initializeAnalyzers() error {
 
 // validateAnalyzerConfig validates analyzer configuration
 func (d *Distributor) validateAnalyzerConfig(cfg config.AnalyzerConfig) error {
+	if cfg.ID == "" {
+		return fmt.Errorf("analyzer ID is required")
+	}
+	if len(cfg.ID) > config.MaxAnalyzerIDLength {
+		return fmt.Errorf("ID length %d exceeds maximum %d", len(cfg.ID), config.MaxAnalyzerIDLength)
+	}
 	if cfg.Weight < config.MinWeight || cfg.Weight > config.MaxWeight {
 		return fmt.Errorf("weight %.2f must be between %.2f and %.2f", cfg.Weight, config.MinWeight, config.MaxWeight)
 	}
@@ -125,9 +164,193 @@ func (d *Distributor) validateAnalyzerConfig(cfg config.AnalyzerConfig) error {
 	if cfg.ProcessingTimeMs < 0 {
 		return fmt.Errorf("processing time cannot be negative")
 	}
+	if cfg.MaxInFlight < 0 || cfg.MaxInFlight > config.MaxAnalyzerMaxInFlight {
+		return fmt.Errorf("max in-flight %d must be between 1 and %d, or 0 for the default", cfg.MaxInFlight, config.MaxAnalyzerMaxInFlight)
+	}
+	return nil
+}
+
+// AddAnalyzer registers a new analyzer with the load balancer and health monitor.
+// Its RunAnalyzer goroutine starts immediately if the distributor is running.
+func (d *Distributor) AddAnalyzer(cfg config.AnalyzerConfig) error {
+	if err := d.validateAnalyzerConfig(cfg); err != nil {
+		return fmt.Errorf("%w: %v", interfaces.ErrInvalidAnalyzer, err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.analyzers[cfg.ID]; exists {
+		return fmt.Errorf("%w: %s", interfaces.ErrAnalyzerExists, cfg.ID)
+	}
+
+	ctx, cancel := context.WithCancel(d.ctx)
+	entry := &registeredAnalyzer{
+		analyzer: &models.Analyzer{
+			ID:               cfg.ID,
+			Name:             cfg.Name,
+			Weight:           cfg.Weight,
+			ProcessingTimeMs: cfg.ProcessingTimeMs,
+			IsHealthy:        true,
+			LastHealthCheck:  time.Now(),
+		},
+		ctx:      ctx,
+		cancel:   cancel,
+		bulkhead: newBulkhead(maxInFlight(cfg), config.AnalyzerQueueSize),
+	}
+	d.analyzers[cfg.ID] = entry
+
+	d.health.AddAnalyzer(entry.analyzer)
+	d.loadBalancer.AddAnalyzer(entry.analyzer)
+
+	if d.isRunning {
+		d.startAnalyzerLocked(entry)
+	}
+
+	d.logger.Info("Analyzer registered",
+		zap.String("analyzer", cfg.ID),
+		zap.String("name", cfg.Name),
+		zap.Float64("weight", cfg.Weight),
+		zap.Int("max_in_flight", maxInFlight(cfg)),
+	)
 	return nil
 }
 
+// maxInFlight returns an analyzer's in-flight limit, applying the default
+func maxInFlight(cfg config.AnalyzerConfig) int {
+	if cfg.MaxInFlight == 0 {
+		return config.DefaultAnalyzerMaxInFlight
+	}
+	return cfg.MaxInFlight
+}
+
+// UpdateAnalyzer changes a registered analyzer's settings, keeping its counters, health and in-flight packets.
+// The analyzer is replaced rather than modified, since processors and the API read it without holding d.mu;
+// packets already dispatched finish with the previous settings and count against the previous value.
+func (d *Distributor) UpdateAnalyzer(cfg config.AnalyzerConfig) error {
+	if err := d.validateAnalyzerConfig(cfg); err != nil {
+		return fmt.Errorf("%w: %v", interfaces.ErrInvalidAnalyzer, err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, exists := d.analyzers[cfg.ID]
+	if !exists {
+		return fmt.Errorf("%w: %s", interfaces.ErrAnalyzerNotFound, cfg.ID)
+	}
+
+	updated := &models.Analyzer{
+		ID:               cfg.ID,
+		Name:             cfg.Name,
+		Weight:           cfg.Weight,
+		ProcessingTimeMs: cfg.ProcessingTimeMs,
+		ProcessedCount:   entry.analyzer.GetProcessedCount(),
+		ErrorCount:       entry.analyzer.GetErrorCount(),
+	}
+	// The health monitor carries the health state over, since it updates it under its own lock
+	d.health.AddAnalyzer(updated)
+	entry.analyzer = updated
+	entry.bulkhead.resize(maxInFlight(cfg))
+	d.loadBalancer.AddAnalyzer(updated)
+
+	d.logger.Info("Analyzer updated",
+		zap.String("analyzer", cfg.ID),
+		zap.String("name", cfg.Name),
+		zap.Float64("weight", cfg.Weight),
+		zap.Int("max_in_flight", maxInFlight(cfg)),
+	)
+	return nil
+}
+
+// RemoveAnalyzer takes an analyzer out of rotation and returns. The packets already sent to it drain in
+// the background for up to AnalyzerDrainTimeout, then its RunAnalyzer goroutine is stopped.
+func (d *Distributor) RemoveAnalyzer(analyzerID string) error {
+	d.mu.RLock()
+	_, exists := d.analyzers[analyzerID]
+	d.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("%w: %s", interfaces.ErrAnalyzerNotFound, analyzerID)
+	}
+
+	// Stop new selections before unregistering, so dispatch never waits on a removed analyzer
+	d.loadBalancer.RemoveAnalyzer(analyzerID)
+	d.health.RemoveAnalyzer(analyzerID)
+
+	d.mu.Lock()
+	entry, exists := d.analyzers[analyzerID]
+	if !exists {
+		// Removed concurrently
+		d.mu.Unlock()
+		return fmt.Errorf("%w: %s", interfaces.ErrAnalyzerNotFound, analyzerID)
+	}
+	delete(d.analyzers, analyzerID)
+	d.mu.Unlock()
+
+	// Tracked so Stop waits for the drain, which shutdown cuts short
+	d.wg.Add(1)
+	go d.drainAnalyzer(analyzerID, entry)
+	return nil
+}
+
+// drainAnalyzer waits up to AnalyzerDrainTimeout for a removed analyzer's in-flight packets, then stops it
+func (d *Distributor) drainAnalyzer(analyzerID string, entry *registeredAnalyzer) {
+	defer d.wg.Done()
+
+	drained := make(chan struct{})
+	go func() {
+		entry.inFlight.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(config.AnalyzerDrainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		d.logger.Info("Analyzer removed", zap.String("analyzer", analyzerID))
+	case <-timer.C:
+		d.logger.Error("Analyzer removed before its in-flight packets finished",
+			zap.String("analyzer", analyzerID),
+			zap.Duration("drain_timeout", config.AnalyzerDrainTimeout),
+		)
+	case <-d.ctx.Done():
+	}
+
+	entry.cancel()
+	d.metrics.ForgetAnalyzer(analyzerID)
+}
+
+// SetAnalyzerOverride sets or clears an operator health override and rebalances weights
+func (d *Distributor) SetAnalyzerOverride(analyzerID string, override *models.HealthOverride) error {
+	if override != nil {
+		if !override.Mode.IsValid() {
+			return fmt.Errorf("%w: unknown mode %q", interfaces.ErrInvalidOverride, override.Mode)
+		}
+		if override.Mode == models.OverrideMaintenance && override.ExpiresAt == nil {
+			return fmt.Errorf("%w: maintenance requires an expiry time", interfaces.ErrInvalidOverride)
+		}
+		if override.ExpiresAt != nil && !override.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("%w: expiry time must be in the future", interfaces.ErrInvalidOverride)
+		}
+	}
+
+	if err := d.health.SetOverride(analyzerID, override); err != nil {
+		return err
+	}
+	d.loadBalancer.UpdateWeights()
+	return nil
+}
+
+// startAnalyzerLocked starts an analyzer's RunAnalyzer goroutine once; callers hold d.mu
+func (d *Distributor) startAnalyzerLocked(entry *registeredAnalyzer) {
+	if entry.started {
+		return
+	}
+	entry.started = true
+	go d.packetProcessor.RunAnalyzer(entry.ctx, &d.wg, entry.analyzer)
+}
+
 // Start begins the distributor service
 func (d *Distributor) Start() error {
 	d.mu.Lock()
@@ -146,18 +369,22 @@ func (d *Distributor) Start() error {
 	}
 
 	for i := 0; i < config.PacketWorkers; i++ {
+		d.wg.Add(2)
 		go d.processPackets()
 		go d.processResults()
 	}
 
-	go d.retryHandler.ProcessRetries(d.ctx, &d.wg, d.packetChannel)
+	go d.retryHandler.ProcessRetries(d.ctx, &d.wg, d.packetQueue)
 	go d.persistence.StartCheckpointing(d.ctx, &d.wg, d.getState)
+	d.deduplicator.StartExpiry(d.ctx, &d.wg)
 
 	d.health.Start(d.ctx, &d.wg, func() { d.loadBalancer.UpdateWeights() })
 
-	for _, analyzer := range d.getAnalyzers() {
-		go d.packetProcessor.RunAnalyzer(d.ctx, &d.wg, analyzer)
+	d.mu.Lock()
+	for _, entry := range d.analyzers {
+		d.startAnalyzerLocked(entry)
 	}
+	d.mu.Unlock()
 
 	return nil
 }
@@ -181,114 +408,710 @@ func (d *Distributor) Stop() error {
 	d.cancel()
 	d.wg.Wait()
 
+	// End live result streams now that no more results will be published
+	d.resultHub.Close()
+
+	if err := d.deadLetters.Close(); err != nil {
+		d.logger.Error("Failed to close dead letter store", zap.Error(err))
+	}
+
 	// Close channels to prevent resource leaks and signal shutdown completion
-	close(d.packetChannel)
 	close(d.resultChannel)
 	close(d.retryChannel)
 
 	return nil
 }
 
-// SubmitPacket submits a log packet for processing
-func (d *Distributor) SubmitPacket(packet models.LogPacket) error {
+// SubmitPacket submits a log packet for processing.
+// A packet whose ID or idempotency key was already accepted within the
+// deduplication window is acknowledged with ErrDuplicatePacket and not dispatched again.
+func (d *Distributor) SubmitPacket(packet models.LogPacket) (err error) {
+	ctx, span := tracing.StartPacketSpan(packet, "distributor.submit")
+	defer func() {
+		// Duplicates are acknowledged, not failed
+		if errors.Is(err, interfaces.ErrDuplicatePacket) {
+			span.SetAttributes(attribute.Bool("packet.duplicate", true))
+			span.End()
+			return
+		}
+		tracing.EndSpan(span, err)
+	}()
+
+	// Packets submitted without a trace start one here, so later stages share it
+	if packet.TraceParent == "" {
+		packet.TraceParent = tracing.TraceParent(ctx)
+	}
+
 	if err := d.validator.ValidatePacket(packet); err != nil {
-		return fmt.Errorf("packet validation failed: %w", err)
+		return fmt.Errorf("%w: %v", interfaces.ErrInvalidPacket, err)
+	}
+
+	dedupKey := packet.DedupKey()
+	if dedupKey != "" && !d.deduplicator.Reserve(dedupKey) {
+		atomic.AddInt64(&d.duplicatePackets, 1)
+		return interfaces.ErrDuplicatePacket
 	}
 
-	// Track packet for retry
-	d.retryHandler.TrackPacket(packet)
 	atomic.AddInt64(&d.totalPacketsReceived, 1)
+	d.metrics.PacketReceived()
 
-	select {
-	case d.packetChannel <- packet:
-		return nil
-	case <-time.After(config.SubmissionTimeout):
-		d.retryHandler.UntrackPacket(packet.ID)
-		return fmt.Errorf("submission timeout: queue full")
-	case <-d.ctx.Done():
-		return fmt.Errorf("distributor shutting down")
+	// Routing rules may split the packet so each analyzer only receives the messages routed to it,
+	// and affinity may split each route's messages further by key
+	var parts []models.LogPacket
+	for _, routed := range d.router.Split(packet) {
+		parts = append(parts, d.partitioner.Partition(routed)...)
+	}
+	partIDs := make([]string, len(parts))
+	for i, part := range parts {
+		partIDs[i] = part.ID
+	}
+	if len(parts) > 1 {
+		span.SetAttributes(attribute.StringSlice("packet.parts", partIDs))
+		d.lifecycle.Record(packet.TenantID, packet.ID, models.PacketTransition{
+			State:      models.PacketStateSplit,
+			RetryCount: packet.RetryCount,
+			Parts:      partIDs,
+		})
+	}
+
+	// Parts without an explicit priority take their own most severe message's
+	for i := range parts {
+		parts[i].Priority = parts[i].EffectivePriority()
+	}
+
+	// Recorded before the send so a fast worker's dispatch cannot precede it
+	for _, part := range parts {
+		d.retryHandler.TrackPacket(part)
+		d.lifecycle.Record(part.TenantID, part.ID, models.PacketTransition{
+			State:      models.PacketStateQueued,
+			RetryCount: part.RetryCount,
+		})
+	}
+
+	submitCtx, cancel := context.WithTimeout(d.ctx, config.SubmissionTimeout)
+	defer cancel()
+
+	for i, part := range parts {
+		part.EnqueuedAt = time.Now()
+		if d.packetQueue.Enqueue(submitCtx, part) {
+			continue
+		}
+
+		if d.ctx.Err() != nil {
+			for _, rejected := range parts[i:] {
+				d.recordRejected(rejected, interfaces.ErrShuttingDown)
+			}
+			d.deduplicator.Release(dedupKey)
+			return interfaces.ErrShuttingDown
+		}
+		// Parts already queued are delivered; a client retry may deliver them again
+		for _, rejected := range parts[i:] {
+			d.retryHandler.UntrackPacket(rejected.TrackingKey())
+			d.recordRejected(rejected, interfaces.ErrSubmissionTimeout)
+		}
+		d.deduplicator.Release(dedupKey)
+		return interfaces.ErrSubmissionTimeout
+	}
+	return nil
+}
+
+// recordRejected marks a packet that could not be queued
+func (d *Distributor) recordRejected(packet models.LogPacket, err error) {
+	d.lifecycle.Record(packet.TenantID, packet.ID, models.PacketTransition{
+		State:      models.PacketStateRejected,
+		RetryCount: packet.RetryCount,
+		Error:      err.Error(),
+	})
+}
+
+// GetPacketStatus returns the lifecycle status of a packet the tenant recently submitted
+func (d *Distributor) GetPacketStatus(tenantID, packetID string) (*models.PacketStatus, bool) {
+	return d.lifecycle.Get(tenantID, packetID)
+}
+
+// SubscribeResults streams analysis results matching the filter until unsubscribed
+func (d *Distributor) SubscribeResults(filter models.ResultFilter) (<-chan models.AnalysisResult, func(), error) {
+	return d.resultHub.Subscribe(filter)
+}
+
+// ListDeadLetters returns a page of dead letter entries matching the filter, newest first
+func (d *Distributor) ListDeadLetters(filter models.DeadLetterFilter, cursor string, limit int) (*models.DeadLetterPage, error) {
+	return d.deadLetters.List(filter, cursor, limit)
+}
+
+// ReplayDeadLetters resubmits unreplayed dead letter entries matching the request through SubmitPacket.
+// Retry counts are reset and the deduplication window is bypassed. Submissions are paced to the
+// request's rate, and a single call stops after MaxReplayDuration, leaving the rest for the next call.
+func (d *Distributor) ReplayDeadLetters(ctx context.Context, req models.ReplayRequest) (*models.ReplayResult, error) {
+	filter := req.DeadLetterFilter
+	notReplayed := false
+	filter.Replayed = &notReplayed
+
+	var candidates []models.DeadLetterEntry
+	cursor := ""
+	for {
+		page, err := d.deadLetters.List(filter, cursor, config.MaxDeadLetterPageSize)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, page.Entries...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	// Pages are newest first; replay oldest first
+	for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+
+	result := &models.ReplayResult{
+		DryRun:    req.DryRun,
+		Matched:   len(candidates),
+		PacketIDs: []string{},
+	}
+	if req.Limit > 0 && len(candidates) > req.Limit {
+		candidates = candidates[:req.Limit]
+	}
+
+	if req.DryRun {
+		for _, entry := range candidates {
+			result.PacketIDs = append(result.PacketIDs, entry.Packet.ID)
+		}
+		result.Replayed = len(candidates)
+		result.Remaining = result.Matched - result.Replayed
+		return result, nil
+	}
+
+	rate := req.RatePerSecond
+	if rate <= 0 {
+		rate = config.DefaultReplayRate
 	}
+	interval := time.Duration(float64(time.Second) / rate)
+	deadline := time.Now().Add(config.MaxReplayDuration)
+
+	var replayed []string
+	processed := 0
+
+replay:
+	for i, entry := range candidates {
+		if i > 0 {
+			if time.Now().Add(interval).After(deadline) {
+				break
+			}
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				break replay
+			}
+		}
+
+		packet := entry.Packet
+		packet.RetryCount = 0
+		d.deduplicator.Release(packet.DedupKey())
+
+		processed++
+		if err := d.SubmitPacket(packet); err != nil {
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[packet.ID] = err.Error()
+			result.Failed++
+			if errors.Is(err, interfaces.ErrShuttingDown) {
+				break
+			}
+			continue
+		}
+
+		replayed = append(replayed, entry.ID)
+		result.PacketIDs = append(result.PacketIDs, packet.ID)
+	}
+
+	result.Replayed = len(replayed)
+	result.Remaining = result.Matched - processed
+
+	if err := d.deadLetters.MarkReplayed(replayed, time.Now()); err != nil {
+		// The packets are already resubmitted; report the partial result with the error
+		d.logger.Error("Failed to mark dead letters as replayed", zap.Error(err))
+		return result, err
+	}
+
+	d.logger.Info("Dead letters replayed",
+		zap.Int("matched", result.Matched),
+		zap.Int("replayed", result.Replayed),
+		zap.Int("failed", result.Failed),
+		zap.Int("remaining", result.Remaining),
+	)
+	return result, nil
 }
 
 // processPackets handles incoming log packets (runs as worker pool for high throughput)
 func (d *Distributor) processPackets() {
-	d.wg.Add(1)
 	defer d.wg.Done()
 
 	for {
-		select {
-		case packet := <-d.packetChannel:
-			// Add small delay to simulate worker processing time
-			time.Sleep(100 * time.Millisecond)
-			d.distributePacket(packet)
-		case <-d.ctx.Done():
+		packet, ok := d.packetQueue.Dequeue(d.ctx)
+		if !ok {
 			return
 		}
+		if !packet.EnqueuedAt.IsZero() {
+			_, span := tracing.StartPacketSpan(packet, "distributor.queue_wait",
+				trace.WithTimestamp(packet.EnqueuedAt),
+				trace.WithAttributes(attribute.String("packet.priority", string(packet.EffectivePriority()))),
+			)
+			span.End()
+		}
+
+		// Add small delay to simulate worker processing time
+		time.Sleep(100 * time.Millisecond)
+		d.distributePacket(packet)
 	}
 }
 
-// distributePacket distributes a packet using the load balancer
+// distributePacket sends a packet to its route's analyzers using the load balancer. Only the legs the
+// delivery is missing are dispatched: every leg on the first attempt, the failed ones on a retry.
 func (d *Distributor) distributePacket(packet models.LogPacket) {
-	selectedAnalyzer := d.loadBalancer.SelectAnalyzer()
-	if selectedAnalyzer == nil {
-		d.logger.Error("No healthy analyzers available, requeueing packet", zap.String("packet_id", packet.ID))
+	delivery := d.router.Delivery(packet.Route)
+	_, span := tracing.StartPacketSpan(packet, "distributor.select_analyzer", trace.WithAttributes(
+		attribute.String("packet.route", routeName(packet.Route)),
+		attribute.String("packet.delivery", string(delivery.Mode)),
+	))
+
+	progress := d.retryHandler.DeliveryProgress(packet.TrackingKey())
+	held := make(map[string]bool)
+	for _, analyzerID := range progress.Pending {
+		held[analyzerID] = true
+	}
+	for _, analyzerID := range progress.Succeeded {
+		held[analyzerID] = true
+	}
+
+	wanted, quorum := missingLegs(delivery, len(held))
+	var selected []selectedAnalyzer
+	if wanted != 0 {
+		selected = d.selectAnalyzers(packet, held, wanted)
+	}
+	if delivery.Mode == models.DeliveryAll {
+		// Every analyzer configured on the route must succeed, including those not accepting packets right now
+		quorum = progress.Quorum
+		if quorum == 0 {
+			quorum = len(d.routeMembers(packet.Route))
+		}
+	}
+	short := quorum == 0 || len(held)+len(selected) < quorum
+
+	if len(selected) == 0 {
+		if !short {
+			// Every leg is already processing or done
+			span.End()
+			return
+		}
+		if len(held) > 0 {
+			span.End()
+			d.retryMissingLegs(packet, held, quorum)
+			return
+		}
+		tracing.EndSpan(span, errNoHealthyAnalyzers)
+		d.logger.Error("No healthy analyzers available, requeueing packet",
+			zap.String("packet_id", packet.ID),
+			zap.String("route", routeName(packet.Route)),
+		)
 		d.requeuePacketWithDelay(packet, 5*time.Second)
 		return
 	}
 
-	// Send to analyzer for processing
-	go d.sendToAnalyzer(selectedAnalyzer, packet)
+	analyzerIDs := make([]string, len(selected))
+	for i, leg := range selected {
+		analyzerIDs[i] = leg.analyzer.ID
+	}
+	span.SetAttributes(attribute.StringSlice("analyzer.ids", analyzerIDs))
+	span.End()
+
+	d.retryHandler.StartLegs(packet.TrackingKey(), quorum, analyzerIDs)
+	hedge, hedged := d.router.Hedging(packet.Route)
+	for _, leg := range selected {
+		d.lifecycle.Record(packet.TenantID, packet.ID, models.PacketTransition{
+			State:      models.PacketStateDispatched,
+			AnalyzerID: leg.analyzer.ID,
+			RetryCount: packet.RetryCount,
+		})
+
+		// Send to analyzer for processing once its bulkhead has a slot; tracked so Stop waits before closing channels
+		d.wg.Add(1)
+		if hedged {
+			go d.dispatchHedged(packet, leg, hedge)
+		} else {
+			go d.dispatch(packet, leg)
+		}
+		atomic.AddInt64(&d.totalMessagesRouted, int64(len(packet.Messages)))
+		d.metrics.PacketRouted(leg.analyzer.ID)
+	}
+
+	if short {
+		for _, leg := range selected {
+			held[leg.analyzer.ID] = true
+		}
+		d.retryMissingLegs(packet, held, quorum)
+	}
+}
+
+// routeMembers returns the registered analyzers configured on a route
+func (d *Distributor) routeMembers(route string) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	members := make(map[string]bool)
+	for id := range d.analyzers {
+		if d.router.Allows(route, id) {
+			members[id] = true
+		}
+	}
+	return sortedIDs(members)
+}
+
+// retryMissingLegs schedules a retry for the legs of a delivery that too few analyzers accepted, so they
+// are dispatched once their analyzers accept packets again. Like a failed leg, it uses up the packet's
+// retry budget, so a packet whose quorum stays out of reach is dead-lettered.
+func (d *Distributor) retryMissingLegs(packet models.LogPacket, held map[string]bool, quorum int) {
+	missing := ""
+	for _, analyzerID := range d.routeMembers(packet.Route) {
+		if !held[analyzerID] {
+			missing = analyzerID
+			break
+		}
+	}
+
+	d.logger.Warn("Too few analyzers for delivery quorum, retrying missing legs",
+		zap.String("packet_id", packet.ID),
+		zap.String("route", routeName(packet.Route)),
+		zap.Int("quorum", quorum),
+		zap.Int("legs", len(held)),
+		zap.String("missing_analyzer", missing),
+	)
+	d.retryHandler.HandleFailedPacket(failedResult(packet, missing,
+		fmt.Sprintf("only %d of %d analyzers accepted the packet", len(held), quorum)))
+}
+
+// missingLegs returns how many more analyzers a delivery needs (-1 for every eligible one)
+// and the successful legs that complete it (0 when set by the first dispatch)
+func missingLegs(delivery models.Delivery, held int) (wanted, quorum int) {
+	switch delivery.Mode {
+	case models.DeliveryAll:
+		return -1, 0
+	case models.DeliveryKOfN:
+		return delivery.FanOut - held, delivery.Quorum
+	default:
+		return 1 - held, 1
+	}
+}
+
+// selectedAnalyzer is an analyzer chosen for one leg of a delivery
+type selectedAnalyzer struct {
+	analyzer *models.Analyzer
+	bulkhead *bulkhead // admitted at selection; the packet acquires a slot before processing
+	release  func()
+}
+
+// selectAnalyzers picks up to wanted distinct analyzers on the packet's route (every one when wanted
+// is negative), skipping those that already hold a leg
+func (d *Distributor) selectAnalyzers(packet models.LogPacket, held map[string]bool, wanted int) []selectedAnalyzer {
+	bulkheads := d.bulkheads()
+	chosen := make(map[string]bool)
+	routable := func(analyzerID string) bool {
+		return !held[analyzerID] && !chosen[analyzerID] && d.router.Allows(packet.Route, analyzerID) && d.health.Allows(analyzerID)
+	}
+	// Analyzers with a free in-flight slot come first; saturated ones only while their queue has room
+	withSlot := func(analyzerID string) bool {
+		return bulkheads[analyzerID] != nil && bulkheads[analyzerID].hasSlot() && routable(analyzerID)
+	}
+	withRoom := func(analyzerID string) bool {
+		return bulkheads[analyzerID] != nil && bulkheads[analyzerID].hasRoom() && routable(analyzerID)
+	}
+
+	// Packets without an affinity key are spread by ID
+	key := packet.Affinity
+	if key == "" {
+		key = packet.ID
+	}
+
+	var selected []selectedAnalyzer
+	for wanted < 0 || len(selected) < wanted {
+		leg, ok := d.selectAnalyzer(key, withSlot)
+		if !ok {
+			leg, ok = d.selectAnalyzer(key, withRoom)
+		}
+		if !ok {
+			break
+		}
+		chosen[leg.analyzer.ID] = true
+		selected = append(selected, leg)
+	}
+	return selected
+}
+
+// bulkheads returns every registered analyzer's bulkhead. Selection filters use this snapshot
+// since they run under the load balancer's lock, which AddAnalyzer takes while holding d.mu.
+func (d *Distributor) bulkheads() map[string]*bulkhead {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	bulkheads := make(map[string]*bulkhead, len(d.analyzers))
+	for id, entry := range d.analyzers {
+		bulkheads[id] = entry.bulkhead
+	}
+	return bulkheads
+}
+
+// selectAnalyzer picks an eligible analyzer, admits a packet to its bulkhead and counts the packet in
+// flight to it, so removal waits for the packet. The leg's release must be called once the packet's
+// result is handed off.
+func (d *Distributor) selectAnalyzer(key string, eligible func(analyzerID string) bool) (selectedAnalyzer, bool) {
+	d.selectMu.Lock()
+	defer d.selectMu.Unlock()
+
+	// An analyzer removed between selection and lookup is never selected again, so one retry per analyzer suffices
+	for attempt := 0; attempt <= d.analyzerCount(); attempt++ {
+		analyzer := d.loadBalancer.SelectAnalyzer(key, eligible)
+		if analyzer == nil {
+			return selectedAnalyzer{}, false
+		}
+
+		d.mu.RLock()
+		entry, exists := d.analyzers[analyzer.ID]
+		if exists && entry.analyzer == analyzer {
+			entry.inFlight.Add(1)
+			d.mu.RUnlock()
+			entry.bulkhead.admit()
+			d.health.RecordDispatch(analyzer.ID)
+			return selectedAnalyzer{analyzer: analyzer, bulkhead: entry.bulkhead, release: entry.inFlight.Done}, true
+		}
+		d.mu.RUnlock()
+	}
+	return selectedAnalyzer{}, false
+}
+
+// analyzerCount returns the number of registered analyzers
+func (d *Distributor) analyzerCount() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.analyzers)
+}
+
+// dispatch runs one leg of a packet and hands its result to the result processor
+func (d *Distributor) dispatch(packet models.LogPacket, leg selectedAnalyzer) {
+	defer d.wg.Done()
+
+	result, completed := d.runLeg(d.ctx, leg, packet)
+	if !completed {
+		// Shutting down - fail the leg so the packet is untracked
+		d.retryHandler.HandleFailedPacket(result)
+		return
+	}
+	d.submitResult(packet, result)
+}
+
+// hedgeLeg is the outcome of one leg of a hedged packet
+type hedgeLeg struct {
+	result    models.AnalysisResult
+	completed bool
+	hedge     bool // the second leg
+}
+
+// dispatchHedged runs a packet's leg and, if it has not returned within the route's hedge delay and the
+// rate cap allows, a second leg on another analyzer of the route. The first success is handed on and
+// cancels the other leg; until then failed legs are handed on too, and the RetryHandler only retries
+// the packet once both have failed.
+func (d *Distributor) dispatchHedged(packet models.LogPacket, primary selectedAnalyzer, hedge models.HedgeConfig) {
+	defer d.wg.Done()
+
+	route := routeName(packet.Route)
+	ctx, cancel := context.WithCancel(d.ctx)
+	defer cancel()
+
+	legs := make(chan hedgeLeg, 2)
+	run := func(leg selectedAnalyzer, isHedge bool) {
+		start := time.Now()
+		result, completed := d.runLeg(ctx, leg, packet)
+		if completed {
+			d.hedger.RecordLatency(route, time.Since(start))
+		}
+		legs <- hedgeLeg{result: result, completed: completed, hedge: isHedge}
+	}
+	go run(primary, false)
+	running := 1
+
+	// A nil channel never fires, so packets are not hedged until the route has enough latencies
+	var hedgeTimer <-chan time.Time
+	if delay, ok := d.hedger.Delay(route, hedge); ok {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		hedgeTimer = timer.C
+	}
+
+	won := false
+	for running > 0 {
+		select {
+		case <-hedgeTimer:
+			hedgeTimer = nil
+			if second, ok := d.selectHedge(packet, primary.analyzer.ID); ok {
+				go run(second, true)
+				running++
+			}
+		case leg := <-legs:
+			running--
+			hedgeTimer = nil
+			switch {
+			case won:
+				// Cancelled, or finished before the cancellation reached it
+				if leg.completed && leg.result.Success {
+					d.hedger.RecordOutcome(route, models.HedgeDuplicate)
+				}
+			case !leg.completed:
+				// Shutting down - fail the leg so the packet is untracked
+				d.retryHandler.HandleFailedPacket(leg.result)
+			case leg.result.Success:
+				won = true
+				cancel()
+				if leg.hedge {
+					d.hedger.RecordOutcome(route, models.HedgeWon)
+				}
+				d.submitResult(packet, leg.result)
+			default:
+				d.submitResult(packet, leg.result)
+			}
+		}
+	}
+}
+
+// selectHedge picks a second analyzer on the packet's route for a hedged packet, if the rate cap allows,
+// and records the hedge leg
+func (d *Distributor) selectHedge(packet models.LogPacket, primaryID string) (selectedAnalyzer, bool) {
+	route := routeName(packet.Route)
+	if !d.hedger.Allow(route) {
+		return selectedAnalyzer{}, false
+	}
+	selected := d.selectAnalyzers(packet, map[string]bool{primaryID: true}, 1)
+	if len(selected) == 0 {
+		return selectedAnalyzer{}, false
+	}
+	leg := selected[0]
+
+	_, span := tracing.StartPacketSpan(packet, "distributor.hedge", trace.WithAttributes(
+		attribute.String("packet.route", route),
+		attribute.String("analyzer.id", leg.analyzer.ID),
+		attribute.String("analyzer.slow_id", primaryID),
+	))
+	span.End()
+
+	d.retryHandler.StartHedge(packet.TrackingKey(), leg.analyzer.ID)
+	d.hedger.RecordOutcome(route, models.HedgeSent)
+	d.lifecycle.Record(packet.TenantID, packet.ID, models.PacketTransition{
+		State:      models.PacketStateDispatched,
+		AnalyzerID: leg.analyzer.ID,
+		RetryCount: packet.RetryCount,
+	})
 	atomic.AddInt64(&d.totalMessagesRouted, int64(len(packet.Messages)))
+	d.metrics.PacketRouted(leg.analyzer.ID)
+	return leg, true
+}
+
+// runLeg processes a packet on a selected analyzer once its bulkhead has a slot. It reports false, with a
+// failure result, when ctx ends first; the cancelled leg counts neither for nor against the analyzer.
+func (d *Distributor) runLeg(ctx context.Context, leg selectedAnalyzer, packet models.LogPacket) (models.AnalysisResult, bool) {
+	defer leg.release()
+
+	start := time.Now()
+	if !leg.bulkhead.acquire(ctx) {
+		d.loadBalancer.RecordCompletion(leg.analyzer.ID, time.Since(start))
+		d.health.RecordCancelled(leg.analyzer.ID)
+		return failedResult(packet, leg.analyzer.ID, "queued packet interrupted by shutdown"), false
+	}
+	defer leg.bulkhead.release()
+	return d.sendToAnalyzer(ctx, leg.analyzer, packet)
 }
 
-// sendToAnalyzer sends a packet to a specific analyzer
-func (d *Distributor) sendToAnalyzer(analyzer *models.Analyzer, packet models.LogPacket) {
-	result := d.packetProcessor.ProcessPacket(analyzer, packet)
+// sendToAnalyzer sends a packet to a specific analyzer, reporting false if ctx cancelled its processing
+func (d *Distributor) sendToAnalyzer(ctx context.Context, analyzer *models.Analyzer, packet models.LogPacket) (models.AnalysisResult, bool) {
+	_, span := tracing.StartPacketSpan(packet, "analyzer.process", trace.WithAttributes(attribute.String("analyzer.id", analyzer.ID)))
+	start := time.Now()
+	result := d.packetProcessor.ProcessPacket(ctx, analyzer, packet)
+	latency := time.Since(start)
+	d.loadBalancer.RecordCompletion(analyzer.ID, latency)
+	if !result.Success && ctx.Err() != nil {
+		d.health.RecordCancelled(analyzer.ID)
+		tracing.EndSpan(span, ctx.Err())
+		return failedResult(packet, analyzer.ID, "processing interrupted by shutdown"), false
+	}
 
+	d.health.RecordResult(analyzer.ID, result.Success)
+	d.metrics.ObserveProcessing(analyzer.ID, result.Success, latency)
+	result.TraceParent = packet.TraceParent
+	result.TenantID = packet.TenantID
+	if result.Success {
+		tracing.EndSpan(span, nil)
+	} else {
+		tracing.EndSpan(span, errors.New(result.Error))
+	}
+	return result, true
+}
+
+// submitResult hands a leg's result to the result processor
+func (d *Distributor) submitResult(packet models.LogPacket, result models.AnalysisResult) {
 	select {
 	case d.resultChannel <- result:
 		// Success - result submitted
 	case <-d.ctx.Done():
 		// Shutting down - create failure result to ensure packet is untracked
-		failureResult := models.AnalysisResult{
-			PacketID:    packet.ID,
-			AnalyzerID:  analyzer.ID,
-			Success:     false,
-			ProcessedAt: time.Now(),
-			Error:       "processing interrupted by shutdown",
-		}
-		d.retryHandler.HandleFailedPacket(failureResult)
-		return
+		d.retryHandler.HandleFailedPacket(failedResult(packet, result.AnalyzerID, "processing interrupted by shutdown"))
 	case <-time.After(config.ResultTimeout):
 		// Result channel full - create failure result to trigger retry logic
+		d.retryHandler.HandleFailedPacket(failedResult(packet, result.AnalyzerID, "result channel timeout - system overloaded"))
+	}
+}
 
-		failureResult := models.AnalysisResult{
-			PacketID:    packet.ID,
-			AnalyzerID:  analyzer.ID,
-			Success:     false,
-			ProcessedAt: time.Now(),
-			Error:       "result channel timeout - system overloaded",
-		}
-		d.retryHandler.HandleFailedPacket(failureResult)
+// failedResult creates the failure result of a leg that produced no analysis result of its own
+func failedResult(packet models.LogPacket, analyzerID, reason string) models.AnalysisResult {
+	return models.AnalysisResult{
+		PacketID:    packet.ID,
+		AnalyzerID:  analyzerID,
+		Success:     false,
+		ProcessedAt: time.Now(),
+		RetryCount:  packet.RetryCount,
+		Error:       reason,
+		TraceParent: packet.TraceParent,
+		TenantID:    packet.TenantID,
 	}
 }
 
 // processResults handles analysis results
 func (d *Distributor) processResults() {
-	d.wg.Add(1)
 	defer d.wg.Done()
 
 	for {
 		select {
 		case result := <-d.resultChannel:
+			_, span := tracing.StartResultSpan(result, "distributor.handle_result")
+
 			// Add delay to simulate result processing (database writes, etc.)
 			time.Sleep(100 * time.Millisecond)
 
 			if result.Success {
-				d.retryHandler.UntrackPacket(result.PacketID)
+				d.metrics.PacketSucceeded(result.AnalyzerID)
+				// Fanned-out packets succeed once their quorum of legs has
+				if d.retryHandler.HandleSucceededPacket(result) {
+					d.lifecycle.Record(result.TenantID, result.PacketID, models.PacketTransition{
+						State:      models.PacketStateSucceeded,
+						AnalyzerID: result.AnalyzerID,
+						RetryCount: result.RetryCount,
+					})
+				}
 			} else {
 				d.retryHandler.HandleFailedPacket(result)
 			}
+
+			d.resultHub.Publish(result)
+			span.End()
 		case <-d.ctx.Done():
 			return
 		}
@@ -302,33 +1125,54 @@ func (d *Distributor) requeuePacketWithDelay(packet models.LogPacket, delay time
 
 	select {
 	case <-timer.C:
-		select {
-		case d.packetChannel <- packet:
-		case <-d.ctx.Done():
-		default:
+		packet.EnqueuedAt = time.Now()
+		if !d.packetQueue.TryEnqueue(packet) {
 			d.logger.Error("Failed to requeue packet: queue full", zap.String("packet_id", packet.ID))
 		}
 	case <-d.ctx.Done():
 	}
 }
 
+// bulkheadStats returns every analyzer's in-flight and queued packets
+func (d *Distributor) bulkheadStats() map[string]models.BulkheadStats {
+	stats := make(map[string]models.BulkheadStats)
+	for id, bulkhead := range d.bulkheads() {
+		stats[id] = bulkhead.stats()
+	}
+	return stats
+}
+
 // GetStats returns current distributor statistics
 func (d *Distributor) GetStats() *models.DistributorStats {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
 	statsCopy := *d.stats
+	statsCopy.AnalyzerStats = d.getAnalyzers()
 	statsCopy.TotalPacketsReceived = d.getTotalPacketsReceived()
 	statsCopy.TotalMessagesRouted = atomic.LoadInt64(&d.totalMessagesRouted)
+	statsCopy.DuplicatePackets = atomic.LoadInt64(&d.duplicatePackets)
+	statsCopy.ResultSubscribers = d.resultHub.SubscriberCount()
+	statsCopy.DroppedStreamResults = d.resultHub.DroppedCount()
 	statsCopy.Uptime = time.Since(d.startTime)
-	statsCopy.PacketChannelUtil = float64(len(d.packetChannel)) / float64(config.PacketChannelBuffer) * 100
+	statsCopy.PacketChannelUtil = float64(d.packetQueue.Depth()) / float64(d.packetQueue.Capacity()) * 100
 	statsCopy.ResultChannelUtil = float64(len(d.resultChannel)) / float64(config.ResultChannelBuffer) * 100
 	statsCopy.RetryChannelUtil = float64(len(d.retryChannel)) / float64(config.RetryChannelBuffer) * 100
+	statsCopy.PacketQueueDepth = d.packetQueue.Depth()
+	statsCopy.Lanes = d.packetQueue.Stats()
+	statsCopy.ResultQueueDepth = len(d.resultChannel)
+	statsCopy.RetryQueueDepth = len(d.retryChannel)
+	statsCopy.Routes = d.router.Stats()
+	statsCopy.EffectiveWeights = d.loadBalancer.EffectiveWeights()
+	statsCopy.Circuits = d.health.CircuitStats()
+	statsCopy.Bulkheads = d.bulkheadStats()
+	statsCopy.Hedging = d.hedger.Stats()
 
-	// Count active analyzers
+	// Count analyzers that can currently receive packets
 	activeCount := 0
-	for _, analyzer := range d.getAnalyzers() {
-		if analyzer.IsHealthy {
+	now := time.Now()
+	for _, analyzer := range statsCopy.AnalyzerStats {
+		if analyzer.AcceptsPackets(now) {
 			activeCount++
 		}
 	}
@@ -339,17 +1183,18 @@ func (d *Distributor) GetStats() *models.DistributorStats {
 
 // getFailedPacketsCount returns the count of failed packets
 func (d *Distributor) getFailedPacketsCount() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.retryHandler.GetFailedPacketsCount(d.getAnalyzers())
 }
 
-// getAnalyzers returns the analyzers map
+// getAnalyzers returns a copy of the registered analyzers; callers hold d.mu
 func (d *Distributor) getAnalyzers() map[string]*models.Analyzer {
-	return d.analyzers
-}
-
-// getPacketChannel returns the packet channel
-func (d *Distributor) getPacketChannel() chan models.LogPacket {
-	return d.packetChannel
+	analyzers := make(map[string]*models.Analyzer, len(d.analyzers))
+	for id, entry := range d.analyzers {
+		analyzers[id] = entry.analyzer
+	}
+	return analyzers
 }
 
 // getTotalPacketsReceived returns atomic counter value
@@ -374,6 +1219,7 @@ func (d *Distributor) getState() *models.DistributorState {
 		PendingPackets: trackedPackets,
 		LastCheckpoint: time.Now(),
 		TotalProcessed: d.getTotalPacketsReceived(),
+		DedupEntries:   d.deduplicator.Snapshot(),
 	}
 }
 
@@ -384,23 +1230,33 @@ func (d *Distributor) recoverFromState(state *models.DistributorState) {
 
 	// Restore analyzers
 	for id, analyzer := range state.Analyzers {
-		if existing, ok := d.getAnalyzers()[id]; ok {
+		if entry, ok := d.analyzers[id]; ok {
+			existing := entry.analyzer
 			atomic.AddInt64(&existing.ProcessedCount, analyzer.ProcessedCount)
 			atomic.AddInt64(&existing.ErrorCount, analyzer.ErrorCount)
 			existing.LastHealthCheck = analyzer.LastHealthCheck
+			if analyzer.Override.ActiveMode(time.Now()) != "" {
+				d.health.SetOverride(id, analyzer.Override)
+			}
 		}
 	}
 
+	// Restore the deduplication window so client retries across a restart are still recognized
+	d.deduplicator.Restore(state.DedupEntries)
+
 	// Restore tracked packets
 	restoredCount := 0
 	for _, packet := range state.PendingPackets {
 		d.retryHandler.TrackPacket(packet)
+		d.lifecycle.Record(packet.TenantID, packet.ID, models.PacketTransition{
+			State:      models.PacketStateQueued,
+			RetryCount: packet.RetryCount,
+		})
 
-		select {
-		case d.packetChannel <- packet:
+		if d.packetQueue.TryEnqueue(packet) {
 			restoredCount++
-		default:
-			d.logger.Info("Channel full during recovery, packet will be retried",
+		} else {
+			d.logger.Info("Queue full during recovery, packet will be retried",
 				zap.String("packet_id", packet.ID))
 		}
 	}
@@ -2,6 +2,7 @@ package implementations
 
 import (
 	"context"
+	"fmt"
 	"logs-distributor/config"
 	"logs-distributor/distributor/interfaces"
 	"logs-distributor/models"
@@ -12,24 +13,197 @@ import (
 	"go.uber.org/zap"
 )
 
-// HealthMonitor implements the HealthMonitor interface
+// HealthMonitor implements the HealthMonitor interface with periodic health checks
+// and a circuit breaker per analyzer fed by packet results
 type HealthMonitor struct {
-	analyzers map[string]*models.Analyzer
-	logger    *zap.Logger
+	analyzers      map[string]*models.Analyzer
+	breakers       map[string]*circuitBreaker
+	breakerConfig  CircuitBreakerConfig
+	onHealthChange func() // set by Start; called without holding mu
+	logger         *zap.Logger
+	mu             sync.Mutex
 }
 
 // Ensure HealthMonitor implements HealthMonitor interface
 var _ interfaces.HealthMonitor = (*HealthMonitor)(nil)
 
-func NewHealthMonitor(analyzers map[string]*models.Analyzer, logger *zap.Logger) interfaces.HealthMonitor {
+func NewHealthMonitor(breakerConfig CircuitBreakerConfig, logger *zap.Logger) interfaces.HealthMonitor {
 	return &HealthMonitor{
-		analyzers: analyzers,
-		logger:    logger,
+		analyzers:     make(map[string]*models.Analyzer),
+		breakers:      make(map[string]*circuitBreaker),
+		breakerConfig: breakerConfig,
+		logger:        logger,
 	}
 }
 
+// AddAnalyzer starts health checking an analyzer; a re-added analyzer keeps its circuit,
+// and one replacing a registered analyzer with the same ID takes over its health and override
+func (h *HealthMonitor) AddAnalyzer(analyzer *models.Analyzer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if previous, exists := h.analyzers[analyzer.ID]; exists {
+		analyzer.IsHealthy = previous.IsHealthy
+		analyzer.LastHealthCheck = previous.LastHealthCheck
+		analyzer.Override = previous.Override
+	}
+	h.analyzers[analyzer.ID] = analyzer
+	if _, exists := h.breakers[analyzer.ID]; !exists {
+		h.breakers[analyzer.ID] = newCircuitBreaker(h.breakerConfig)
+	}
+}
+
+// RemoveAnalyzer stops health checking an analyzer
+func (h *HealthMonitor) RemoveAnalyzer(analyzerID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.analyzers, analyzerID)
+	if breaker, exists := h.breakers[analyzerID]; exists {
+		if breaker.reopen != nil {
+			breaker.reopen.Stop()
+		}
+		delete(h.breakers, analyzerID)
+	}
+}
+
+// Allows reports whether the analyzer's circuit lets a packet through. A forced-healthy override bypasses the circuit.
+func (h *HealthMonitor) Allows(analyzerID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	breaker, exists := h.breakers[analyzerID]
+	if !exists {
+		return true
+	}
+	if h.analyzers[analyzerID].Override.ActiveMode(time.Now()) == models.OverrideForcedHealthy {
+		return true
+	}
+	return breaker.allows()
+}
+
+// RecordDispatch counts a packet sent to the analyzer, which is a probe while its circuit is half-open
+func (h *HealthMonitor) RecordDispatch(analyzerID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if breaker, exists := h.breakers[analyzerID]; exists {
+		breaker.dispatched()
+	}
+}
+
+// RecordResult feeds a packet result to the analyzer's circuit, opening it after too many failures
+// and closing it once enough probes succeed
+func (h *HealthMonitor) RecordResult(analyzerID string, success bool) {
+	h.mu.Lock()
+	breaker, exists := h.breakers[analyzerID]
+	if !exists || !breaker.record(success, time.Now()) {
+		h.mu.Unlock()
+		return
+	}
+	if breaker.state == models.CircuitOpen {
+		h.scheduleHalfOpen(analyzerID, breaker)
+	}
+	stats := breaker.stats()
+	h.mu.Unlock()
+
+	h.circuitChanged(analyzerID, stats)
+}
+
+// RecordCancelled ends a dispatch that was cancelled before its result, freeing its probe slot without
+// counting it for or against the analyzer
+func (h *HealthMonitor) RecordCancelled(analyzerID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if breaker, exists := h.breakers[analyzerID]; exists {
+		breaker.cancelled()
+	}
+}
+
+// scheduleHalfOpen moves an open circuit to half-open after the open timeout; callers hold h.mu
+func (h *HealthMonitor) scheduleHalfOpen(analyzerID string, breaker *circuitBreaker) {
+	breaker.reopen = time.AfterFunc(h.breakerConfig.OpenTimeout, func() {
+		h.mu.Lock()
+		if h.breakers[analyzerID] != breaker || breaker.state != models.CircuitOpen {
+			h.mu.Unlock()
+			return
+		}
+		breaker.halfOpen(time.Now())
+		stats := breaker.stats()
+		h.mu.Unlock()
+
+		h.circuitChanged(analyzerID, stats)
+	})
+}
+
+// circuitChanged logs a circuit transition and reports it like a health change.
+// Callers must not hold h.mu: the callback rebalances the load balancer, whose selections call Allows.
+func (h *HealthMonitor) circuitChanged(analyzerID string, stats models.CircuitStats) {
+	if stats.State == models.CircuitOpen {
+		h.logger.Warn("Analyzer circuit opened",
+			zap.String("analyzer", analyzerID),
+			zap.String("reason", stats.Reason),
+			zap.Int64("trips", stats.Trips),
+		)
+	} else {
+		h.logger.Info("Analyzer circuit changed",
+			zap.String("analyzer", analyzerID),
+			zap.String("state", string(stats.State)),
+		)
+	}
+
+	h.mu.Lock()
+	onHealthChange := h.onHealthChange
+	h.mu.Unlock()
+	if onHealthChange != nil {
+		onHealthChange()
+	}
+}
+
+// CircuitStats returns the state of every analyzer's circuit
+func (h *HealthMonitor) CircuitStats() map[string]models.CircuitStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := make(map[string]models.CircuitStats, len(h.breakers))
+	for id, breaker := range h.breakers {
+		stats[id] = breaker.stats()
+	}
+	return stats
+}
+
+// SetOverride sets or, when override is nil, clears an operator override on an analyzer.
+// Forced modes take effect on the analyzer's health immediately.
+func (h *HealthMonitor) SetOverride(analyzerID string, override *models.HealthOverride) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	analyzer, exists := h.analyzers[analyzerID]
+	if !exists {
+		return fmt.Errorf("%w: %s", interfaces.ErrAnalyzerNotFound, analyzerID)
+	}
+
+	analyzer.Override = override
+	h.applyForcedHealth(analyzer, time.Now())
+
+	if override == nil {
+		h.logger.Info("Analyzer override cleared", zap.String("analyzer", analyzerID))
+	} else {
+		h.logger.Info("Analyzer override set",
+			zap.String("analyzer", analyzerID),
+			zap.String("mode", string(override.Mode)),
+			zap.String("reason", override.Reason),
+			zap.Timep("expires_at", override.ExpiresAt),
+		)
+	}
+	return nil
+}
+
 // Start begins health monitoring
 func (h *HealthMonitor) Start(ctx context.Context, wg *sync.WaitGroup, onHealthChange func()) {
+	h.mu.Lock()
+	h.onHealthChange = onHealthChange
+	h.mu.Unlock()
+
 	go h.healthChecker(ctx, wg, onHealthChange)
 }
 
@@ -55,14 +229,30 @@ func (h *HealthMonitor) healthChecker(ctx context.Context, wg *sync.WaitGroup, o
 
 // checkAnalyzerHealth checks and updates analyzer health status
 func (h *HealthMonitor) checkAnalyzerHealth() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	healthChanged := false
+	now := time.Now()
 
 	for _, analyzer := range h.analyzers {
+		if analyzer.Override.Expired(now) {
+			h.logger.Info("Analyzer override expired",
+				zap.String("analyzer", analyzer.ID),
+				zap.String("mode", string(analyzer.Override.Mode)),
+			)
+			analyzer.Override = nil
+			healthChanged = true
+		}
+
 		oldHealth := analyzer.IsHealthy
 
-		// Simulate health check
-		analyzer.IsHealthy = rand.Float64() > config.HealthFailureRate
-		analyzer.LastHealthCheck = time.Now()
+		// Forced overrides take precedence over the health check
+		if !h.applyForcedHealth(analyzer, now) {
+			// Simulate health check
+			analyzer.IsHealthy = rand.Float64() > config.HealthFailureRate
+			analyzer.LastHealthCheck = now
+		}
 
 		if oldHealth != analyzer.IsHealthy {
 			healthChanged = true
@@ -77,3 +267,17 @@ func (h *HealthMonitor) checkAnalyzerHealth() bool {
 
 	return healthChanged
 }
+
+// applyForcedHealth sets the health of an analyzer under a forced override, reporting whether one applied
+func (h *HealthMonitor) applyForcedHealth(analyzer *models.Analyzer, now time.Time) bool {
+	switch analyzer.Override.ActiveMode(now) {
+	case models.OverrideForcedHealthy:
+		analyzer.IsHealthy = true
+	case models.OverrideForcedUnhealthy:
+		analyzer.IsHealthy = false
+	default:
+		return false
+	}
+	analyzer.LastHealthCheck = now
+	return true
+}
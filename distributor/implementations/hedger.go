@@ -0,0 +1,139 @@
+package implementations
+
+import (
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// routeHedging is the latency window, hedge budget and counters of one hedged route
+type routeHedging struct {
+	latencies []time.Duration // recent latencies; a ring once full
+	next      int
+	budget    float64 // hedges the route may send now
+	delay     time.Duration
+	stats     models.HedgeStats
+}
+
+// LatencyHedger implements the Hedger interface. A route's hedge delay is a percentile of its recent
+// leg latencies, and every packet on the route earns max_rate of a hedge, so at most that share of
+// its packets is hedged once the saved-up budget is spent.
+type LatencyHedger struct {
+	routes       map[string]*routeHedging
+	window       int
+	minLatencies int
+	burst        float64
+	mu           sync.Mutex
+}
+
+// Ensure LatencyHedger implements Hedger interface
+var _ interfaces.Hedger = (*LatencyHedger)(nil)
+
+// NewHedger creates a hedger keeping window latencies per route. Routes are not hedged until they have
+// minLatencies latencies, and save up at most burst hedges.
+func NewHedger(window, minLatencies, burst int) interfaces.Hedger {
+	return &LatencyHedger{
+		routes:       make(map[string]*routeHedging),
+		window:       window,
+		minLatencies: minLatencies,
+		burst:        float64(burst),
+	}
+}
+
+// route returns a route's hedging state, creating it on first use; callers hold h.mu
+func (h *LatencyHedger) route(name string) *routeHedging {
+	route, exists := h.routes[name]
+	if !exists {
+		route = &routeHedging{}
+		h.routes[name] = route
+	}
+	return route
+}
+
+// Delay counts a packet dispatched on the route towards its hedge budget and returns how long its
+// analyzer may take before the packet is hedged. It reports false until the route has enough latencies.
+func (h *LatencyHedger) Delay(name string, cfg models.HedgeConfig) (time.Duration, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	route := h.route(name)
+	route.stats.Packets++
+	route.budget = math.Min(route.budget+cfg.MaxRate, h.burst)
+
+	if len(route.latencies) == 0 || len(route.latencies) < h.minLatencies {
+		route.delay = 0
+		return 0, false
+	}
+	sorted := append([]time.Duration(nil), route.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(cfg.Percentile/100*float64(len(sorted)))) - 1
+	route.delay = sorted[rank]
+	if minDelay := time.Duration(cfg.MinDelayMs) * time.Millisecond; route.delay < minDelay {
+		route.delay = minDelay
+	}
+	return route.delay, true
+}
+
+// Allow spends a hedge from the route's budget, reporting false when the rate cap leaves none
+func (h *LatencyHedger) Allow(name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	route := h.route(name)
+	if route.budget < 1 {
+		route.stats.Throttled++
+		return false
+	}
+	route.budget--
+	return true
+}
+
+// RecordLatency adds the latency of a leg that finished on the route
+func (h *LatencyHedger) RecordLatency(name string, latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	route := h.route(name)
+	if len(route.latencies) < h.window {
+		route.latencies = append(route.latencies, latency)
+		return
+	}
+	route.latencies[route.next] = latency
+	route.next = (route.next + 1) % h.window
+}
+
+// RecordOutcome counts an event of a hedged packet on the route
+func (h *LatencyHedger) RecordOutcome(name string, outcome models.HedgeOutcome) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	route := h.route(name)
+	switch outcome {
+	case models.HedgeSent:
+		route.stats.Hedged++
+	case models.HedgeWon:
+		route.stats.Won++
+	case models.HedgeDuplicate:
+		route.stats.Duplicates++
+	}
+}
+
+// Stats returns the hedging counters and current delay of every route that dispatched a packet
+func (h *LatencyHedger) Stats() map[string]models.HedgeStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.routes) == 0 {
+		return nil
+	}
+	stats := make(map[string]models.HedgeStats, len(h.routes))
+	for name, route := range h.routes {
+		routeStats := route.stats
+		routeStats.DelayMs = float64(route.delay) / float64(time.Millisecond)
+		stats[name] = routeStats
+	}
+	return stats
+}
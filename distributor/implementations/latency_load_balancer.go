@@ -0,0 +1,166 @@
+package implementations
+
+import (
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// minLatencyCost keeps outstanding packets counting towards the cost of an analyzer whose estimate has faded
+	minLatencyCost = float64(time.Millisecond) / float64(time.Second)
+	// unmeasuredCost is the cost per outstanding packet of an analyzer that has not returned a result yet,
+	// so a new analyzer is tried with one packet rather than flooded until its first result
+	unmeasuredCost = float64(time.Hour) / float64(time.Second)
+)
+
+// analyzerLoad is the latency estimate and outstanding packet count of one analyzer
+type analyzerLoad struct {
+	weight      float64
+	ewma        float64 // seconds
+	measured    bool
+	updated     time.Time
+	outstanding int
+}
+
+// LatencyLoadBalancer implements the LoadBalancer interface with power of two choices:
+// it samples two eligible analyzers and picks the one with the lower latency EWMA times
+// outstanding packets, divided by the configured weight.
+//
+// The EWMA follows latency peaks immediately and decays over time otherwise, so a slow
+// analyzer stops receiving packets after its first slow result. An analyzer that receives
+// no packets has its estimate fade towards zero, so it is tried again once it recovers.
+type LatencyLoadBalancer struct {
+	analyzers map[string]*models.Analyzer
+	loads     map[string]*analyzerLoad
+	decay     time.Duration
+	random    *rand.Rand
+	logger    *zap.Logger
+	mu        sync.Mutex
+}
+
+// Ensure LatencyLoadBalancer implements LoadBalancer interface
+var _ interfaces.LoadBalancer = (*LatencyLoadBalancer)(nil)
+
+// NewLatencyLoadBalancer creates a latency-aware load balancer; decay is the time constant of the latency EWMA
+func NewLatencyLoadBalancer(decay time.Duration, logger *zap.Logger) interfaces.LoadBalancer {
+	return &LatencyLoadBalancer{
+		analyzers: make(map[string]*models.Analyzer),
+		loads:     make(map[string]*analyzerLoad),
+		decay:     decay,
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		logger:    logger,
+	}
+}
+
+// AddAnalyzer adds an analyzer to the pool, or picks up a registered analyzer's new weight
+func (lb *LatencyLoadBalancer) AddAnalyzer(analyzer *models.Analyzer) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	load, exists := lb.loads[analyzer.ID]
+	if !exists {
+		load = &analyzerLoad{updated: time.Now()}
+		lb.loads[analyzer.ID] = load
+	}
+	load.weight = analyzer.Weight
+	lb.analyzers[analyzer.ID] = analyzer
+}
+
+// RemoveAnalyzer takes an analyzer out of the pool; it is never selected once this returns
+func (lb *LatencyLoadBalancer) RemoveAnalyzer(analyzerID string) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	delete(lb.analyzers, analyzerID)
+	delete(lb.loads, analyzerID)
+}
+
+// SelectAnalyzer picks the cheaper of two random eligible analyzers and counts a packet outstanding
+// to it until RecordCompletion; the packet key is ignored. Only analyzers passing eligible are considered; nil allows all.
+func (lb *LatencyLoadBalancer) SelectAnalyzer(key string, eligible func(analyzerID string) bool) *models.Analyzer {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	now := time.Now()
+	var candidates []*models.Analyzer
+	for _, analyzer := range lb.analyzers {
+		if lb.loads[analyzer.ID].weight > 0 && analyzer.AcceptsPackets(now) && (eligible == nil || eligible(analyzer.ID)) {
+			candidates = append(candidates, analyzer)
+		}
+	}
+
+	var selected *models.Analyzer
+	switch len(candidates) {
+	case 0:
+		return nil
+	case 1:
+		selected = candidates[0]
+	default:
+		i := lb.random.Intn(len(candidates))
+		j := lb.random.Intn(len(candidates) - 1)
+		if j >= i {
+			j++
+		}
+		selected = candidates[i]
+		if lb.cost(candidates[j].ID, now) < lb.cost(selected.ID, now) {
+			selected = candidates[j]
+		}
+	}
+
+	lb.loads[selected.ID].outstanding++
+	return selected
+}
+
+// cost estimates how long a new packet would wait at an analyzer, relative to its weight
+func (lb *LatencyLoadBalancer) cost(analyzerID string, now time.Time) float64 {
+	load := lb.loads[analyzerID]
+	if !load.measured {
+		return unmeasuredCost * float64(load.outstanding) / load.weight
+	}
+	ewma := load.ewma * lb.decayFactor(now.Sub(load.updated))
+	return (ewma + minLatencyCost) * float64(load.outstanding+1) / load.weight
+}
+
+// decayFactor returns how much of the latency estimate remains after elapsed without results
+func (lb *LatencyLoadBalancer) decayFactor(elapsed time.Duration) float64 {
+	return math.Exp(-float64(elapsed) / float64(lb.decay))
+}
+
+// RecordCompletion ends an outstanding packet and folds its processing latency into the analyzer's EWMA
+func (lb *LatencyLoadBalancer) RecordCompletion(analyzerID string, latency time.Duration) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	load, exists := lb.loads[analyzerID]
+	if !exists {
+		return
+	}
+	if load.outstanding > 0 {
+		load.outstanding--
+	}
+
+	now := time.Now()
+	sample := latency.Seconds()
+	if sample > load.ewma {
+		load.ewma = sample
+	} else {
+		w := lb.decayFactor(now.Sub(load.updated))
+		load.ewma = load.ewma*w + sample*(1-w)
+	}
+	load.measured = true
+	load.updated = now
+}
+
+// UpdateWeights is a no-op; health and overrides are checked on every selection
+func (lb *LatencyLoadBalancer) UpdateWeights() {}
+
+// EffectiveWeights returns nil; the configured weights are used as they are
+func (lb *LatencyLoadBalancer) EffectiveWeights() map[string]models.EffectiveWeight {
+	return nil
+}
@@ -0,0 +1,95 @@
+package implementations
+
+import (
+	"container/list"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"sync"
+	"time"
+)
+
+// PacketLifecycleStore implements the LifecycleStore interface with a bounded, least-recently-updated eviction policy
+type PacketLifecycleStore struct {
+	mu             sync.Mutex
+	maxPackets     int
+	maxTransitions int
+	entries        map[string]*list.Element
+	order          *list.List // least recently updated first; values are *models.PacketStatus
+}
+
+// Ensure PacketLifecycleStore implements LifecycleStore interface
+var _ interfaces.LifecycleStore = (*PacketLifecycleStore)(nil)
+
+func NewLifecycleStore(maxPackets, maxTransitions int) interfaces.LifecycleStore {
+	return &PacketLifecycleStore{
+		maxPackets:     maxPackets,
+		maxTransitions: maxTransitions,
+		entries:        make(map[string]*list.Element),
+		order:          list.New(),
+	}
+}
+
+// Record appends a state transition for the tenant's packet, evicting the stalest packet when full
+func (s *PacketLifecycleStore) Record(tenantID, packetID string, transition models.PacketTransition) {
+	if transition.At.IsZero() {
+		transition.At = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := models.TenantScopedKey(tenantID, packetID)
+	var status *models.PacketStatus
+	if element, exists := s.entries[key]; exists {
+		status = element.Value.(*models.PacketStatus)
+		s.order.MoveToBack(element)
+	} else {
+		status = &models.PacketStatus{PacketID: packetID, TenantID: tenantID, CreatedAt: transition.At}
+		s.entries[key] = s.order.PushBack(status)
+		s.evictLocked()
+	}
+
+	status.State = transition.State
+	status.RetryCount = transition.RetryCount
+	status.UpdatedAt = transition.At
+	if transition.AnalyzerID != "" {
+		status.AnalyzerID = transition.AnalyzerID
+	}
+	if transition.Error != "" {
+		status.LastError = transition.Error
+	}
+	if len(transition.Parts) > 0 {
+		status.Parts = transition.Parts
+	}
+
+	status.Transitions = append(status.Transitions, transition)
+	if len(status.Transitions) > s.maxTransitions {
+		// Keep the first transition so the packet's origin stays visible
+		status.Transitions = append(status.Transitions[:1], status.Transitions[len(status.Transitions)-s.maxTransitions+1:]...)
+	}
+}
+
+// Get returns a copy of the lifecycle status of the tenant's packet
+func (s *PacketLifecycleStore) Get(tenantID, packetID string) (*models.PacketStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, exists := s.entries[models.TenantScopedKey(tenantID, packetID)]
+	if !exists {
+		return nil, false
+	}
+
+	status := *element.Value.(*models.PacketStatus)
+	status.Transitions = append([]models.PacketTransition(nil), status.Transitions...)
+	return &status, true
+}
+
+// evictLocked drops the least recently updated packets beyond maxPackets
+func (s *PacketLifecycleStore) evictLocked() {
+	for s.order.Len() > s.maxPackets {
+		oldest := s.order.Front()
+		s.order.Remove(oldest)
+		status := oldest.Value.(*models.PacketStatus)
+		delete(s.entries, models.TenantScopedKey(status.TenantID, status.PacketID))
+	}
+}
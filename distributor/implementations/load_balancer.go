@@ -1,44 +1,123 @@
 package implementations
 
 import (
+	"fmt"
+	"logs-distributor/config"
 	"logs-distributor/distributor/interfaces"
 	"logs-distributor/models"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+// LoadBalancerStrategy selects how packets are spread across analyzers
+type LoadBalancerStrategy string
+
+// Supported load balancing strategies
+const (
+	LoadBalancerWeighted LoadBalancerStrategy = "weighted" // smooth weighted round robin over the configured weights
+	LoadBalancerLatency  LoadBalancerStrategy = "latency"  // power of two choices over latency and outstanding packets
+	LoadBalancerHash     LoadBalancerStrategy = "hash"     // consistent hashing of affinity keys with bounded loads
+	LoadBalancerAdaptive LoadBalancerStrategy = "adaptive" // weighted round robin with weights scaled down by error rate
+)
+
+// NewLoadBalancerForStrategy creates the load balancer for a strategy; an empty strategy is weighted
+func NewLoadBalancerForStrategy(strategy LoadBalancerStrategy, logger *zap.Logger) (interfaces.LoadBalancer, error) {
+	switch strategy {
+	case LoadBalancerWeighted, "":
+		return NewLoadBalancer(logger), nil
+	case LoadBalancerLatency:
+		return NewLatencyLoadBalancer(config.LatencyDecayTime, logger), nil
+	case LoadBalancerHash:
+		return NewConsistentHashLoadBalancer(config.HashRingNodesPerWeight, config.HashLoadFactor, logger), nil
+	case LoadBalancerAdaptive:
+		return NewAdaptiveLoadBalancer(AdaptiveWeightConfig{
+			Window:             config.AdaptiveWeightWindow,
+			Interval:           config.AdaptiveWeightInterval,
+			MinResults:         config.AdaptiveWeightMinResults,
+			TolerableErrorRate: config.AdaptiveTolerableErrorRate,
+			Floor:              config.AdaptiveWeightFloor,
+			Ceiling:            config.AdaptiveWeightCeiling,
+			RecoveryStep:       config.AdaptiveWeightRecoveryStep,
+		}, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown load balancer %q - expected %s, %s, %s or %s", strategy,
+			LoadBalancerWeighted, LoadBalancerLatency, LoadBalancerHash, LoadBalancerAdaptive)
+	}
+}
+
 // WeightedLoadBalancer implements the LoadBalancer interface
 type WeightedLoadBalancer struct {
-	analyzers       map[string]*models.Analyzer
-	logger          *zap.Logger
-	mu              sync.RWMutex
-	currentWeights  map[string]float64
-	originalWeights map[string]float64
+	analyzers        map[string]*models.Analyzer
+	logger           *zap.Logger
+	mu               sync.RWMutex
+	currentWeights   map[string]float64
+	originalWeights  map[string]float64
+	effectiveWeights map[string]float64 // the configured weights unless adaptive
+
+	// Adaptive mode; nil keeps the configured weights
+	adaptive        *AdaptiveWeightConfig
+	adaptiveWeights map[string]*adaptiveWeight
+	lastAdjustment  time.Time
 }
 
 // Ensure WeightedLoadBalancer implements LoadBalancer interface
 var _ interfaces.LoadBalancer = (*WeightedLoadBalancer)(nil)
 
-func NewLoadBalancer(analyzers map[string]*models.Analyzer, logger *zap.Logger) interfaces.LoadBalancer {
-	lb := &WeightedLoadBalancer{
-		analyzers:       analyzers,
-		logger:          logger,
-		currentWeights:  make(map[string]float64),
-		originalWeights: make(map[string]float64),
+func NewLoadBalancer(logger *zap.Logger) interfaces.LoadBalancer {
+	return newWeightedLoadBalancer(logger)
+}
+
+// newWeightedLoadBalancer creates a weighted round robin load balancer over the configured weights
+func newWeightedLoadBalancer(logger *zap.Logger) *WeightedLoadBalancer {
+	return &WeightedLoadBalancer{
+		analyzers:        make(map[string]*models.Analyzer),
+		logger:           logger,
+		currentWeights:   make(map[string]float64),
+		originalWeights:  make(map[string]float64),
+		effectiveWeights: make(map[string]float64),
 	}
+}
+
+// AddAnalyzer adds an analyzer to the rotation, or picks up a registered analyzer's new weight
+func (lb *WeightedLoadBalancer) AddAnalyzer(analyzer *models.Analyzer) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
 
-	// Initialize weights
-	for _, analyzer := range analyzers {
+	_, exists := lb.analyzers[analyzer.ID]
+	if !exists {
 		lb.currentWeights[analyzer.ID] = analyzer.Weight
-		lb.originalWeights[analyzer.ID] = analyzer.Weight
 	}
 
-	return lb
+	// A new configured weight starts over from the full weight
+	if !exists || lb.originalWeights[analyzer.ID] != analyzer.Weight {
+		lb.effectiveWeights[analyzer.ID] = analyzer.Weight
+		if lb.adaptive != nil {
+			lb.adaptiveWeights[analyzer.ID] = &adaptiveWeight{reason: reasonConfigured}
+		}
+	}
+	lb.analyzers[analyzer.ID] = analyzer
+	lb.originalWeights[analyzer.ID] = analyzer.Weight
 }
 
-// SelectAnalyzer selects an analyzer using weighted round-robin load balancing
-func (lb *WeightedLoadBalancer) SelectAnalyzer() *models.Analyzer {
+// RemoveAnalyzer takes an analyzer out of the rotation; it is never selected once this returns
+func (lb *WeightedLoadBalancer) RemoveAnalyzer(analyzerID string) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	delete(lb.analyzers, analyzerID)
+	delete(lb.currentWeights, analyzerID)
+	delete(lb.originalWeights, analyzerID)
+	delete(lb.effectiveWeights, analyzerID)
+	if lb.adaptive != nil {
+		delete(lb.adaptiveWeights, analyzerID)
+	}
+}
+
+// SelectAnalyzer selects an analyzer using weighted round-robin load balancing; the packet key is ignored.
+// Only analyzers passing eligible are considered, so each route is balanced by its analyzers' weights; nil allows all.
+func (lb *WeightedLoadBalancer) SelectAnalyzer(key string, eligible func(analyzerID string) bool) *models.Analyzer {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
 
@@ -46,14 +125,17 @@ func (lb *WeightedLoadBalancer) SelectAnalyzer() *models.Analyzer {
 		return nil
 	}
 
+	now := time.Now()
+	lb.adjustWeights(now)
+
 	// Find healthy analyzers and calculate total weight in single pass
 	var healthyAnalyzers []*models.Analyzer
 	var totalWeight float64
 
 	for _, analyzer := range lb.analyzers {
-		if analyzer.IsHealthy {
+		if analyzer.AcceptsPackets(now) && (eligible == nil || eligible(analyzer.ID)) {
 			healthyAnalyzers = append(healthyAnalyzers, analyzer)
-			totalWeight += lb.originalWeights[analyzer.ID]
+			totalWeight += lb.effectiveWeights[analyzer.ID]
 		}
 	}
 
@@ -63,7 +145,7 @@ func (lb *WeightedLoadBalancer) SelectAnalyzer() *models.Analyzer {
 
 	// Update current weights for all healthy analyzers
 	for _, analyzer := range healthyAnalyzers {
-		lb.currentWeights[analyzer.ID] += lb.originalWeights[analyzer.ID]
+		lb.currentWeights[analyzer.ID] += lb.effectiveWeights[analyzer.ID]
 	}
 
 	// Find analyzer with highest current weight
@@ -86,15 +168,19 @@ func (lb *WeightedLoadBalancer) SelectAnalyzer() *models.Analyzer {
 	return selectedAnalyzer
 }
 
-// UpdateWeights resets weights when health changes
+// RecordCompletion is a no-op; weighted round robin only uses the configured weights
+func (lb *WeightedLoadBalancer) RecordCompletion(analyzerID string, latency time.Duration) {}
+
+// UpdateWeights resets weights when health or an operator override changes
 func (lb *WeightedLoadBalancer) UpdateWeights() {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
 
+	now := time.Now()
 	for _, analyzer := range lb.analyzers {
-		if analyzer.IsHealthy {
+		if analyzer.AcceptsPackets(now) {
 			// Reset weight for recovered analyzers
-			lb.currentWeights[analyzer.ID] = lb.originalWeights[analyzer.ID]
+			lb.currentWeights[analyzer.ID] = lb.effectiveWeights[analyzer.ID]
 		}
 	}
 }
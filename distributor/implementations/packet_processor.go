@@ -35,10 +35,24 @@ func (a *PacketProcessor) RunAnalyzer(ctx context.Context, wg *sync.WaitGroup, a
 	<-ctx.Done()
 }
 
-// ProcessPacket simulates analysis processing for embedded analyzers
-func (a *PacketProcessor) ProcessPacket(analyzer *models.Analyzer, packet models.LogPacket) models.AnalysisResult {
+// ProcessPacket simulates analysis processing for embedded analyzers.
+// A packet cancelled through ctx fails without counting against the analyzer.
+func (a *PacketProcessor) ProcessPacket(ctx context.Context, analyzer *models.Analyzer, packet models.LogPacket) models.AnalysisResult {
 	// Simulate processing time
-	time.Sleep(time.Duration(analyzer.ProcessingTimeMs) * time.Millisecond)
+	timer := time.NewTimer(time.Duration(analyzer.ProcessingTimeMs) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return models.AnalysisResult{
+			PacketID:    packet.ID,
+			AnalyzerID:  analyzer.ID,
+			Success:     false,
+			ProcessedAt: time.Now(),
+			RetryCount:  packet.RetryCount,
+			Error:       "processing cancelled",
+		}
+	}
 
 	// Simulate occasional failures
 	success := rand.Float64() > config.AnalyzerFailureRate
@@ -48,6 +62,7 @@ func (a *PacketProcessor) ProcessPacket(analyzer *models.Analyzer, packet models
 		AnalyzerID:  analyzer.ID,
 		Success:     success,
 		ProcessedAt: time.Now(),
+		RetryCount:  packet.RetryCount,
 	}
 
 	if success {
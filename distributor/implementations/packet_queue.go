@@ -0,0 +1,179 @@
+package implementations
+
+import (
+	"context"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"sync"
+	"sync/atomic"
+)
+
+// packetLane is the bounded queue of one priority
+type packetLane struct {
+	priority models.Priority
+	packets  chan models.LogPacket
+	weight   int
+	current  int   // smooth weighted round robin credit
+	dequeued int64 // atomic
+}
+
+// PriorityPacketQueue implements the PacketQueue interface with a bounded lane per priority.
+// Workers take packets from the backlogged lanes by smooth weighted round robin, so a burst of
+// low priority packets cannot delay high priority ones and low lanes still get their share.
+type PriorityPacketQueue struct {
+	lanes      []*packetLane // highest priority first
+	byPriority map[models.Priority]*packetLane
+	available  chan struct{} // one token per queued packet, so idle workers block until any lane has one
+	mu         sync.Mutex    // guards the lane credits
+}
+
+// Ensure PriorityPacketQueue implements PacketQueue interface
+var _ interfaces.PacketQueue = (*PriorityPacketQueue)(nil)
+
+// DefaultLaneWeights returns the lane weights from the config package
+func DefaultLaneWeights() map[models.Priority]int {
+	return map[models.Priority]int{
+		models.PriorityHigh:   config.HighPriorityLaneWeight,
+		models.PriorityNormal: config.NormalPriorityLaneWeight,
+		models.PriorityLow:    config.LowPriorityLaneWeight,
+	}
+}
+
+// NewPacketQueue creates a packet queue holding up to capacity packets, divided evenly across the
+// priority lanes with any remainder going to the highest. Priorities without a positive weight get a weight of 1.
+func NewPacketQueue(capacity int, weights map[models.Priority]int) interfaces.PacketQueue {
+	q := &PriorityPacketQueue{
+		byPriority: make(map[models.Priority]*packetLane, len(models.Priorities)),
+		available:  make(chan struct{}, capacity),
+	}
+	for i, priority := range models.Priorities {
+		weight := weights[priority]
+		if weight < 1 {
+			weight = 1
+		}
+		laneCapacity := capacity / len(models.Priorities)
+		if i < capacity%len(models.Priorities) {
+			laneCapacity++
+		}
+		lane := &packetLane{
+			priority: priority,
+			packets:  make(chan models.LogPacket, laneCapacity),
+			weight:   weight,
+		}
+		q.lanes = append(q.lanes, lane)
+		q.byPriority[priority] = lane
+	}
+	return q
+}
+
+// lane returns the lane of a packet; unknown priorities are queued as normal
+func (q *PriorityPacketQueue) lane(packet models.LogPacket) *packetLane {
+	if lane, exists := q.byPriority[packet.EffectivePriority()]; exists {
+		return lane
+	}
+	return q.byPriority[models.PriorityNormal]
+}
+
+// Enqueue waits for room in the packet's lane; it returns false if ctx is done first
+func (q *PriorityPacketQueue) Enqueue(ctx context.Context, packet models.LogPacket) bool {
+	select {
+	case q.lane(packet).packets <- packet:
+		q.available <- struct{}{}
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// TryEnqueue queues the packet if its lane has room
+func (q *PriorityPacketQueue) TryEnqueue(packet models.LogPacket) bool {
+	select {
+	case q.lane(packet).packets <- packet:
+		q.available <- struct{}{}
+		return true
+	default:
+		return false
+	}
+}
+
+// Dequeue waits for a packet and takes it from the lane whose turn it is; it returns false if ctx is done first
+func (q *PriorityPacketQueue) Dequeue(ctx context.Context) (models.LogPacket, bool) {
+	select {
+	case <-q.available:
+	case <-ctx.Done():
+		return models.LogPacket{}, false
+	}
+
+	// The token guarantees a packet for this worker, though another worker may take the one a lane showed
+	for {
+		for _, lane := range q.order() {
+			select {
+			case packet := <-lane.packets:
+				atomic.AddInt64(&lane.dequeued, 1)
+				return packet, true
+			default:
+			}
+		}
+	}
+}
+
+// order returns the lanes to try: the backlogged lane whose turn it is, then the rest by priority
+func (q *PriorityPacketQueue) order() []*packetLane {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var next *packetLane
+	total := 0
+	for _, lane := range q.lanes {
+		if len(lane.packets) == 0 {
+			continue
+		}
+		lane.current += lane.weight
+		total += lane.weight
+		if next == nil || lane.current > next.current {
+			next = lane
+		}
+	}
+	if next == nil {
+		return q.lanes
+	}
+	next.current -= total
+
+	order := make([]*packetLane, 0, len(q.lanes))
+	order = append(order, next)
+	for _, lane := range q.lanes {
+		if lane != next {
+			order = append(order, lane)
+		}
+	}
+	return order
+}
+
+// Depth returns the number of queued packets across all lanes
+func (q *PriorityPacketQueue) Depth() int {
+	depth := 0
+	for _, lane := range q.lanes {
+		depth += len(lane.packets)
+	}
+	return depth
+}
+
+// Capacity returns the number of packets all lanes can hold
+func (q *PriorityPacketQueue) Capacity() int {
+	return cap(q.available)
+}
+
+// Stats returns the depth, capacity, weight and dequeue count of every lane
+func (q *PriorityPacketQueue) Stats() map[models.Priority]models.LaneStats {
+	stats := make(map[models.Priority]models.LaneStats, len(q.lanes))
+	for _, lane := range q.lanes {
+		stats[lane.priority] = models.LaneStats{
+			Depth:    len(lane.packets),
+			Capacity: cap(lane.packets),
+			Weight:   lane.weight,
+			Dequeued: atomic.LoadInt64(&lane.dequeued),
+		}
+	}
+	return stats
+}
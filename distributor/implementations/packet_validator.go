@@ -26,6 +26,10 @@ func (v *PacketValidator) ValidatePacket(packet models.LogPacket) error {
 		return fmt.Errorf("packet contains %d messages, maximum allowed is %d", len(packet.Messages), config.MaxMessagesPerPacket)
 	}
 
+	if packet.Priority != "" && !packet.Priority.IsValid() {
+		return fmt.Errorf("unknown priority %q - expected %s, %s or %s", packet.Priority, models.PriorityHigh, models.PriorityNormal, models.PriorityLow)
+	}
+
 	totalSize := 0
 	for _, msg := range packet.Messages {
 		if len(msg.Message) > config.MaxLogMessageLength {
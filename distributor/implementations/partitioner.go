@@ -0,0 +1,96 @@
+package implementations
+
+import (
+	"fmt"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"strings"
+)
+
+const (
+	affinitySourceKey      = "source"
+	affinityMetadataPrefix = "metadata."
+)
+
+// AffinityPartitioner implements the Partitioner interface, keying messages by their source or a metadata value
+type AffinityPartitioner struct {
+	enabled     bool
+	metadataKey string // empty keys by source
+	mixed       models.MixedAffinity
+}
+
+// Ensure AffinityPartitioner implements Partitioner interface
+var _ interfaces.Partitioner = (*AffinityPartitioner)(nil)
+
+// NewPartitioner validates the affinity config. With no key packets are returned unchanged.
+func NewPartitioner(cfg models.AffinityConfig) (interfaces.Partitioner, error) {
+	p := &AffinityPartitioner{mixed: cfg.Mixed}
+
+	switch {
+	case cfg.Key == "":
+		return p, nil
+	case cfg.Key == affinitySourceKey:
+	case strings.HasPrefix(cfg.Key, affinityMetadataPrefix) && len(cfg.Key) > len(affinityMetadataPrefix):
+		p.metadataKey = strings.TrimPrefix(cfg.Key, affinityMetadataPrefix)
+	default:
+		return nil, fmt.Errorf("unknown affinity key %q - expected %s or %s<key>", cfg.Key, affinitySourceKey, affinityMetadataPrefix)
+	}
+
+	switch cfg.Mixed {
+	case "":
+		p.mixed = models.MixedAffinitySplit
+	case models.MixedAffinitySplit, models.MixedAffinityFirst:
+	default:
+		return nil, fmt.Errorf("unknown mixed affinity %q - expected %s or %s", cfg.Mixed, models.MixedAffinitySplit, models.MixedAffinityFirst)
+	}
+
+	p.enabled = true
+	return p, nil
+}
+
+// key returns a message's affinity key; messages without the metadata key share the empty key
+func (p *AffinityPartitioner) key(msg models.LogMessage) string {
+	if p.metadataKey == "" {
+		return msg.Source
+	}
+	value, exists := msg.Metadata[p.metadataKey]
+	if !exists {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// Partition sets a packet's affinity key. A packet whose messages share a key is returned whole; otherwise
+// it follows its first message's key or, when splitting, each key gets a part with ID "<packet ID>:key-<n>".
+func (p *AffinityPartitioner) Partition(packet models.LogPacket) []models.LogPacket {
+	if !p.enabled || len(packet.Messages) == 0 {
+		return []models.LogPacket{packet}
+	}
+
+	var order []string
+	messages := make(map[string][]models.LogMessage)
+	for _, msg := range packet.Messages {
+		key := p.key(msg)
+		if _, seen := messages[key]; !seen {
+			order = append(order, key)
+		}
+		messages[key] = append(messages[key], msg)
+	}
+
+	if len(order) == 1 || p.mixed == models.MixedAffinityFirst {
+		packet.Affinity = order[0]
+		return []models.LogPacket{packet}
+	}
+
+	parts := make([]models.LogPacket, len(order))
+	for i, key := range order {
+		part := packet
+		part.ID = fmt.Sprintf("%s:key-%d", packet.ID, i+1)
+		part.Messages = messages[key]
+		part.Affinity = key
+		// Duplicates were already checked against the submitted packet's key
+		part.IdempotencyKey = ""
+		parts[i] = part
+	}
+	return parts
+}
@@ -18,15 +18,18 @@ import (
 
 // PersistenceManager implements the PersistenceManager interface
 type PersistenceManager struct {
-	logger *zap.Logger
+	compressedFile string
+	logger         *zap.Logger
 }
 
 // Ensure PersistenceManager implements PersistenceManager interface
 var _ interfaces.PersistenceManager = (*PersistenceManager)(nil)
 
-func NewPersistenceManager(logger *zap.Logger) interfaces.PersistenceManager {
+// NewPersistenceManager creates a persistence manager that keeps the state gzip-compressed at statePath + ".gz"
+func NewPersistenceManager(statePath string, logger *zap.Logger) interfaces.PersistenceManager {
 	return &PersistenceManager{
-		logger: logger,
+		compressedFile: statePath + ".gz",
+		logger:         logger,
 	}
 }
 
@@ -65,8 +68,7 @@ func (s *PersistenceManager) SaveState(state *models.DistributorState) error {
 	}
 
 	// Write compressed file
-	compressedFile := config.StateFilePath + ".gz"
-	file, err := os.Create(compressedFile)
+	file, err := os.Create(s.compressedFile)
 	if err != nil {
 		return fmt.Errorf("failed to create compressed state file: %w", err)
 	}
@@ -87,8 +89,7 @@ func (s *PersistenceManager) SaveState(state *models.DistributorState) error {
 
 // RecoverState recovers distributor state from disk
 func (s *PersistenceManager) RecoverState() (*models.DistributorState, error) {
-	compressedFile := config.StateFilePath + ".gz"
-	file, err := os.Open(compressedFile)
+	file, err := os.Open(s.compressedFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open compressed state file: %w", err)
 	}
@@ -0,0 +1,121 @@
+package implementations
+
+import (
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// resultSubscriber is a single live consumer of analysis results
+type resultSubscriber struct {
+	filter  models.ResultFilter
+	results chan models.AnalysisResult
+}
+
+// ResultHub implements the ResultHub interface. Publishing never blocks:
+// results are dropped for subscribers whose buffers are full.
+type ResultHub struct {
+	logger         *zap.Logger
+	mu             sync.RWMutex
+	subscribers    map[int]*resultSubscriber
+	nextID         int
+	maxSubscribers int
+	bufferSize     int
+	closed         bool
+	dropped        int64
+}
+
+// Ensure ResultHub implements ResultHub interface
+var _ interfaces.ResultHub = (*ResultHub)(nil)
+
+func NewResultHub(maxSubscribers, bufferSize int, logger *zap.Logger) interfaces.ResultHub {
+	return &ResultHub{
+		logger:         logger,
+		subscribers:    make(map[int]*resultSubscriber),
+		maxSubscribers: maxSubscribers,
+		bufferSize:     bufferSize,
+	}
+}
+
+// Publish delivers the result to every matching subscriber without waiting on slow consumers
+func (h *ResultHub) Publish(result models.AnalysisResult) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, subscriber := range h.subscribers {
+		if !subscriber.filter.Matches(result) {
+			continue
+		}
+		select {
+		case subscriber.results <- result:
+		default:
+			atomic.AddInt64(&h.dropped, 1)
+		}
+	}
+}
+
+// Subscribe registers a subscriber and returns its result channel and an unsubscribe function.
+// The channel is closed on unsubscribe or when the hub is closed.
+func (h *ResultHub) Subscribe(filter models.ResultFilter) (<-chan models.AnalysisResult, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, interfaces.ErrShuttingDown
+	}
+	if len(h.subscribers) >= h.maxSubscribers {
+		return nil, nil, interfaces.ErrTooManySubscribers
+	}
+
+	id := h.nextID
+	h.nextID++
+	subscriber := &resultSubscriber{
+		filter:  filter,
+		results: make(chan models.AnalysisResult, h.bufferSize),
+	}
+	h.subscribers[id] = subscriber
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if _, exists := h.subscribers[id]; exists {
+				delete(h.subscribers, id)
+				close(subscriber.results)
+			}
+		})
+	}
+
+	return subscriber.results, unsubscribe, nil
+}
+
+// SubscriberCount returns the number of active subscribers
+func (h *ResultHub) SubscriberCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// DroppedCount returns how many results were dropped for slow subscribers
+func (h *ResultHub) DroppedCount() int64 {
+	return atomic.LoadInt64(&h.dropped)
+}
+
+// Close ends every subscription and rejects new ones
+func (h *ResultHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	if len(h.subscribers) > 0 {
+		h.logger.Info("Closing result stream subscriptions", zap.Int("subscribers", len(h.subscribers)))
+	}
+	for id, subscriber := range h.subscribers {
+		delete(h.subscribers, id)
+		close(subscriber.results)
+	}
+}
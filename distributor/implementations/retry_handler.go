@@ -2,15 +2,17 @@ package implementations
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
+	"errors"
 	"logs-distributor/config"
 	"logs-distributor/distributor/interfaces"
 	"logs-distributor/models"
+	"logs-distributor/tracing"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -19,59 +21,192 @@ type RetryHandler struct {
 	retryChannel chan models.LogPacket
 	logger       *zap.Logger
 	mu           sync.RWMutex
-	packetMap    map[string]models.LogPacket
+	packetMap    map[string]models.LogPacket // keyed by LogPacket.TrackingKey, like legs
+	legs         map[string]*deliveryLegs
+	lifecycle    interfaces.LifecycleStore
+	deadLetters  interfaces.DeadLetterStore
+	metrics      interfaces.MetricsRecorder
 	ctx          context.Context
 }
 
+// deliveryLegs tracks which analyzers hold legs of a packet's delivery
+type deliveryLegs struct {
+	quorum         int
+	pending        map[string]bool
+	succeeded      map[string]bool
+	retryScheduled bool // a requeue is waiting out its backoff; later failed legs are redispatched with it
+	hedged         bool // the pending legs race for the same result; one failing leaves the others to win
+}
+
+// errRetryChannelFull marks backoff spans whose retry was dropped
+var errRetryChannelFull = errors.New("retry channel full")
+
 // Ensure RetryHandler implements RetryHandler interface
 var _ interfaces.RetryHandler = (*RetryHandler)(nil)
 
-func NewRetryHandler(retryChannel chan models.LogPacket, logger *zap.Logger, ctx context.Context) interfaces.RetryHandler {
+func NewRetryHandler(retryChannel chan models.LogPacket, lifecycle interfaces.LifecycleStore, deadLetters interfaces.DeadLetterStore, metrics interfaces.MetricsRecorder, logger *zap.Logger, ctx context.Context) interfaces.RetryHandler {
 	return &RetryHandler{
 		retryChannel: retryChannel,
 		logger:       logger,
 		packetMap:    make(map[string]models.LogPacket),
+		legs:         make(map[string]*deliveryLegs),
+		lifecycle:    lifecycle,
+		deadLetters:  deadLetters,
+		metrics:      metrics,
 		ctx:          ctx,
 	}
 }
 
-// TrackPacket stores a packet for potential retry
+// TrackPacket stores a packet for potential retry; its delivery starts with no legs
 func (r *RetryHandler) TrackPacket(packet models.LogPacket) {
 	r.mu.Lock()
-	r.packetMap[packet.ID] = packet
+	r.packetMap[packet.TrackingKey()] = packet
+	delete(r.legs, packet.TrackingKey())
 	r.mu.Unlock()
 }
 
 // UntrackPacket removes a packet from tracking (success case)
-func (r *RetryHandler) UntrackPacket(packetID string) {
+func (r *RetryHandler) UntrackPacket(packetKey string) {
 	r.mu.Lock()
-	delete(r.packetMap, packetID)
+	delete(r.packetMap, packetKey)
+	delete(r.legs, packetKey)
 	r.mu.Unlock()
 }
 
-// HandleFailedPacket implements retry logic with exponential backoff
+// StartLegs records legs of a tracked packet dispatched to the analyzers.
+// The quorum is fixed by the first dispatch; legs added later (retries) keep it.
+func (r *RetryHandler) StartLegs(packetKey string, quorum int, analyzerIDs []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, tracked := r.packetMap[packetKey]; !tracked {
+		return
+	}
+
+	legs, exists := r.legs[packetKey]
+	if !exists {
+		legs = &deliveryLegs{pending: make(map[string]bool), succeeded: make(map[string]bool)}
+		r.legs[packetKey] = legs
+	}
+	if legs.quorum == 0 {
+		legs.quorum = quorum
+	}
+	for _, analyzerID := range analyzerIDs {
+		legs.pending[analyzerID] = true
+	}
+}
+
+// StartHedge records a hedge leg racing a tracked packet's pending leg. Until every racing leg has
+// finished, a failed one is not retried.
+func (r *RetryHandler) StartHedge(packetKey, analyzerID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	legs, exists := r.legs[packetKey]
+	if _, tracked := r.packetMap[packetKey]; !tracked || !exists {
+		return
+	}
+	legs.hedged = true
+	legs.pending[analyzerID] = true
+}
+
+// DeliveryProgress returns the analyzers holding legs of a tracked packet
+func (r *RetryHandler) DeliveryProgress(packetKey string) models.DeliveryProgress {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	legs, exists := r.legs[packetKey]
+	if !exists {
+		return models.DeliveryProgress{}
+	}
+	return models.DeliveryProgress{
+		Quorum:    legs.quorum,
+		Pending:   sortedIDs(legs.pending),
+		Succeeded: sortedIDs(legs.succeeded),
+	}
+}
+
+// HandleSucceededPacket records a successful leg and reports whether the packet's quorum is now met,
+// in which case the packet is untracked. Results arriving after that are ignored.
+func (r *RetryHandler) HandleSucceededPacket(result models.AnalysisResult) bool {
+	key := result.TrackingKey()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, tracked := r.packetMap[key]; !tracked {
+		return false
+	}
+
+	if legs, exists := r.legs[key]; exists {
+		delete(legs.pending, result.AnalyzerID)
+		legs.succeeded[result.AnalyzerID] = true
+		if len(legs.succeeded) < legs.quorum {
+			return false
+		}
+	}
+
+	delete(r.packetMap, key)
+	delete(r.legs, key)
+	return true
+}
+
+// HandleFailedPacket implements retry logic with exponential backoff.
+// Only the failed leg is redispatched; legs still processing or already succeeded are kept.
+// Failed legs share the packet's retry budget. A failed leg of a hedged packet is only retried
+// once its racing leg has failed too.
 func (r *RetryHandler) HandleFailedPacket(result models.AnalysisResult) {
+	key := result.TrackingKey()
 	r.mu.Lock()
-	packet, exists := r.packetMap[result.PacketID]
+	packet, exists := r.packetMap[key]
 	if !exists {
 		r.mu.Unlock()
-		r.logger.Error("Failed packet not found in map", zap.String("packet_id", result.PacketID))
+		// A leg that finished after its packet completed or was dead-lettered
+		r.logger.Debug("Failed packet is no longer tracked", zap.String("packet_id", result.PacketID))
 		return
 	}
 
+	legs := r.legs[key]
+	if legs != nil {
+		delete(legs.pending, result.AnalyzerID)
+		if legs.hedged && len(legs.pending) > 0 {
+			r.mu.Unlock()
+			r.logger.Debug("Hedged leg failed while another is processing",
+				zap.String("packet_id", result.PacketID),
+				zap.String("analyzer", result.AnalyzerID),
+			)
+			return
+		}
+		legs.hedged = false
+	}
+
 	if packet.RetryCount < config.MaxRetries {
 		packet.RetryCount++
 		backoffDuration := time.Duration(packet.RetryCount*config.RetryBackoffFactor) * config.BaseRetryDelay
 
 		// Update packet in map with new retry count
-		r.packetMap[result.PacketID] = packet
+		r.packetMap[key] = packet
+		schedule := legs == nil || !legs.retryScheduled
+		if legs != nil {
+			legs.retryScheduled = true
+		}
 		r.mu.Unlock()
 
+		r.metrics.PacketRetried(result.AnalyzerID)
+		r.lifecycle.Record(packet.TenantID, packet.ID, models.PacketTransition{
+			State:      models.PacketStateRetrying,
+			AnalyzerID: result.AnalyzerID,
+			RetryCount: packet.RetryCount,
+			Error:      result.Error,
+		})
+
 		// Schedule retry with proper timer cleanup
-		go r.scheduleRetryWithCleanup(r.ctx, packet, backoffDuration)
+		if schedule {
+			go r.scheduleRetryWithCleanup(r.ctx, packet, backoffDuration)
+		}
 	} else {
 		// Remove from packet map before logging (prevent further concurrent access)
-		delete(r.packetMap, result.PacketID)
+		delete(r.packetMap, key)
+		delete(r.legs, key)
 		r.mu.Unlock()
 
 		r.logger.Error("Packet failed permanently after max retries",
@@ -80,12 +215,27 @@ func (r *RetryHandler) HandleFailedPacket(result models.AnalysisResult) {
 			zap.String("final_error", result.Error),
 		)
 
-		r.saveToDeadLetterFile(packet, result.Error)
+		r.metrics.PacketDeadLettered(result.AnalyzerID)
+		r.lifecycle.Record(packet.TenantID, packet.ID, models.PacketTransition{
+			State:      models.PacketStateDeadLettered,
+			AnalyzerID: result.AnalyzerID,
+			RetryCount: packet.RetryCount,
+			Error:      result.Error,
+		})
+
+		_, span := tracing.StartPacketSpan(packet, "retry.dead_letter", trace.WithAttributes(
+			attribute.String("analyzer.id", result.AnalyzerID),
+			attribute.String("error", result.Error),
+		))
+		r.saveToDeadLetters(packet, result)
+		span.End()
 	}
 }
 
 // scheduleRetryWithCleanup schedules retry with proper resource cleanup
+// The backoff is traced in the packet's original trace, so retries stay linked to the submission.
 func (r *RetryHandler) scheduleRetryWithCleanup(ctx context.Context, packet models.LogPacket, delay time.Duration) {
+	_, span := tracing.StartPacketSpan(packet, "retry.backoff", trace.WithAttributes(attribute.String("retry.delay", delay.String())))
 	timer := time.NewTimer(delay)
 	defer timer.Stop()
 
@@ -93,36 +243,67 @@ func (r *RetryHandler) scheduleRetryWithCleanup(ctx context.Context, packet mode
 	case <-timer.C:
 		select {
 		case r.retryChannel <- packet:
+			span.End()
 		case <-ctx.Done():
 			// Context cancelled during retry submission - drop packet
+			tracing.EndSpan(span, ctx.Err())
 		default:
 			r.logger.Error("Failed to schedule retry: channel full", zap.String("packet_id", packet.ID))
+			r.clearRetryScheduled(packet.TrackingKey())
+			tracing.EndSpan(span, errRetryChannelFull)
 		}
 	case <-ctx.Done():
 		// Context cancelled during delay - abort retry
+		tracing.EndSpan(span, ctx.Err())
+	}
+}
+
+// clearRetryScheduled lets the next failed leg schedule a retry
+func (r *RetryHandler) clearRetryScheduled(packetKey string) {
+	r.mu.Lock()
+	if legs, exists := r.legs[packetKey]; exists {
+		legs.retryScheduled = false
 	}
+	r.mu.Unlock()
 }
 
 // ProcessRetries handles retry packets
-func (r *RetryHandler) ProcessRetries(ctx context.Context, wg *sync.WaitGroup, packetChannel chan models.LogPacket) {
+func (r *RetryHandler) ProcessRetries(ctx context.Context, wg *sync.WaitGroup, queue interfaces.PacketQueue) {
 	wg.Add(1)
 	defer wg.Done()
 
 	for {
 		select {
 		case packet := <-r.retryChannel:
-			// Update packet map with retry count
+			// The tracked copy has the retry count of every leg that failed during the backoff
 			r.mu.Lock()
-			r.packetMap[packet.ID] = packet
+			key := packet.TrackingKey()
+			tracked, exists := r.packetMap[key]
+			if legs, ok := r.legs[key]; ok {
+				legs.retryScheduled = false
+			}
 			r.mu.Unlock()
+			if !exists {
+				// Completed by its other legs or dead-lettered during the backoff
+				continue
+			}
+			packet = tracked
 
-			// Resubmit for processing
-			select {
-			case packetChannel <- packet:
-			case <-time.After(config.SubmissionTimeout):
-				r.logger.Error("Failed to submit retry packet", zap.String("packet_id", packet.ID))
-			case <-ctx.Done():
+			// Resubmit for processing in the packet's priority lane
+			packet.EnqueuedAt = time.Now()
+			submitCtx, cancel := context.WithTimeout(ctx, config.SubmissionTimeout)
+			queued := queue.Enqueue(submitCtx, packet)
+			cancel()
+			switch {
+			case queued:
+				r.lifecycle.Record(packet.TenantID, packet.ID, models.PacketTransition{
+					State:      models.PacketStateQueued,
+					RetryCount: packet.RetryCount,
+				})
+			case ctx.Err() != nil:
 				return
+			default:
+				r.logger.Error("Failed to submit retry packet", zap.String("packet_id", packet.ID))
 			}
 		case <-ctx.Done():
 			return
@@ -130,47 +311,20 @@ func (r *RetryHandler) ProcessRetries(ctx context.Context, wg *sync.WaitGroup, p
 	}
 }
 
-// saveToDeadLetterFile saves permanently failed packets with rotation
-func (r *RetryHandler) saveToDeadLetterFile(packet models.LogPacket, finalError string) {
-	deadLetterEntry := struct {
-		Packet     models.LogPacket `json:"packet"`
-		FinalError string           `json:"final_error"`
-		FailedAt   time.Time        `json:"failed_at"`
-	}{
+// saveToDeadLetters appends a permanently failed packet to the dead letter store
+func (r *RetryHandler) saveToDeadLetters(packet models.LogPacket, result models.AnalysisResult) {
+	deadLetterEntry := models.DeadLetterEntry{
 		Packet:     packet,
-		FinalError: finalError,
+		FinalError: result.Error,
+		AnalyzerID: result.AnalyzerID,
 		FailedAt:   time.Now(),
 	}
 
-	// Read existing dead letter entries
-	var deadLetterEntries []interface{}
-	if data, err := ioutil.ReadFile(config.DeadLetterFile); err == nil {
-		if err := json.Unmarshal(data, &deadLetterEntries); err != nil {
-			r.logger.Error("Failed to parse existing dead letter file", zap.Error(err))
-		}
-	}
-
-	// Limit dead letter file size (rotate if too large)
-	const maxDeadLetterEntries = 10000
-	if len(deadLetterEntries) >= maxDeadLetterEntries {
-		// Keep only the most recent entries
-		deadLetterEntries = deadLetterEntries[len(deadLetterEntries)-maxDeadLetterEntries/2:]
-		r.logger.Info("Rotated dead letter file", zap.Int("new_size", len(deadLetterEntries)))
-	}
-
-	deadLetterEntries = append(deadLetterEntries, deadLetterEntry)
-
-	// Write back to file with compact JSON
-	if data, err := json.Marshal(deadLetterEntries); err == nil {
-		if err := ioutil.WriteFile(config.DeadLetterFile, data, 0644); err != nil {
-			r.logger.Error("Failed to write dead letter file", zap.Error(err))
-		} else {
-			r.logger.Info("Packet saved to dead letter file",
-				zap.String("packet_id", packet.ID),
-				zap.String("file", config.DeadLetterFile),
-			)
-		}
+	if err := r.deadLetters.Append(deadLetterEntry); err != nil {
+		r.logger.Error("Failed to write dead letter entry", zap.String("packet_id", packet.ID), zap.Error(err))
+		return
 	}
+	r.logger.Info("Packet saved to dead letter store", zap.String("packet_id", packet.ID))
 }
 
 // GetFailedPacketsCount returns the count of failed packets
@@ -0,0 +1,435 @@
+package implementations
+
+import (
+	"encoding/json"
+	"fmt"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// defaultRouteName names the default route in part IDs and stats; rules cannot use it
+const defaultRouteName = "default"
+
+// routeNamePattern keeps rule names safe to embed in packet IDs
+var routeNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// compiledRule is a routing rule with its patterns compiled and its traffic counters
+type compiledRule struct {
+	name      string
+	levels    []string
+	sources   []*regexp.Regexp
+	message   *regexp.Regexp
+	metadata  map[string]*regexp.Regexp
+	analyzers map[string]bool
+	delivery  models.Delivery
+	hedge     *models.HedgeConfig // set when the rule's group is hedged
+
+	packets  int64
+	messages int64
+}
+
+// RuleRouter implements the Router interface with ordered content rules; the first matching rule wins
+type RuleRouter struct {
+	rules    []*compiledRule
+	byName   map[string]*compiledRule
+	routed   map[string]bool     // analyzers some rule routes to, kept out of the default pool
+	fallback map[string]bool     // the default group; nil uses every analyzer not in routed
+	delivery models.Delivery     // the default route's delivery
+	hedge    *models.HedgeConfig // the default route's hedging
+
+	defaultPackets  int64
+	defaultMessages int64
+}
+
+// Ensure RuleRouter implements Router interface
+var _ interfaces.Router = (*RuleRouter)(nil)
+
+// LoadRoutingConfig reads routing rules and analyzer groups from a JSON file
+func LoadRoutingConfig(path string) (models.RoutingConfig, error) {
+	var cfg models.RoutingConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read routing rules file: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse routing rules file: %w", err)
+	}
+	return cfg, nil
+}
+
+// NewRouter validates and compiles the routing rules. With no rules every packet
+// stays whole on the default route and may go to any analyzer.
+func NewRouter(cfg models.RoutingConfig) (interfaces.Router, error) {
+	r := &RuleRouter{
+		byName: make(map[string]*compiledRule),
+		routed: make(map[string]bool),
+	}
+
+	for name, members := range cfg.Groups {
+		if len(members) == 0 {
+			return nil, fmt.Errorf("group %q has no analyzers", name)
+		}
+	}
+
+	hedges, err := validateHedging(cfg.Hedging, cfg.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Default != "" {
+		members, exists := cfg.Groups[cfg.Default]
+		if !exists {
+			return nil, fmt.Errorf("default group %q is not defined", cfg.Default)
+		}
+		r.fallback = analyzerSet(members)
+	}
+
+	delivery, err := validateDelivery(cfg.Delivery, len(r.fallback))
+	if err != nil {
+		return nil, fmt.Errorf("default route: %w", err)
+	}
+	r.delivery = delivery
+	if hedge, exists := hedges[cfg.Default]; exists {
+		if delivery.Mode != models.DeliveryOne {
+			return nil, fmt.Errorf("default route: hedging only applies to %s delivery", models.DeliveryOne)
+		}
+		r.hedge = &hedge
+	}
+
+	for i, rule := range cfg.Rules {
+		compiled, err := compileRule(rule, cfg.Groups, hedges)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		if _, exists := r.byName[compiled.name]; exists {
+			return nil, fmt.Errorf("rule %d: duplicate name %q", i, compiled.name)
+		}
+
+		r.rules = append(r.rules, compiled)
+		r.byName[compiled.name] = compiled
+		for id := range compiled.analyzers {
+			r.routed[id] = true
+		}
+	}
+
+	return r, nil
+}
+
+// validateHedging checks the hedged groups and fills in the default hedging settings
+func validateHedging(hedging map[string]models.HedgeConfig, groups map[string][]string) (map[string]models.HedgeConfig, error) {
+	hedges := make(map[string]models.HedgeConfig, len(hedging))
+	for name, hedge := range hedging {
+		members, exists := groups[name]
+		if !exists {
+			return nil, fmt.Errorf("hedged group %q is not defined", name)
+		}
+		if len(members) < 2 {
+			return nil, fmt.Errorf("hedged group %q needs at least 2 analyzers", name)
+		}
+
+		if hedge.Percentile == 0 {
+			hedge.Percentile = config.HedgePercentile
+		}
+		if hedge.MinDelayMs == 0 {
+			hedge.MinDelayMs = int(config.HedgeMinDelay / time.Millisecond)
+		}
+		if hedge.MaxRate == 0 {
+			hedge.MaxRate = config.HedgeMaxRate
+		}
+		switch {
+		case hedge.Percentile <= 0 || hedge.Percentile >= 100:
+			return nil, fmt.Errorf("hedged group %q: percentile must be between 0 and 100", name)
+		case hedge.MinDelayMs < 0:
+			return nil, fmt.Errorf("hedged group %q: min_delay_ms must not be negative", name)
+		case hedge.MaxRate < 0 || hedge.MaxRate > 1:
+			return nil, fmt.Errorf("hedged group %q: max_rate must be between 0 and 1", name)
+		}
+		hedges[name] = hedge
+	}
+	return hedges, nil
+}
+
+// compileRule checks a rule's target and conditions and compiles its patterns
+func compileRule(rule models.RoutingRule, groups map[string][]string, hedges map[string]models.HedgeConfig) (*compiledRule, error) {
+	if !routeNamePattern.MatchString(rule.Name) {
+		return nil, fmt.Errorf("name %q must be letters, digits, '-' or '_'", rule.Name)
+	}
+	if rule.Name == defaultRouteName {
+		return nil, fmt.Errorf("name %q is reserved", defaultRouteName)
+	}
+
+	compiled := &compiledRule{name: rule.Name}
+
+	switch {
+	case rule.Analyzer != "" && rule.Group != "":
+		return nil, fmt.Errorf("set either analyzer or group, not both")
+	case rule.Analyzer != "":
+		compiled.analyzers = analyzerSet([]string{rule.Analyzer})
+	case rule.Group != "":
+		members, exists := groups[rule.Group]
+		if !exists {
+			return nil, fmt.Errorf("group %q is not defined", rule.Group)
+		}
+		compiled.analyzers = analyzerSet(members)
+	default:
+		return nil, fmt.Errorf("an analyzer or group is required")
+	}
+
+	delivery, err := validateDelivery(rule.Delivery, len(compiled.analyzers))
+	if err != nil {
+		return nil, err
+	}
+	compiled.delivery = delivery
+
+	if hedge, exists := hedges[rule.Group]; exists && rule.Group != "" {
+		if delivery.Mode != models.DeliveryOne {
+			return nil, fmt.Errorf("hedging only applies to %s delivery", models.DeliveryOne)
+		}
+		compiled.hedge = &hedge
+	}
+
+	if len(rule.Levels) == 0 && len(rule.Sources) == 0 && rule.Message == "" && len(rule.Metadata) == 0 {
+		return nil, fmt.Errorf("at least one of levels, sources, message or metadata is required")
+	}
+
+	for _, level := range rule.Levels {
+		compiled.levels = append(compiled.levels, strings.ToUpper(level))
+	}
+	for _, source := range rule.Sources {
+		compiled.sources = append(compiled.sources, wildcardPattern(source))
+	}
+	if rule.Message != "" {
+		message, err := regexp.Compile(rule.Message)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message pattern: %w", err)
+		}
+		compiled.message = message
+	}
+	if len(rule.Metadata) > 0 {
+		compiled.metadata = make(map[string]*regexp.Regexp, len(rule.Metadata))
+		for key, value := range rule.Metadata {
+			compiled.metadata[key] = wildcardPattern(value)
+		}
+	}
+
+	return compiled, nil
+}
+
+// validateDelivery checks a route's delivery against the number of analyzers it routes to
+// (0 when unknown) and fills in the default mode
+func validateDelivery(delivery models.Delivery, analyzers int) (models.Delivery, error) {
+	switch delivery.Mode {
+	case "", models.DeliveryOne, models.DeliveryAll:
+		if delivery.FanOut != 0 || delivery.Quorum != 0 {
+			return delivery, fmt.Errorf("fan_out and quorum only apply to %s delivery", models.DeliveryKOfN)
+		}
+		if delivery.Mode == "" {
+			delivery.Mode = models.DeliveryOne
+		}
+	case models.DeliveryKOfN:
+		if delivery.FanOut < 1 || delivery.Quorum < 1 || delivery.Quorum > delivery.FanOut {
+			return delivery, fmt.Errorf("%s delivery needs 1 <= quorum <= fan_out", models.DeliveryKOfN)
+		}
+		if analyzers > 0 && delivery.FanOut > analyzers {
+			return delivery, fmt.Errorf("fan_out %d exceeds the route's %d analyzers", delivery.FanOut, analyzers)
+		}
+	default:
+		return delivery, fmt.Errorf("unknown delivery %q - expected %s, %s or %s", delivery.Mode, models.DeliveryOne, models.DeliveryAll, models.DeliveryKOfN)
+	}
+	return delivery, nil
+}
+
+// wildcardPattern compiles a pattern in which * matches any run of characters
+func wildcardPattern(pattern string) *regexp.Regexp {
+	quoted := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	return regexp.MustCompile("^" + quoted + "$")
+}
+
+// analyzerSet converts analyzer IDs to a set
+func analyzerSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// matches reports whether a message meets every condition the rule sets
+func (c *compiledRule) matches(msg models.LogMessage) bool {
+	if len(c.levels) > 0 && !containsString(c.levels, strings.ToUpper(msg.Level)) {
+		return false
+	}
+	if len(c.sources) > 0 && !anyPatternMatches(c.sources, msg.Source) {
+		return false
+	}
+	if c.message != nil && !c.message.MatchString(msg.Message) {
+		return false
+	}
+	for key, pattern := range c.metadata {
+		value, exists := msg.Metadata[key]
+		if !exists || !pattern.MatchString(fmt.Sprint(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+// anyPatternMatches reports whether any pattern matches s
+func anyPatternMatches(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// route returns the name of the first rule matching the message, or "" for the default route
+func (r *RuleRouter) route(msg models.LogMessage) string {
+	for _, rule := range r.rules {
+		if rule.matches(msg) {
+			return rule.name
+		}
+	}
+	return ""
+}
+
+// Split groups a packet's messages by route. A packet whose messages share a route is returned whole
+// with its Route set; otherwise each route gets a part with ID "<packet ID>:<route>", so an analyzer
+// only ever receives the messages routed to it.
+func (r *RuleRouter) Split(packet models.LogPacket) []models.LogPacket {
+	var order []string
+	messages := make(map[string][]models.LogMessage)
+	for _, msg := range packet.Messages {
+		route := r.route(msg)
+		if _, seen := messages[route]; !seen {
+			order = append(order, route)
+		}
+		messages[route] = append(messages[route], msg)
+	}
+
+	parts := make([]models.LogPacket, 0, len(order))
+	for _, route := range order {
+		part := packet
+		part.Route = route
+		if len(order) > 1 {
+			part.ID = packet.ID + ":" + routeName(route)
+			part.Messages = messages[route]
+			// Duplicates were already checked against the submitted packet's key
+			part.IdempotencyKey = ""
+		}
+		r.count(route, len(part.Messages))
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// routeName returns the display name of a route
+func routeName(route string) string {
+	if route == "" {
+		return defaultRouteName
+	}
+	return route
+}
+
+// count records a packet sent down a route
+func (r *RuleRouter) count(route string, messages int) {
+	if rule, exists := r.byName[route]; exists {
+		atomic.AddInt64(&rule.packets, 1)
+		atomic.AddInt64(&rule.messages, int64(messages))
+		return
+	}
+	atomic.AddInt64(&r.defaultPackets, 1)
+	atomic.AddInt64(&r.defaultMessages, int64(messages))
+}
+
+// Allows reports whether a packet on the route may be dispatched to the analyzer.
+// Routes of rules that no longer exist fall back to the default route.
+func (r *RuleRouter) Allows(route, analyzerID string) bool {
+	if rule, exists := r.byName[route]; exists {
+		return rule.analyzers[analyzerID]
+	}
+	if r.fallback != nil {
+		return r.fallback[analyzerID]
+	}
+	return !r.routed[analyzerID]
+}
+
+// Delivery returns how many analyzers a packet on the route is sent to.
+// Routes of rules that no longer exist use the default route's delivery.
+func (r *RuleRouter) Delivery(route string) models.Delivery {
+	if rule, exists := r.byName[route]; exists {
+		return rule.delivery
+	}
+	return r.delivery
+}
+
+// Hedging returns the hedging settings of a route, if its analyzer group is hedged.
+// Routes of rules that no longer exist use the default route's hedging.
+func (r *RuleRouter) Hedging(route string) (models.HedgeConfig, bool) {
+	hedge := r.hedge
+	if rule, exists := r.byName[route]; exists {
+		hedge = rule.hedge
+	}
+	if hedge == nil {
+		return models.HedgeConfig{}, false
+	}
+	return *hedge, true
+}
+
+// Stats returns the traffic per rule, in rule order, followed by the default route
+func (r *RuleRouter) Stats() []models.RouteStats {
+	if len(r.rules) == 0 {
+		return nil
+	}
+
+	stats := make([]models.RouteStats, 0, len(r.rules)+1)
+	for _, rule := range r.rules {
+		stats = append(stats, models.RouteStats{
+			Name:      rule.name,
+			Analyzers: sortedIDs(rule.analyzers),
+			Delivery:  rule.delivery,
+			Packets:   atomic.LoadInt64(&rule.packets),
+			Messages:  atomic.LoadInt64(&rule.messages),
+		})
+	}
+	stats = append(stats, models.RouteStats{
+		Name:      defaultRouteName,
+		Analyzers: sortedIDs(r.fallback),
+		Delivery:  r.delivery,
+		Packets:   atomic.LoadInt64(&r.defaultPackets),
+		Messages:  atomic.LoadInt64(&r.defaultMessages),
+	})
+	return stats
+}
+
+// sortedIDs returns the IDs in a set in sorted order
+func sortedIDs(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
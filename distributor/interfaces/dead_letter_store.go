@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"logs-distributor/models"
+	"time"
+)
+
+// DeadLetterStore defines the interface for durable storage of permanently failed packets
+type DeadLetterStore interface {
+	Append(entry models.DeadLetterEntry) error
+	List(filter models.DeadLetterFilter, cursor string, limit int) (*models.DeadLetterPage, error)
+	MarkReplayed(entryIDs []string, replayedAt time.Time) error
+	Close() error
+}
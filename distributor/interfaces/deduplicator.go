@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Deduplicator defines the interface for detecting repeated packet submissions
+type Deduplicator interface {
+	Reserve(key string) bool
+	Release(key string)
+	Snapshot() map[string]time.Time
+	Restore(entries map[string]time.Time)
+	StartExpiry(ctx context.Context, wg *sync.WaitGroup)
+}
@@ -1,6 +1,10 @@
 package interfaces
 
-import "logs-distributor/models"
+import (
+	"context"
+	"logs-distributor/config"
+	"logs-distributor/models"
+)
 
 // Distributor defines the main interface for log distribution services
 type Distributor interface {
@@ -15,4 +19,28 @@ type Distributor interface {
 
 	// GetStats returns current distributor statistics
 	GetStats() *models.DistributorStats
+
+	// GetPacketStatus returns the lifecycle status of a packet the tenant recently submitted
+	GetPacketStatus(tenantID, packetID string) (*models.PacketStatus, bool)
+
+	// SubscribeResults streams analysis results matching the filter until unsubscribed
+	SubscribeResults(filter models.ResultFilter) (<-chan models.AnalysisResult, func(), error)
+
+	// AddAnalyzer registers a new analyzer and starts routing packets to it
+	AddAnalyzer(cfg config.AnalyzerConfig) error
+
+	// UpdateAnalyzer changes the name, weight and processing time of a registered analyzer
+	UpdateAnalyzer(cfg config.AnalyzerConfig) error
+
+	// RemoveAnalyzer stops routing packets to an analyzer; its in-flight packets finish in the background
+	RemoveAnalyzer(analyzerID string) error
+
+	// ListDeadLetters returns a page of dead letter entries matching the filter, newest first
+	ListDeadLetters(filter models.DeadLetterFilter, cursor string, limit int) (*models.DeadLetterPage, error)
+
+	// ReplayDeadLetters resubmits matching dead-lettered packets and marks them replayed
+	ReplayDeadLetters(ctx context.Context, req models.ReplayRequest) (*models.ReplayResult, error)
+
+	// SetAnalyzerOverride sets an operator health override on an analyzer; nil clears it
+	SetAnalyzerOverride(analyzerID string, override *models.HealthOverride) error
 }
@@ -0,0 +1,26 @@
+package interfaces
+
+import "errors"
+
+// Sentinel errors returned by Distributor.SubmitPacket so callers can tell
+// rejected packets apart from transient submission failures
+var (
+	ErrInvalidPacket     = errors.New("packet validation failed")
+	ErrSubmissionTimeout = errors.New("submission timeout: queue full")
+	ErrShuttingDown      = errors.New("distributor shutting down")
+	ErrDuplicatePacket   = errors.New("duplicate packet: already accepted")
+)
+
+// Sentinel errors returned by the Distributor's analyzer registry methods
+var (
+	ErrInvalidAnalyzer  = errors.New("invalid analyzer configuration")
+	ErrAnalyzerExists   = errors.New("analyzer already registered")
+	ErrAnalyzerNotFound = errors.New("analyzer not found")
+	ErrInvalidOverride  = errors.New("invalid analyzer override")
+)
+
+// ErrInvalidCursor is returned by DeadLetterStore.List when the cursor was not issued by the store
+var ErrInvalidCursor = errors.New("invalid dead letter cursor")
+
+// ErrTooManySubscribers is returned by ResultHub.Subscribe when the subscriber limit is reached
+var ErrTooManySubscribers = errors.New("too many result stream subscribers")
@@ -2,10 +2,19 @@ package interfaces
 
 import (
 	"context"
+	"logs-distributor/models"
 	"sync"
 )
 
 // HealthMonitor defines the interface for analyzer health monitoring
 type HealthMonitor interface {
 	Start(ctx context.Context, wg *sync.WaitGroup, onHealthChange func())
+	AddAnalyzer(analyzer *models.Analyzer)
+	RemoveAnalyzer(analyzerID string)
+	SetOverride(analyzerID string, override *models.HealthOverride) error
+	Allows(analyzerID string) bool
+	RecordDispatch(analyzerID string)
+	RecordResult(analyzerID string, success bool)
+	RecordCancelled(analyzerID string)
+	CircuitStats() map[string]models.CircuitStats
 }
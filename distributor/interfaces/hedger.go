@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"logs-distributor/models"
+	"time"
+)
+
+// Hedger defines the interface for deciding when packets on hedged routes go to a second analyzer
+type Hedger interface {
+	Delay(route string, cfg models.HedgeConfig) (time.Duration, bool)
+	Allow(route string) bool
+	RecordLatency(route string, latency time.Duration)
+	RecordOutcome(route string, outcome models.HedgeOutcome)
+	Stats() map[string]models.HedgeStats
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import "logs-distributor/models"
+
+// LifecycleStore defines the interface for tracking packet lifecycle transitions.
+// Packets are kept per tenant, so tenants may use the same packet IDs.
+type LifecycleStore interface {
+	Record(tenantID, packetID string, transition models.PacketTransition)
+	Get(tenantID, packetID string) (*models.PacketStatus, bool)
+}
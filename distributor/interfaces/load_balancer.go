@@ -1,9 +1,16 @@
 package interfaces
 
-import "logs-distributor/models"
+import (
+	"logs-distributor/models"
+	"time"
+)
 
 // LoadBalancer defines the interface for analyzer selection and weight management
 type LoadBalancer interface {
-	SelectAnalyzer() *models.Analyzer
+	SelectAnalyzer(key string, eligible func(analyzerID string) bool) *models.Analyzer
+	RecordCompletion(analyzerID string, latency time.Duration)
 	UpdateWeights()
+	EffectiveWeights() map[string]models.EffectiveWeight
+	AddAnalyzer(analyzer *models.Analyzer)
+	RemoveAnalyzer(analyzerID string)
 }
@@ -0,0 +1,14 @@
+package interfaces
+
+import "time"
+
+// MetricsRecorder defines the interface for recording packet pipeline metrics
+type MetricsRecorder interface {
+	PacketReceived()
+	PacketRouted(analyzerID string)
+	PacketSucceeded(analyzerID string)
+	PacketRetried(analyzerID string)
+	PacketDeadLettered(analyzerID string)
+	ObserveProcessing(analyzerID string, success bool, duration time.Duration)
+	ForgetAnalyzer(analyzerID string)
+}
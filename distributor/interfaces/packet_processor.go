@@ -8,6 +8,6 @@ import (
 
 // PacketProcessor defines the interface for packet analysis
 type PacketProcessor interface {
-	ProcessPacket(analyzer *models.Analyzer, packet models.LogPacket) models.AnalysisResult
+	ProcessPacket(ctx context.Context, analyzer *models.Analyzer, packet models.LogPacket) models.AnalysisResult
 	RunAnalyzer(ctx context.Context, wg *sync.WaitGroup, analyzer *models.Analyzer)
 }
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"logs-distributor/models"
+)
+
+// PacketQueue defines the interface for the queue between packet submission and the dispatch workers
+type PacketQueue interface {
+	Enqueue(ctx context.Context, packet models.LogPacket) bool
+	TryEnqueue(packet models.LogPacket) bool
+	Dequeue(ctx context.Context) (models.LogPacket, bool)
+	Depth() int
+	Capacity() int
+	Stats() map[models.Priority]models.LaneStats
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+import "logs-distributor/models"
+
+// Partitioner defines the interface for assigning packets their affinity keys
+type Partitioner interface {
+	Partition(packet models.LogPacket) []models.LogPacket
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import "logs-distributor/models"
+
+// ResultHub defines the interface for fanning analysis results out to live subscribers
+type ResultHub interface {
+	Publish(result models.AnalysisResult)
+	Subscribe(filter models.ResultFilter) (<-chan models.AnalysisResult, func(), error)
+	SubscriberCount() int
+	DroppedCount() int64
+	Close()
+}
@@ -6,12 +6,17 @@ import (
 	"sync"
 )
 
-// RetryHandler defines the interface for retry logic
+// RetryHandler defines the interface for retry logic.
+// Tracked packets are identified by their tenant-scoped LogPacket.TrackingKey.
 type RetryHandler interface {
 	TrackPacket(packet models.LogPacket)
-	UntrackPacket(packetID string)
+	UntrackPacket(packetKey string)
+	StartLegs(packetKey string, quorum int, analyzerIDs []string)
+	StartHedge(packetKey, analyzerID string)
+	DeliveryProgress(packetKey string) models.DeliveryProgress
+	HandleSucceededPacket(result models.AnalysisResult) bool
 	HandleFailedPacket(result models.AnalysisResult)
-	ProcessRetries(ctx context.Context, wg *sync.WaitGroup, packetChannel chan models.LogPacket)
+	ProcessRetries(ctx context.Context, wg *sync.WaitGroup, queue PacketQueue)
 	GetFailedPacketsCount(analyzers map[string]*models.Analyzer) int
 	GetTrackedPackets() []models.LogPacket
 }
@@ -0,0 +1,12 @@
+package interfaces
+
+import "logs-distributor/models"
+
+// Router defines the interface for content-based routing ahead of load balancing
+type Router interface {
+	Split(packet models.LogPacket) []models.LogPacket
+	Allows(route, analyzerID string) bool
+	Delivery(route string) models.Delivery
+	Hedging(route string) (models.HedgeConfig, bool)
+	Stats() []models.RouteStats
+}
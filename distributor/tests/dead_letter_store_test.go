@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"logs-distributor/config"
+	"logs-distributor/distributor/implementations"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestDeadLetterStore creates a dead letter store in a temporary directory
+func newTestDeadLetterStore(t *testing.T, logger *zap.Logger) interfaces.DeadLetterStore {
+	store, err := implementations.NewDeadLetterStore(t.TempDir(), config.DeadLetterSegmentMaxBytes,
+		config.DeadLetterSegmentMaxAge, config.DeadLetterMaxSegments, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// appendTestDeadLetters appends n entries for packets dl-0..dl-(n-1), one minute apart
+func appendTestDeadLetters(t *testing.T, store interfaces.DeadLetterStore, n int, start time.Time) {
+	for i := 0; i < n; i++ {
+		analyzerID := "analyzer-even"
+		if i%2 == 1 {
+			analyzerID = "analyzer-odd"
+		}
+		require.NoError(t, store.Append(models.DeadLetterEntry{
+			Packet:     models.LogPacket{ID: fmt.Sprintf("dl-%d", i), TenantID: "tenant"},
+			FinalError: fmt.Sprintf("error %d", i),
+			AnalyzerID: analyzerID,
+			FailedAt:   start.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+}
+
+func TestDeadLetterStore_Pagination(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	store := newTestDeadLetterStore(t, logger)
+	appendTestDeadLetters(t, store, 5, time.Now().Add(-time.Hour))
+
+	var ids []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		page, err := store.List(models.DeadLetterFilter{}, cursor, 2)
+		require.NoError(t, err)
+		for _, entry := range page.Entries {
+			assert.NotEmpty(t, entry.ID)
+			ids = append(ids, entry.Packet.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"dl-4", "dl-3", "dl-2", "dl-1", "dl-0"}, ids, "Entries should be listed newest first")
+
+	_, err := store.List(models.DeadLetterFilter{}, "not-a-cursor", 2)
+	assert.ErrorIs(t, err, interfaces.ErrInvalidCursor)
+}
+
+func TestDeadLetterStore_Filters(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	start := time.Now().Add(-time.Hour)
+	store := newTestDeadLetterStore(t, logger)
+	appendTestDeadLetters(t, store, 6, start)
+
+	page, err := store.List(models.DeadLetterFilter{AnalyzerID: "analyzer-odd"}, "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, "dl-5", page.Entries[0].Packet.ID)
+	assert.Equal(t, "dl-3", page.Entries[1].Packet.ID)
+
+	page, err = store.List(models.DeadLetterFilter{AnalyzerID: "analyzer-odd"}, page.NextCursor, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, "dl-1", page.Entries[0].Packet.ID)
+	assert.Empty(t, page.NextCursor)
+
+	since := start.Add(2 * time.Minute)
+	until := start.Add(4 * time.Minute)
+	page, err = store.List(models.DeadLetterFilter{Since: &since, Until: &until}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, "dl-3", page.Entries[0].Packet.ID)
+	assert.Equal(t, "dl-2", page.Entries[1].Packet.ID)
+
+	page, err = store.List(models.DeadLetterFilter{PacketIDs: []string{"dl-0"}, ErrorContains: "error 0"}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, "tenant", page.Entries[0].Packet.TenantID)
+
+	require.NoError(t, store.Append(models.DeadLetterEntry{
+		Packet:   models.LogPacket{ID: "dl-other", TenantID: "other-tenant"},
+		FailedAt: start,
+	}))
+	page, err = store.List(models.DeadLetterFilter{TenantID: "tenant"}, "", 10)
+	require.NoError(t, err)
+	assert.Len(t, page.Entries, 6, "Other tenants' entries should be filtered out")
+	page, err = store.List(models.DeadLetterFilter{TenantID: "other-tenant"}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, "dl-other", page.Entries[0].Packet.ID)
+}
+
+func TestDeadLetterStore_ReplayMarksSurviveReopen(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	dir := t.TempDir()
+	store, err := implementations.NewDeadLetterStore(dir, config.DeadLetterSegmentMaxBytes, config.DeadLetterSegmentMaxAge, 10, logger)
+	require.NoError(t, err)
+	appendTestDeadLetters(t, store, 3, time.Now().Add(-time.Hour))
+
+	page, err := store.List(models.DeadLetterFilter{PacketIDs: []string{"dl-1"}}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	require.NoError(t, store.MarkReplayed([]string{page.Entries[0].ID}, time.Now()))
+	require.NoError(t, store.Close())
+
+	// Simulate a crash in the middle of an append
+	segment := filepath.Join(dir, "segment-000001.jsonl")
+	file, err := os.OpenFile(segment, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"packet":{"id":"torn"`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	store, err = implementations.NewDeadLetterStore(dir, config.DeadLetterSegmentMaxBytes, config.DeadLetterSegmentMaxAge, 10, logger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	notReplayed := false
+	page, err = store.List(models.DeadLetterFilter{Replayed: &notReplayed}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2, "The partial entry should be dropped and the replayed one excluded")
+	assert.Equal(t, "dl-2", page.Entries[0].Packet.ID)
+	assert.Equal(t, "dl-0", page.Entries[1].Packet.ID)
+
+	// Appends after a truncated torn write stay readable
+	appendTestDeadLetters(t, store, 1, time.Now())
+	page, err = store.List(models.DeadLetterFilter{}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 4)
+	assert.Equal(t, "dl-0", page.Entries[0].Packet.ID)
+	assert.NotNil(t, page.Entries[2].ReplayedAt)
+}
+
+func TestDeadLetterStore_RotationAndRetention(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	dir := t.TempDir()
+	// Tiny segments so every entry starts a new one
+	store, err := implementations.NewDeadLetterStore(dir, 1, config.DeadLetterSegmentMaxAge, 3, logger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	appendTestDeadLetters(t, store, 5, time.Now().Add(-time.Hour))
+
+	segments, err := filepath.Glob(filepath.Join(dir, "segment-*.jsonl"))
+	require.NoError(t, err)
+	assert.Len(t, segments, 3, "Segments beyond the retention limit should be deleted")
+
+	page, err := store.List(models.DeadLetterFilter{}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 3)
+	assert.Equal(t, "dl-4", page.Entries[0].Packet.ID)
+	assert.Equal(t, "dl-2", page.Entries[2].Packet.ID)
+}
+
+func TestDeadLetterStore_ImportLegacyFile(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	store := newTestDeadLetterStore(t, logger)
+	legacy := filepath.Join(t.TempDir(), "failed_packets.json")
+
+	imported, err := implementations.ImportDeadLetterFile(store, legacy)
+	require.NoError(t, err)
+	assert.Equal(t, 0, imported, "A missing legacy file should import nothing")
+
+	data, err := json.Marshal([]models.DeadLetterEntry{
+		{Packet: models.LogPacket{ID: "legacy-1"}, FinalError: "old failure", FailedAt: time.Now().Add(-time.Hour)},
+		{Packet: models.LogPacket{ID: "legacy-2"}, FinalError: "old failure", FailedAt: time.Now()},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(legacy, data, 0644))
+
+	imported, err = implementations.ImportDeadLetterFile(store, legacy)
+	require.NoError(t, err)
+	assert.Equal(t, 2, imported)
+	assert.NoFileExists(t, legacy)
+	assert.FileExists(t, legacy+".imported")
+
+	page, err := store.List(models.DeadLetterFilter{}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, "legacy-2", page.Entries[0].Packet.ID)
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	"logs-distributor/distributor/implementations"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeduplicator_ReserveAndRelease(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	dedup := implementations.NewDeduplicator(time.Minute, 100, logger)
+
+	assert.True(t, dedup.Reserve("key-1"), "First reservation should succeed")
+	assert.False(t, dedup.Reserve("key-1"), "Duplicate inside the window should be rejected")
+	assert.True(t, dedup.Reserve("key-2"))
+
+	// Released keys can be reserved again (failed submissions)
+	dedup.Release("key-1")
+	assert.True(t, dedup.Reserve("key-1"))
+}
+
+func TestDeduplicator_WindowExpiry(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	dedup := implementations.NewDeduplicator(50*time.Millisecond, 100, logger)
+
+	assert.True(t, dedup.Reserve("key"))
+	time.Sleep(80 * time.Millisecond)
+	assert.True(t, dedup.Reserve("key"), "Key should be accepted again once it leaves the window")
+}
+
+func TestDeduplicator_MaxEntries(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	dedup := implementations.NewDeduplicator(time.Minute, 2, logger)
+
+	assert.True(t, dedup.Reserve("a"))
+	assert.True(t, dedup.Reserve("b"))
+	assert.True(t, dedup.Reserve("c"))
+	assert.True(t, dedup.Reserve("d"))
+
+	// Oldest keys are evicted first when the window is over capacity
+	snapshot := dedup.Snapshot()
+	assert.LessOrEqual(t, len(snapshot), 2)
+	assert.Contains(t, snapshot, "d")
+	assert.NotContains(t, snapshot, "a")
+}
+
+func TestDeduplicator_SnapshotRestore(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	original := implementations.NewDeduplicator(time.Minute, 100, logger)
+	original.Reserve("recent")
+
+	snapshot := original.Snapshot()
+	snapshot["expired"] = time.Now().Add(-time.Hour)
+
+	restored := implementations.NewDeduplicator(time.Minute, 100, logger)
+	restored.Restore(snapshot)
+
+	assert.False(t, restored.Reserve("recent"), "Restored keys should still be recognized")
+	assert.True(t, restored.Reserve("expired"), "Keys older than the window should not be restored")
+}
+
+func TestDeduplicator_StartExpiry(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	dedup := implementations.NewDeduplicator(time.Minute, 100, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	dedup.StartExpiry(ctx, &wg)
+
+	cancel()
+	wg.Wait()
+}
@@ -2,10 +2,15 @@ package tests
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"logs-distributor/config"
 	"logs-distributor/distributor/implementations"
 	"logs-distributor/distributor/interfaces"
+	"logs-distributor/metrics"
 	"logs-distributor/models"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -29,40 +34,65 @@ func createTestPacket() models.LogPacket {
 }
 
 // createTestDistributor creates a distributor with real implementations for testing
-func createTestDistributor(logger *zap.Logger) interfaces.Distributor {
+func createTestDistributor(t *testing.T, logger *zap.Logger) interfaces.Distributor {
+	return createTestDistributorWithDeadLetters(t, logger, newTestDeadLetterStore(t, logger))
+}
+
+// createTestDistributorWithDeadLetters creates a test distributor using the given dead letter store
+func createTestDistributorWithDeadLetters(t *testing.T, logger *zap.Logger, deadLetters interfaces.DeadLetterStore) interfaces.Distributor {
+	return implementations.NewDistributor(logger, newTestDistributorConfig(logger, deadLetters, testStatePath(t)))
+}
+
+// testStatePath returns a state file path private to the test, so no state is shared between tests
+func testStatePath(t *testing.T) string {
+	return filepath.Join(t.TempDir(), config.StateFilePath)
+}
+
+// newTestDistributorConfig returns the components of a test distributor with a single fast analyzer and no routing rules
+func newTestDistributorConfig(logger *zap.Logger, deadLetters interfaces.DeadLetterStore, statePath string) *implementations.DistributorConfig {
 	// Create test analyzers
-	analyzers := map[string]*models.Analyzer{
-		"test-analyzer": {
+	analyzers := []config.AnalyzerConfig{
+		{
 			ID:               "test-analyzer",
 			Name:             "Test Analyzer",
 			Weight:           1.0,
 			ProcessingTimeMs: 1, // Fast for tests
-			IsHealthy:        true,
-			LastHealthCheck:  time.Now(),
 		},
 	}
 
 	// Create channels and context
 	retryChannel := make(chan models.LogPacket, 10)
-	ctx, _ := context.WithCancel(context.Background())
+	ctx := context.Background()
+
+	lifecycle := implementations.NewLifecycleStore(config.MaxTrackedPacketStatuses, config.MaxPacketTransitions)
+	router, _ := implementations.NewRouter(models.RoutingConfig{})
+	partitioner, _ := implementations.NewPartitioner(models.AffinityConfig{})
 
-	distributorConfig := &implementations.DistributorConfig{
-		LoadBalancer:    implementations.NewLoadBalancer(analyzers, logger),
-		HealthMonitor:   implementations.NewHealthMonitor(analyzers, logger),
-		PersistenceMgr:  implementations.NewPersistenceManager(logger),
-		RetryHandler:    implementations.NewRetryHandler(retryChannel, logger, ctx),
+	return &implementations.DistributorConfig{
+		Analyzers:       analyzers,
+		LoadBalancer:    implementations.NewLoadBalancer(logger),
+		Router:          router,
+		Hedger:          implementations.NewHedger(config.HedgeLatencyWindow, config.HedgeMinLatencies, config.HedgeBudgetBurst),
+		Partitioner:     partitioner,
+		PacketQueue:     implementations.NewPacketQueue(config.PacketChannelBuffer, implementations.DefaultLaneWeights()),
+		HealthMonitor:   implementations.NewHealthMonitor(implementations.DefaultCircuitBreakerConfig(), logger),
+		PersistenceMgr:  implementations.NewPersistenceManager(statePath, logger),
+		RetryHandler:    implementations.NewRetryHandler(retryChannel, lifecycle, deadLetters, metrics.New(), logger, ctx),
 		PacketProcessor: implementations.NewPacketProcessor(logger),
 		PacketValidator: implementations.NewPacketValidator(),
+		Deduplicator:    implementations.NewDeduplicator(config.DeduplicationWindow, config.MaxDeduplicationEntries, logger),
+		LifecycleStore:  lifecycle,
+		ResultHub:       implementations.NewResultHub(config.MaxResultSubscribers, config.ResultSubscriberBuffer, logger),
+		DeadLetterStore: deadLetters,
+		Metrics:         metrics.New(),
 	}
-
-	return implementations.NewDistributor(logger, distributorConfig)
 }
 
 func TestDistributor_Lifecycle(t *testing.T) {
 	logger := createTestLogger()
 	defer logger.Sync()
 
-	d := createTestDistributor(logger)
+	d := createTestDistributor(t, logger)
 
 	// Test initial state
 	assert.NotNil(t, d)
@@ -88,7 +118,7 @@ func TestDistributor_PacketValidation(t *testing.T) {
 	logger := createTestLogger()
 	defer logger.Sync()
 
-	d := createTestDistributor(logger)
+	d := createTestDistributor(t, logger)
 	require.NoError(t, d.Start())
 	defer d.Stop()
 
@@ -137,11 +167,159 @@ func TestDistributor_PacketValidation(t *testing.T) {
 	}
 }
 
+func TestDistributor_SubmitPacketErrors(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	d := createTestDistributor(t, logger)
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	err := d.SubmitPacket(models.LogPacket{ID: "empty"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, interfaces.ErrInvalidPacket), "Validation failures should wrap ErrInvalidPacket")
+	assert.False(t, errors.Is(err, interfaces.ErrSubmissionTimeout))
+
+	assert.NoError(t, d.SubmitPacket(createTestPacket()))
+}
+
+func TestDistributor_DuplicateSubmission(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	// Both distributors share the state file, like a restarted process
+	statePath := testStatePath(t)
+	d := implementations.NewDistributor(logger, newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), statePath))
+	require.NoError(t, d.Start())
+
+	packet := createTestPacket()
+	require.NoError(t, d.SubmitPacket(packet))
+
+	err := d.SubmitPacket(packet)
+	assert.True(t, errors.Is(err, interfaces.ErrDuplicatePacket), "Resubmitting the same packet ID should be a duplicate")
+
+	keyed := models.NewIdempotentLogPacket(packet.Messages, "", "client-key/0")
+	require.NoError(t, d.SubmitPacket(keyed))
+	assert.Equal(t, keyed.ID, models.NewIdempotentLogPacket(packet.Messages, "", "client-key/0").ID,
+		"Idempotent packets should get a stable ID")
+
+	// The same idempotency key from another tenant is a different submission
+	otherTenant := models.NewIdempotentLogPacket(packet.Messages, "tenant-b", "client-key/0")
+	assert.NotEqual(t, keyed.ID, otherTenant.ID)
+	require.NoError(t, d.SubmitPacket(otherTenant))
+
+	// Client-supplied packet IDs are scoped to the tenant too
+	sameID := packet
+	sameID.TenantID = "tenant-b"
+	require.NoError(t, d.SubmitPacket(sameID))
+	assert.True(t, errors.Is(d.SubmitPacket(sameID), interfaces.ErrDuplicatePacket))
+
+	assert.Equal(t, int64(2), d.GetStats().DuplicatePackets)
+	require.NoError(t, d.Stop())
+
+	// The deduplication window is persisted with the state and survives a restart
+	restarted := implementations.NewDistributor(logger, newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), statePath))
+	require.NoError(t, restarted.Start())
+	defer restarted.Stop()
+
+	err = restarted.SubmitPacket(keyed)
+	assert.True(t, errors.Is(err, interfaces.ErrDuplicatePacket), "Duplicates should be detected after a restart")
+}
+
+func TestDistributor_PacketStatus(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	d := createTestDistributor(t, logger)
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	_, found := d.GetPacketStatus("", "unknown")
+	assert.False(t, found)
+
+	packet := createTestPacket()
+	packet.TenantID = "tenant-a"
+	require.NoError(t, d.SubmitPacket(packet))
+
+	status, found := d.GetPacketStatus("tenant-a", packet.ID)
+	require.True(t, found)
+	assert.Equal(t, packet.ID, status.PacketID)
+	assert.Equal(t, "tenant-a", status.TenantID, "The owning tenant should be recorded")
+	_, found = d.GetPacketStatus("tenant-b", packet.ID)
+	assert.False(t, found, "Other tenants should not see the packet")
+	assert.Equal(t, models.PacketStateQueued, status.Transitions[0].State)
+
+	// Wait for the packet to leave the queue
+	assert.Eventually(t, func() bool {
+		status, _ := d.GetPacketStatus("tenant-a", packet.ID)
+		return status.State != models.PacketStateQueued
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status, _ = d.GetPacketStatus("tenant-a", packet.ID)
+	assert.Equal(t, models.PacketStateDispatched, status.Transitions[1].State)
+	assert.Equal(t, "test-analyzer", status.Transitions[1].AnalyzerID)
+}
+
+func TestDistributor_TenantsShareClientPacketIDs(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	cfg := newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), testStatePath(t))
+	cfg.PacketProcessor = reliableProcessor{implementations.NewPacketProcessor(logger)}
+	d := implementations.NewDistributor(logger, cfg)
+
+	results, unsubscribe, err := d.SubscribeResults(models.ResultFilter{TenantID: "tenant-a"})
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	packetA := createTestPacket()
+	packetA.ID = "p1"
+	packetA.TenantID = "tenant-a"
+	packetB := createTestPacket()
+	packetB.ID = "p1"
+	packetB.TenantID = "tenant-b"
+	require.NoError(t, d.SubmitPacket(packetA))
+	require.NoError(t, d.SubmitPacket(packetB))
+
+	for _, tenant := range []string{"tenant-a", "tenant-b"} {
+		assert.Eventually(t, func() bool {
+			status, found := d.GetPacketStatus(tenant, "p1")
+			return found && status.State == models.PacketStateSucceeded
+		}, 5*time.Second, 10*time.Millisecond, "%s's packet should be delivered", tenant)
+
+		status, _ := d.GetPacketStatus(tenant, "p1")
+		assert.Equal(t, tenant, status.TenantID)
+		states := make([]models.PacketState, len(status.Transitions))
+		for i, transition := range status.Transitions {
+			states[i] = transition.State
+		}
+		assert.Equal(t, []models.PacketState{models.PacketStateQueued, models.PacketStateDispatched, models.PacketStateSucceeded}, states,
+			"%s's history should not include the other tenant's packet", tenant)
+	}
+
+	// The tenant's subscription only receives its own packet's result
+	select {
+	case result := <-results:
+		assert.Equal(t, "p1", result.PacketID)
+		assert.Equal(t, "tenant-a", result.TenantID)
+	case <-time.After(time.Second):
+		t.Fatal("tenant-a's result was not streamed")
+	}
+	select {
+	case result := <-results:
+		t.Fatalf("unexpected result streamed to tenant-a: %+v", result)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
 func TestDistributor_BasicStats(t *testing.T) {
 	logger := createTestLogger()
 	defer logger.Sync()
 
-	d := createTestDistributor(logger)
+	d := createTestDistributor(t, logger)
 	require.NoError(t, d.Start())
 	defer d.Stop()
 
@@ -149,4 +327,415 @@ func TestDistributor_BasicStats(t *testing.T) {
 	assert.NotNil(t, stats)
 	assert.Greater(t, stats.ActiveAnalyzers, 0, "Should have active analyzers")
 	assert.GreaterOrEqual(t, stats.TotalPacketsReceived, int64(0))
+	capacity := 0
+	for _, priority := range models.Priorities {
+		require.Contains(t, stats.Lanes, priority, "Every priority lane should be reported")
+		capacity += stats.Lanes[priority].Capacity
+	}
+	assert.Equal(t, config.PacketChannelBuffer, capacity, "The lanes should share the packet queue's capacity")
+}
+
+func TestDistributor_AnalyzerRegistry(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	// Simulated failures would retry the packet and count it against the analyzer more than once
+	cfg := newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), testStatePath(t))
+	cfg.PacketProcessor = reliableProcessor{implementations.NewPacketProcessor(logger)}
+	d := implementations.NewDistributor(logger, cfg)
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	err := d.AddAnalyzer(config.AnalyzerConfig{ID: "test-analyzer", Name: "Duplicate", Weight: 0.5})
+	assert.True(t, errors.Is(err, interfaces.ErrAnalyzerExists))
+
+	err = d.AddAnalyzer(config.AnalyzerConfig{ID: "too-heavy", Name: "Too Heavy", Weight: 2.0})
+	assert.True(t, errors.Is(err, interfaces.ErrInvalidAnalyzer))
+
+	err = d.UpdateAnalyzer(config.AnalyzerConfig{ID: "missing", Name: "Missing", Weight: 0.5})
+	assert.True(t, errors.Is(err, interfaces.ErrAnalyzerNotFound))
+	assert.True(t, errors.Is(d.RemoveAnalyzer("missing"), interfaces.ErrAnalyzerNotFound))
+
+	// Route everything to the new analyzer
+	require.NoError(t, d.AddAnalyzer(config.AnalyzerConfig{ID: "slow-analyzer", Name: "Slow Analyzer", Weight: 1.0, ProcessingTimeMs: 300}))
+	require.NoError(t, d.UpdateAnalyzer(config.AnalyzerConfig{ID: "test-analyzer", Name: "Test Analyzer", Weight: 0}))
+
+	stats := d.GetStats()
+	require.Contains(t, stats.AnalyzerStats, "slow-analyzer")
+	assert.Equal(t, float64(0), stats.AnalyzerStats["test-analyzer"].Weight)
+	slow := stats.AnalyzerStats["slow-analyzer"]
+
+	packet := createTestPacket()
+	require.NoError(t, d.SubmitPacket(packet))
+	assert.Eventually(t, func() bool {
+		status, _ := d.GetPacketStatus("", packet.ID)
+		return status.State == models.PacketStateDispatched
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status, _ := d.GetPacketStatus("", packet.ID)
+	assert.Equal(t, "slow-analyzer", status.AnalyzerID)
+
+	// Removal returns at once and the packet already sent to the analyzer still finishes there
+	start := time.Now()
+	require.NoError(t, d.RemoveAnalyzer("slow-analyzer"))
+	assert.Less(t, time.Since(start), 200*time.Millisecond, "Removal should not wait for the drain")
+	assert.NotContains(t, d.GetStats().AnalyzerStats, "slow-analyzer")
+	assert.Eventually(t, func() bool {
+		return slow.GetProcessedCount()+slow.GetErrorCount() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestDistributor_UpdateAnalyzerWhileProcessing(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	cfg := newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), testStatePath(t))
+	cfg.PacketProcessor = reliableProcessor{implementations.NewPacketProcessor(logger)}
+	d := implementations.NewDistributor(logger, cfg)
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	packets := make([]models.LogPacket, 20)
+	for i := range packets {
+		packets[i] = createTestPacket()
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for _, packet := range packets {
+			assert.NoError(t, d.SubmitPacket(packet))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	// Reads the analyzer without holding the distributor's lock, like the API handlers
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			analyzer := d.GetStats().AnalyzerStats["test-analyzer"]
+			assert.NotEmpty(t, analyzer.Name)
+			assert.Positive(t, analyzer.Weight+float64(analyzer.ProcessingTimeMs))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	// Updates race with the packets being processed and with readers of the analyzer
+	for i := 0; i < 20; i++ {
+		require.NoError(t, d.UpdateAnalyzer(config.AnalyzerConfig{
+			ID:               "test-analyzer",
+			Name:             fmt.Sprintf("Test Analyzer v%d", i),
+			Weight:           1.0,
+			ProcessingTimeMs: 1 + i%3,
+		}))
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+
+	assert.Eventually(t, func() bool {
+		for _, packet := range packets {
+			if status, _ := d.GetPacketStatus("", packet.ID); status.State != models.PacketStateSucceeded {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	analyzer := d.GetStats().AnalyzerStats["test-analyzer"]
+	assert.Equal(t, "Test Analyzer v19", analyzer.Name)
+	assert.True(t, analyzer.IsHealthy, "Health should carry over to the updated analyzer")
+	assert.Positive(t, analyzer.GetProcessedCount(), "Counters should carry over to the updated analyzer")
+}
+
+func TestDistributor_AnalyzerOverride(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	d := createTestDistributor(t, logger)
+
+	err := d.SetAnalyzerOverride("missing", &models.HealthOverride{Mode: models.OverrideDraining})
+	assert.True(t, errors.Is(err, interfaces.ErrAnalyzerNotFound))
+
+	err = d.SetAnalyzerOverride("test-analyzer", &models.HealthOverride{Mode: "paused"})
+	assert.True(t, errors.Is(err, interfaces.ErrInvalidOverride))
+
+	err = d.SetAnalyzerOverride("test-analyzer", &models.HealthOverride{Mode: models.OverrideMaintenance})
+	assert.True(t, errors.Is(err, interfaces.ErrInvalidOverride), "Maintenance requires an expiry time")
+
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, d.SetAnalyzerOverride("test-analyzer", &models.HealthOverride{
+		Mode:      models.OverrideMaintenance,
+		Reason:    "upgrade",
+		ExpiresAt: &expiresAt,
+	}))
+
+	stats := d.GetStats()
+	assert.Equal(t, 0, stats.ActiveAnalyzers, "Analyzers in maintenance should not count as active")
+	assert.Equal(t, models.OverrideMaintenance, stats.AnalyzerStats["test-analyzer"].Override.Mode)
+
+	require.NoError(t, d.SetAnalyzerOverride("test-analyzer", nil))
+	assert.Equal(t, 1, d.GetStats().ActiveAnalyzers)
+}
+
+func TestDistributor_ReplayDeadLetters(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	deadLetters := newTestDeadLetterStore(t, logger)
+	d := createTestDistributorWithDeadLetters(t, logger, deadLetters)
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	// Already accepted once, so the replay has to bypass deduplication
+	timedOut := createTestPacket()
+	require.NoError(t, d.SubmitPacket(timedOut))
+	timedOut.RetryCount = config.MaxRetries
+
+	other := createTestPacket()
+	failedAt := time.Now().Add(-time.Hour)
+	require.NoError(t, deadLetters.Append(models.DeadLetterEntry{
+		Packet: timedOut, FinalError: "result channel timeout - system overloaded", AnalyzerID: "test-analyzer", FailedAt: failedAt,
+	}))
+	require.NoError(t, deadLetters.Append(models.DeadLetterEntry{
+		Packet: other, FinalError: "simulated processing error", AnalyzerID: "other-analyzer", FailedAt: failedAt.Add(time.Minute),
+	}))
+
+	result, err := d.ReplayDeadLetters(context.Background(), models.ReplayRequest{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Matched)
+	assert.ElementsMatch(t, []string{timedOut.ID, other.ID}, result.PacketIDs)
+
+	filter := models.DeadLetterFilter{ErrorContains: "timeout", AnalyzerID: "test-analyzer"}
+	result, err = d.ReplayDeadLetters(context.Background(), models.ReplayRequest{DeadLetterFilter: filter})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Replayed)
+	assert.Equal(t, 0, result.Failed)
+	assert.Equal(t, []string{timedOut.ID}, result.PacketIDs)
+
+	status, found := d.GetPacketStatus("", timedOut.ID)
+	require.True(t, found)
+	last := status.Transitions[len(status.Transitions)-1]
+	assert.Equal(t, 0, last.RetryCount, "Replayed packets should start with a fresh retry count")
+
+	// Replayed entries are marked and never replayed again
+	result, err = d.ReplayDeadLetters(context.Background(), models.ReplayRequest{DeadLetterFilter: filter})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Matched)
+
+	until := failedAt.Add(time.Second)
+	result, err = d.ReplayDeadLetters(context.Background(), models.ReplayRequest{
+		DeadLetterFilter: models.DeadLetterFilter{Until: &until},
+		DryRun:           true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Matched, "Only the already replayed entry failed before the cutoff")
+}
+
+func TestDistributor_FanOutDelivery(t *testing.T) {
+	tests := map[string]struct {
+		delivery models.Delivery
+		legs     int
+	}{
+		"all":    {models.Delivery{Mode: models.DeliveryAll}, 3},
+		"k-of-n": {models.Delivery{Mode: models.DeliveryKOfN, FanOut: 2, Quorum: 1}, 2},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger := createTestLogger()
+			defer logger.Sync()
+
+			cfg := newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), testStatePath(t))
+			cfg.Analyzers = []config.AnalyzerConfig{
+				{ID: "anomaly", Name: "Anomaly", Weight: 0.5, ProcessingTimeMs: 1},
+				{ID: "security", Name: "Security", Weight: 0.3, ProcessingTimeMs: 1},
+				{ID: "metrics", Name: "Metrics", Weight: 0.2, ProcessingTimeMs: 1},
+			}
+			router, err := implementations.NewRouter(models.RoutingConfig{Delivery: tt.delivery})
+			require.NoError(t, err)
+			cfg.Router = router
+			d := implementations.NewDistributor(logger, cfg)
+
+			results, unsubscribe, err := d.SubscribeResults(models.ResultFilter{})
+			require.NoError(t, err)
+			defer unsubscribe()
+
+			require.NoError(t, d.Start())
+			defer d.Stop()
+
+			packet := createTestPacket()
+			require.NoError(t, d.SubmitPacket(packet))
+
+			// Each leg publishes its own result
+			analyzers := make(map[string]bool)
+			timeout := time.After(3 * time.Second)
+			for len(analyzers) < tt.legs {
+				select {
+				case result := <-results:
+					if result.PacketID == packet.ID {
+						analyzers[result.AnalyzerID] = true
+					}
+				case <-timeout:
+					t.Fatalf("Timed out waiting for %d legs, got %v", tt.legs, analyzers)
+				}
+			}
+
+			// A failed leg is retried after a backoff before the packet can succeed
+			assert.Eventually(t, func() bool {
+				status, _ := d.GetPacketStatus("", packet.ID)
+				return status.State == models.PacketStateSucceeded
+			}, 10*time.Second, 20*time.Millisecond)
+
+			status, _ := d.GetPacketStatus("", packet.ID)
+			dispatched := 0
+			for _, transition := range status.Transitions {
+				if transition.State == models.PacketStateDispatched {
+					dispatched++
+				}
+			}
+			assert.GreaterOrEqual(t, dispatched, tt.legs)
+		})
+	}
+}
+
+func TestDistributor_AllDeliveryWaitsForUnavailableMember(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	cfg := newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), testStatePath(t))
+	cfg.Analyzers = []config.AnalyzerConfig{
+		{ID: "anomaly", Name: "Anomaly", Weight: 0.5, ProcessingTimeMs: 1},
+		{ID: "security", Name: "Security", Weight: 0.3, ProcessingTimeMs: 1},
+		{ID: "metrics", Name: "Metrics", Weight: 0.2, ProcessingTimeMs: 1},
+	}
+	router, err := implementations.NewRouter(models.RoutingConfig{
+		Groups:   map[string][]string{"pair": {"anomaly", "security"}},
+		Default:  "pair",
+		Delivery: models.Delivery{Mode: models.DeliveryAll},
+	})
+	require.NoError(t, err)
+	cfg.Router = router
+	cfg.PacketProcessor = reliableProcessor{implementations.NewPacketProcessor(logger)}
+	d := implementations.NewDistributor(logger, cfg)
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	// One group member is unhealthy during the first dispatch
+	require.NoError(t, d.SetAnalyzerOverride("security", &models.HealthOverride{Mode: models.OverrideForcedUnhealthy}))
+
+	packet := createTestPacket()
+	require.NoError(t, d.SubmitPacket(packet))
+
+	// The available member's success does not complete the delivery; the missing leg is retried
+	assert.Eventually(t, func() bool {
+		status, _ := d.GetPacketStatus("", packet.ID)
+		for _, transition := range status.Transitions {
+			if transition.State == models.PacketStateRetrying && transition.AnalyzerID == "security" {
+				return true
+			}
+		}
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+	status, _ := d.GetPacketStatus("", packet.ID)
+	assert.NotEqual(t, models.PacketStateSucceeded, status.State)
+
+	// Recovers before the retry; clearing the override would leave it unhealthy until the next health check
+	require.NoError(t, d.SetAnalyzerOverride("security", &models.HealthOverride{Mode: models.OverrideForcedHealthy}))
+	assert.Eventually(t, func() bool {
+		status, _ := d.GetPacketStatus("", packet.ID)
+		return status.State == models.PacketStateSucceeded
+	}, 10*time.Second, 20*time.Millisecond)
+
+	status, _ = d.GetPacketStatus("", packet.ID)
+	dispatched := make(map[string]int)
+	for _, transition := range status.Transitions {
+		if transition.State == models.PacketStateDispatched {
+			dispatched[transition.AnalyzerID]++
+		}
+	}
+	assert.Equal(t, map[string]int{"anomaly": 1, "security": 1}, dispatched,
+		"Each group member should get exactly one leg, and analyzers outside the group none")
+}
+
+func TestDistributor_BulkheadLimitsInFlightPackets(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	cfg := newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), testStatePath(t))
+	cfg.Analyzers = []config.AnalyzerConfig{
+		{ID: "slow", Name: "Slow", Weight: 0.9, ProcessingTimeMs: 400, MaxInFlight: 1},
+		{ID: "fast", Name: "Fast", Weight: 0.1, ProcessingTimeMs: 1},
+	}
+	d := implementations.NewDistributor(logger, cfg)
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	packets := make([]models.LogPacket, 6)
+	for i := range packets {
+		packets[i] = createTestPacket()
+		require.NoError(t, d.SubmitPacket(packets[i]))
+	}
+
+	// The saturated slow analyzer is skipped while the fast one has free slots
+	require.Eventually(t, func() bool {
+		for _, packet := range packets {
+			status, _ := d.GetPacketStatus("", packet.ID)
+			if status.AnalyzerID == "" {
+				return false
+			}
+		}
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+
+	stats := d.GetStats()
+	assert.LessOrEqual(t, stats.Bulkheads["slow"].InFlight, 1)
+	assert.Equal(t, 1, stats.Bulkheads["slow"].MaxInFlight)
+	assert.Equal(t, config.DefaultAnalyzerMaxInFlight, stats.Bulkheads["fast"].MaxInFlight)
+
+	sentToSlow := 0
+	for _, packet := range packets {
+		status, _ := d.GetPacketStatus("", packet.ID)
+		if status.AnalyzerID == "slow" {
+			sentToSlow++
+		}
+	}
+	assert.LessOrEqual(t, sentToSlow, 2, "Only one packet at a time should go to the saturated analyzer")
+}
+
+func TestDistributor_BulkheadQueuesWhenEveryAnalyzerIsSaturated(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	cfg := newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), testStatePath(t))
+	cfg.Analyzers = []config.AnalyzerConfig{
+		{ID: "only", Name: "Only", Weight: 1.0, ProcessingTimeMs: 200, MaxInFlight: 1},
+	}
+	d := implementations.NewDistributor(logger, cfg)
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	packets := make([]models.LogPacket, 3)
+	for i := range packets {
+		packets[i] = createTestPacket()
+		require.NoError(t, d.SubmitPacket(packets[i]))
+	}
+
+	require.Eventually(t, func() bool {
+		bulkhead := d.GetStats().Bulkheads["only"]
+		return bulkhead.InFlight == 1 && bulkhead.Queued == 2
+	}, 2*time.Second, 5*time.Millisecond, "Packets beyond the in-flight limit should wait in the analyzer's queue")
+
+	// Queued packets are processed one at a time; a simulated failure is retried after a backoff
+	assert.Eventually(t, func() bool {
+		for _, packet := range packets {
+			status, _ := d.GetPacketStatus("", packet.ID)
+			if status.State != models.PacketStateSucceeded {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Second, 20*time.Millisecond)
+
+	bulkhead := d.GetStats().Bulkheads["only"]
+	assert.Zero(t, bulkhead.InFlight)
+	assert.Zero(t, bulkhead.Queued)
 }
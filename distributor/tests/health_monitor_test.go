@@ -2,15 +2,28 @@ package tests
 
 import (
 	"context"
+	"errors"
 	"logs-distributor/distributor/implementations"
+	"logs-distributor/distributor/interfaces"
 	"logs-distributor/models"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
+// newTestHealthMonitor creates a health monitor with the analyzers registered
+func newTestHealthMonitor(analyzers map[string]*models.Analyzer, logger *zap.Logger) interfaces.HealthMonitor {
+	healthMonitor := implementations.NewHealthMonitor(implementations.DefaultCircuitBreakerConfig(), logger)
+	for _, analyzer := range analyzers {
+		healthMonitor.AddAnalyzer(analyzer)
+	}
+	return healthMonitor
+}
+
 func TestHealthMonitor_Lifecycle(t *testing.T) {
 	logger := createTestLogger()
 	defer logger.Sync()
@@ -24,7 +37,7 @@ func TestHealthMonitor_Lifecycle(t *testing.T) {
 		},
 	}
 
-	healthMonitor := implementations.NewHealthMonitor(analyzers, logger)
+	healthMonitor := newTestHealthMonitor(analyzers, logger)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -67,7 +80,7 @@ func TestHealthMonitor_MultipleAnalyzers(t *testing.T) {
 		},
 	}
 
-	healthMonitor := implementations.NewHealthMonitor(analyzers, logger)
+	healthMonitor := newTestHealthMonitor(analyzers, logger)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 	defer cancel()
@@ -82,3 +95,166 @@ func TestHealthMonitor_MultipleAnalyzers(t *testing.T) {
 	// Should handle multiple analyzers without issues
 	assert.True(t, true)
 }
+
+func TestHealthMonitor_SetOverride(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	analyzer := &models.Analyzer{ID: "test", Name: "Test Analyzer", Weight: 1.0, IsHealthy: true}
+	healthMonitor := newTestHealthMonitor(map[string]*models.Analyzer{"test": analyzer}, logger)
+
+	err := healthMonitor.SetOverride("missing", &models.HealthOverride{Mode: models.OverrideDraining})
+	assert.True(t, errors.Is(err, interfaces.ErrAnalyzerNotFound))
+
+	require.NoError(t, healthMonitor.SetOverride("test", &models.HealthOverride{Mode: models.OverrideForcedUnhealthy}))
+	assert.False(t, analyzer.IsHealthy, "Forced overrides should apply immediately")
+	assert.False(t, analyzer.AcceptsPackets(time.Now()))
+
+	require.NoError(t, healthMonitor.SetOverride("test", &models.HealthOverride{Mode: models.OverrideForcedHealthy}))
+	assert.True(t, analyzer.IsHealthy)
+
+	require.NoError(t, healthMonitor.SetOverride("test", nil))
+	assert.Nil(t, analyzer.Override)
+}
+
+func TestHealthOverride_Expiry(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Minute)
+	analyzer := &models.Analyzer{
+		ID:        "test",
+		IsHealthy: true,
+		Override:  &models.HealthOverride{Mode: models.OverrideMaintenance, ExpiresAt: &expiresAt},
+	}
+
+	assert.Equal(t, models.OverrideMaintenance, analyzer.Override.ActiveMode(now))
+	assert.False(t, analyzer.AcceptsPackets(now), "Analyzers in maintenance should not receive packets")
+
+	later := expiresAt.Add(time.Second)
+	assert.True(t, analyzer.Override.Expired(later))
+	assert.Equal(t, models.OverrideMode(""), analyzer.Override.ActiveMode(later))
+	assert.True(t, analyzer.AcceptsPackets(later), "Expired overrides should fall back to the health check")
+}
+
+// newTestCircuitMonitor creates a health monitor with one analyzer and a fast-probing circuit breaker
+func newTestCircuitMonitor() interfaces.HealthMonitor {
+	healthMonitor := implementations.NewHealthMonitor(implementations.CircuitBreakerConfig{
+		ConsecutiveFailures: 3,
+		FailureRatio:        0.5,
+		WindowSize:          10,
+		OpenTimeout:         50 * time.Millisecond,
+		HalfOpenProbes:      2,
+	}, createTestLogger())
+	healthMonitor.AddAnalyzer(&models.Analyzer{ID: "test", Name: "Test Analyzer", Weight: 1.0, IsHealthy: true})
+	return healthMonitor
+}
+
+// recordResults dispatches and records a result per entry
+func recordResults(healthMonitor interfaces.HealthMonitor, results ...bool) {
+	for _, success := range results {
+		healthMonitor.RecordDispatch("test")
+		healthMonitor.RecordResult("test", success)
+	}
+}
+
+// waitForCircuit waits until the analyzer's circuit reaches state
+func waitForCircuit(t *testing.T, healthMonitor interfaces.HealthMonitor, state models.CircuitState) {
+	require.Eventually(t, func() bool {
+		return healthMonitor.CircuitStats()["test"].State == state
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestCircuitBreaker_OpensOnConsecutiveFailures(t *testing.T) {
+	healthMonitor := newTestCircuitMonitor()
+
+	recordResults(healthMonitor, false, false, true, false, false)
+	assert.Equal(t, models.CircuitClosed, healthMonitor.CircuitStats()["test"].State, "A success should reset the consecutive failures")
+	assert.True(t, healthMonitor.Allows("test"))
+
+	recordResults(healthMonitor, false)
+	circuit := healthMonitor.CircuitStats()["test"]
+	assert.Equal(t, models.CircuitOpen, circuit.State)
+	assert.Equal(t, "3 consecutive failures", circuit.Reason)
+	assert.Equal(t, int64(1), circuit.Trips)
+	assert.False(t, healthMonitor.Allows("test"), "An open circuit should reject packets")
+}
+
+func TestCircuitBreaker_OpensOnFailureRatio(t *testing.T) {
+	healthMonitor := newTestCircuitMonitor()
+
+	// Never three failures in a row, but half of a full window
+	recordResults(healthMonitor, false, true, false, true, false, true, false, true, true)
+	assert.Equal(t, models.CircuitClosed, healthMonitor.CircuitStats()["test"].State, "The ratio should wait for a full window")
+
+	recordResults(healthMonitor, false)
+	circuit := healthMonitor.CircuitStats()["test"]
+	assert.Equal(t, models.CircuitOpen, circuit.State)
+	assert.Equal(t, "50% of the last 10 results failed", circuit.Reason)
+}
+
+func TestCircuitBreaker_HalfOpenProbes(t *testing.T) {
+	healthMonitor := newTestCircuitMonitor()
+
+	var changes int64
+	var mu sync.Mutex
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	healthMonitor.Start(ctx, &wg, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		changes++
+	})
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	recordResults(healthMonitor, false, false, false)
+	waitForCircuit(t, healthMonitor, models.CircuitHalfOpen)
+
+	// Only the probe limit is let through
+	for i := 0; i < 2; i++ {
+		require.True(t, healthMonitor.Allows("test"))
+		healthMonitor.RecordDispatch("test")
+	}
+	assert.False(t, healthMonitor.Allows("test"), "Half-open circuits should limit probes in flight")
+	assert.Equal(t, 2, healthMonitor.CircuitStats()["test"].ProbesInFlight)
+
+	healthMonitor.RecordResult("test", true)
+	assert.Equal(t, models.CircuitHalfOpen, healthMonitor.CircuitStats()["test"].State)
+	assert.True(t, healthMonitor.Allows("test"), "A finished probe should free its slot")
+
+	healthMonitor.RecordResult("test", true)
+	circuit := healthMonitor.CircuitStats()["test"]
+	assert.Equal(t, models.CircuitClosed, circuit.State, "Enough successful probes should close the circuit")
+	assert.Zero(t, circuit.ConsecutiveFailures)
+	assert.Zero(t, circuit.FailureRatio, "A closed circuit should start a fresh failure history")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.GreaterOrEqual(t, changes, int64(3), "Opening, half-opening and closing should report health changes")
+}
+
+func TestCircuitBreaker_ReopensOnFailedProbe(t *testing.T) {
+	healthMonitor := newTestCircuitMonitor()
+
+	recordResults(healthMonitor, false, false, false)
+	waitForCircuit(t, healthMonitor, models.CircuitHalfOpen)
+
+	recordResults(healthMonitor, false)
+	circuit := healthMonitor.CircuitStats()["test"]
+	assert.Equal(t, models.CircuitOpen, circuit.State)
+	assert.Equal(t, "probe failed", circuit.Reason)
+	assert.Equal(t, int64(2), circuit.Trips)
+
+	waitForCircuit(t, healthMonitor, models.CircuitHalfOpen)
+}
+
+func TestCircuitBreaker_ForcedHealthyBypassesCircuit(t *testing.T) {
+	healthMonitor := newTestCircuitMonitor()
+
+	recordResults(healthMonitor, false, false, false)
+	require.False(t, healthMonitor.Allows("test"))
+
+	require.NoError(t, healthMonitor.SetOverride("test", &models.HealthOverride{Mode: models.OverrideForcedHealthy}))
+	assert.True(t, healthMonitor.Allows("test"), "Forced healthy analyzers should receive packets despite an open circuit")
+}
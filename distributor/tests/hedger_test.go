@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	"logs-distributor/config"
+	"logs-distributor/distributor/implementations"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reliableProcessor processes packets like the embedded analyzers but never fails them,
+// so a test waiting on a slow analyzer is not stretched by retry backoffs
+type reliableProcessor struct {
+	interfaces.PacketProcessor
+}
+
+func (p reliableProcessor) ProcessPacket(ctx context.Context, analyzer *models.Analyzer, packet models.LogPacket) models.AnalysisResult {
+	result := models.AnalysisResult{PacketID: packet.ID, AnalyzerID: analyzer.ID, RetryCount: packet.RetryCount}
+	select {
+	case <-time.After(time.Duration(analyzer.ProcessingTimeMs) * time.Millisecond):
+		atomic.AddInt64(&analyzer.ProcessedCount, 1)
+		result.Success = true
+		result.Results = map[string]interface{}{"analyzer_type": analyzer.Name}
+	case <-ctx.Done():
+		result.Error = "processing cancelled"
+	}
+	result.ProcessedAt = time.Now()
+	return result
+}
+
+func TestHedger_DelayFromPercentile(t *testing.T) {
+	hedger := implementations.NewHedger(100, 10, config.HedgeBudgetBurst)
+	hedge := models.HedgeConfig{Percentile: 90, MinDelayMs: 5, MaxRate: 0.1}
+
+	// Too few latencies to know what is slow
+	for i := 1; i <= 9; i++ {
+		hedger.RecordLatency("default", time.Duration(i)*time.Millisecond)
+	}
+	_, ok := hedger.Delay("default", hedge)
+	assert.False(t, ok)
+
+	hedger.RecordLatency("default", 10*time.Millisecond)
+	delay, ok := hedger.Delay("default", hedge)
+	require.True(t, ok)
+	assert.Equal(t, 9*time.Millisecond, delay)
+
+	// The minimum delay applies to fast routes
+	hedge.MinDelayMs = 50
+	delay, _ = hedger.Delay("default", hedge)
+	assert.Equal(t, 50*time.Millisecond, delay)
+
+	// Routes keep their own latencies
+	_, ok = hedger.Delay("other", hedge)
+	assert.False(t, ok)
+
+	stats := hedger.Stats()
+	assert.Equal(t, int64(3), stats["default"].Packets)
+	assert.Equal(t, 50.0, stats["default"].DelayMs)
+}
+
+func TestHedger_LatencyWindow(t *testing.T) {
+	hedger := implementations.NewHedger(4, 1, config.HedgeBudgetBurst)
+	hedge := models.HedgeConfig{Percentile: 99, MaxRate: 0.1}
+
+	hedger.RecordLatency("default", time.Second)
+	for i := 0; i < 4; i++ {
+		hedger.RecordLatency("default", 10*time.Millisecond)
+	}
+
+	// The slow latency has left the window
+	delay, ok := hedger.Delay("default", hedge)
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Millisecond, delay)
+}
+
+func TestHedger_RateCap(t *testing.T) {
+	hedger := implementations.NewHedger(100, 1, 2)
+	hedge := models.HedgeConfig{Percentile: 95, MaxRate: 0.25}
+
+	// Four packets earn one hedge
+	for i := 0; i < 4; i++ {
+		hedger.Delay("default", hedge)
+	}
+	assert.True(t, hedger.Allow("default"))
+	assert.False(t, hedger.Allow("default"))
+
+	// Quiet periods save up at most the burst
+	for i := 0; i < 40; i++ {
+		hedger.Delay("default", hedge)
+	}
+	assert.True(t, hedger.Allow("default"))
+	assert.True(t, hedger.Allow("default"))
+	assert.False(t, hedger.Allow("default"))
+
+	hedger.RecordOutcome("default", models.HedgeSent)
+	hedger.RecordOutcome("default", models.HedgeWon)
+	hedger.RecordOutcome("default", models.HedgeDuplicate)
+	stats := hedger.Stats()["default"]
+	assert.Equal(t, models.HedgeStats{Packets: 44, Hedged: 1, Won: 1, Throttled: 2, Duplicates: 1}, stats)
+}
+
+func TestDistributor_HedgesSlowAnalyzer(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	cfg := newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), testStatePath(t))
+	cfg.Analyzers = []config.AnalyzerConfig{
+		{ID: "slow", Name: "Slow", Weight: 1.0, ProcessingTimeMs: 3000},
+		{ID: "fast", Name: "Fast", Weight: 1.0, ProcessingTimeMs: 1},
+	}
+	cfg.Router = newTestRouter(t, models.RoutingConfig{
+		Groups:  map[string][]string{"pool": {"slow", "fast"}},
+		Default: "pool",
+		Hedging: map[string]models.HedgeConfig{"pool": {MinDelayMs: 50, MaxRate: 1}},
+	})
+	hedger := implementations.NewHedger(config.HedgeLatencyWindow, 1, config.HedgeBudgetBurst)
+	hedger.RecordLatency("default", 10*time.Millisecond)
+	cfg.Hedger = hedger
+	cfg.PacketProcessor = reliableProcessor{implementations.NewPacketProcessor(logger)}
+	d := implementations.NewDistributor(logger, cfg)
+
+	results, unsubscribe, err := d.SubscribeResults(models.ResultFilter{})
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	packets := make(map[string]bool)
+	for i := 0; i < 6; i++ {
+		packet := createTestPacket()
+		packets[packet.ID] = true
+		require.NoError(t, d.SubmitPacket(packet))
+	}
+
+	// Packets sent to the slow analyzer are won by the fast one well before the slow one returns
+	succeeded := make(map[string]int)
+	timeout := time.After(2500 * time.Millisecond)
+	for len(succeeded) < len(packets) {
+		select {
+		case result := <-results:
+			if packets[result.PacketID] && result.Success {
+				assert.Equal(t, "fast", result.AnalyzerID)
+				succeeded[result.PacketID]++
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for hedged packets, got %d of %d", len(succeeded), len(packets))
+		}
+	}
+	for id, count := range succeeded {
+		assert.Equal(t, 1, count, "Packet %s should have exactly one successful result", id)
+	}
+
+	stats := d.GetStats().Hedging["default"]
+	assert.Equal(t, int64(len(packets)), stats.Packets)
+	assert.Positive(t, stats.Hedged)
+	assert.Equal(t, stats.Hedged, stats.Won)
+	assert.Equal(t, 50.0, stats.DelayMs)
+
+	// The slow analyzer's legs were cancelled rather than left to finish
+	assert.Eventually(t, func() bool {
+		return d.GetStats().Bulkheads["slow"].InFlight == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Zero(t, d.GetStats().AnalyzerStats["slow"].GetProcessedCount())
+}
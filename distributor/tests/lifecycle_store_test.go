@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"fmt"
+	"logs-distributor/distributor/implementations"
+	"logs-distributor/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycleStore_RecordTransitions(t *testing.T) {
+	store := implementations.NewLifecycleStore(10, 10)
+
+	store.Record("", "packet-1", models.PacketTransition{State: models.PacketStateQueued})
+	store.Record("", "packet-1", models.PacketTransition{State: models.PacketStateDispatched, AnalyzerID: "analyzer-a1"})
+	store.Record("", "packet-1", models.PacketTransition{State: models.PacketStateRetrying, AnalyzerID: "analyzer-a1", RetryCount: 1, Error: "boom"})
+	store.Record("", "packet-1", models.PacketTransition{State: models.PacketStateSucceeded, AnalyzerID: "analyzer-a2", RetryCount: 1})
+
+	status, found := store.Get("", "packet-1")
+	require.True(t, found)
+	assert.Equal(t, models.PacketStateSucceeded, status.State)
+	assert.Equal(t, "analyzer-a2", status.AnalyzerID)
+	assert.Equal(t, 1, status.RetryCount)
+	assert.Equal(t, "boom", status.LastError, "Last error should survive a later success")
+	assert.Len(t, status.Transitions, 4)
+	assert.False(t, status.Transitions[0].At.IsZero(), "Transitions should be timestamped")
+
+	// Returned status is a copy
+	status.Transitions[0].State = models.PacketStateRejected
+	again, _ := store.Get("", "packet-1")
+	assert.Equal(t, models.PacketStateQueued, again.Transitions[0].State)
+}
+
+func TestLifecycleStore_Bounds(t *testing.T) {
+	store := implementations.NewLifecycleStore(3, 3)
+
+	for i := 0; i < 4; i++ {
+		store.Record("", fmt.Sprintf("packet-%d", i), models.PacketTransition{State: models.PacketStateQueued})
+	}
+
+	_, found := store.Get("", "packet-0")
+	assert.False(t, found, "Least recently updated packet should be evicted")
+
+	for retry := 1; retry <= 5; retry++ {
+		store.Record("", "packet-1", models.PacketTransition{State: models.PacketStateRetrying, RetryCount: retry})
+	}
+
+	status, found := store.Get("", "packet-1")
+	require.True(t, found)
+	assert.Len(t, status.Transitions, 3)
+	assert.Equal(t, models.PacketStateQueued, status.Transitions[0].State, "First transition should be kept")
+	assert.Equal(t, 5, status.Transitions[2].RetryCount)
+}
+
+func TestLifecycleStore_TenantsShareIDs(t *testing.T) {
+	store := implementations.NewLifecycleStore(2, 10)
+
+	store.Record("tenant-a", "packet-1", models.PacketTransition{State: models.PacketStateQueued})
+	store.Record("tenant-b", "packet-1", models.PacketTransition{State: models.PacketStateDispatched, AnalyzerID: "analyzer-b1"})
+
+	status, found := store.Get("tenant-a", "packet-1")
+	require.True(t, found)
+	assert.Equal(t, "tenant-a", status.TenantID)
+	assert.Equal(t, models.PacketStateQueued, status.State, "Another tenant's packet with the same ID should not be touched")
+	assert.Len(t, status.Transitions, 1)
+
+	status, found = store.Get("tenant-b", "packet-1")
+	require.True(t, found)
+	assert.Equal(t, "analyzer-b1", status.AnalyzerID)
+
+	_, found = store.Get("", "packet-1")
+	assert.False(t, found)
+
+	// Evicting a tenant's packet leaves the other tenant's packet with the same ID
+	store.Record("tenant-b", "packet-1", models.PacketTransition{State: models.PacketStateSucceeded})
+	store.Record("tenant-a", "packet-2", models.PacketTransition{State: models.PacketStateQueued})
+	_, found = store.Get("tenant-a", "packet-1")
+	assert.False(t, found)
+	_, found = store.Get("tenant-b", "packet-1")
+	assert.True(t, found)
+}
@@ -1,14 +1,30 @@
 package tests
 
 import (
+	"fmt"
+	"logs-distributor/config"
 	"logs-distributor/distributor/implementations"
+	"logs-distributor/distributor/interfaces"
 	"logs-distributor/models"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
+// newTestLoadBalancer creates a load balancer with the analyzers registered
+func newTestLoadBalancer(analyzers map[string]*models.Analyzer, logger *zap.Logger) interfaces.LoadBalancer {
+	lb := implementations.NewLoadBalancer(logger)
+	for _, analyzer := range analyzers {
+		lb.AddAnalyzer(analyzer)
+	}
+	return lb
+}
+
 func TestLoadBalancer_WeightedSelection(t *testing.T) {
 	logger := createTestLogger()
 	defer logger.Sync()
@@ -29,12 +45,12 @@ func TestLoadBalancer_WeightedSelection(t *testing.T) {
 		},
 	}
 
-	lb := implementations.NewLoadBalancer(analyzers, logger)
+	lb := newTestLoadBalancer(analyzers, logger)
 
 	// Test multiple selections to verify weighted distribution
 	selections := make(map[string]int)
 	for i := 0; i < 100; i++ {
-		selected := lb.SelectAnalyzer()
+		selected := lb.SelectAnalyzer("", nil)
 		require.NotNil(t, selected)
 		selections[selected.ID]++
 	}
@@ -66,12 +82,12 @@ func TestLoadBalancer_HealthyOnly(t *testing.T) {
 		},
 	}
 
-	lb := implementations.NewLoadBalancer(analyzers, logger)
+	lb := newTestLoadBalancer(analyzers, logger)
 
 	// Test that only healthy analyzers are selected
 	selections := make(map[string]int)
 	for i := 0; i < 10; i++ {
-		selected := lb.SelectAnalyzer()
+		selected := lb.SelectAnalyzer("", nil)
 		if selected != nil {
 			selections[selected.ID]++
 		}
@@ -96,8 +112,8 @@ func TestLoadBalancer_NoHealthyAnalyzers(t *testing.T) {
 		},
 	}
 
-	lb := implementations.NewLoadBalancer(analyzers, logger)
-	selected := lb.SelectAnalyzer()
+	lb := newTestLoadBalancer(analyzers, logger)
+	selected := lb.SelectAnalyzer("", nil)
 	assert.Nil(t, selected, "Should return nil when no healthy analyzers")
 }
 
@@ -114,12 +130,460 @@ func TestLoadBalancer_UpdateWeights(t *testing.T) {
 		},
 	}
 
-	lb := implementations.NewLoadBalancer(analyzers, logger)
+	lb := newTestLoadBalancer(analyzers, logger)
 
 	// Should not panic when updating weights
 	lb.UpdateWeights()
 
 	// Should still work after weight update
-	selected := lb.SelectAnalyzer()
+	selected := lb.SelectAnalyzer("", nil)
 	assert.NotNil(t, selected)
 }
+
+func TestLoadBalancer_AddAndRemoveAnalyzers(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	lb := implementations.NewLoadBalancer(logger)
+	assert.Nil(t, lb.SelectAnalyzer("", nil), "Should return nil with no analyzers")
+
+	first := &models.Analyzer{ID: "first", Name: "First", Weight: 0.5, IsHealthy: true}
+	second := &models.Analyzer{ID: "second", Name: "Second", Weight: 0.5, IsHealthy: true}
+	lb.AddAnalyzer(first)
+	lb.AddAnalyzer(second)
+
+	selections := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		selections[lb.SelectAnalyzer("", nil).ID]++
+	}
+	assert.Equal(t, 5, selections["first"])
+	assert.Equal(t, 5, selections["second"])
+
+	// Re-adding picks up a new weight
+	second.Weight = 0.2
+	lb.AddAnalyzer(second)
+	lb.RemoveAnalyzer("first")
+	for i := 0; i < 10; i++ {
+		selected := lb.SelectAnalyzer("", nil)
+		require.NotNil(t, selected)
+		assert.Equal(t, "second", selected.ID)
+	}
+
+	lb.RemoveAnalyzer("second")
+	assert.Nil(t, lb.SelectAnalyzer("", nil))
+}
+
+func TestLoadBalancer_SkipsOverriddenAnalyzers(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	analyzers := map[string]*models.Analyzer{
+		"draining": {
+			ID:        "draining",
+			Name:      "Draining Analyzer",
+			Weight:    0.5,
+			IsHealthy: true,
+			Override:  &models.HealthOverride{Mode: models.OverrideDraining},
+		},
+		"forced": {
+			ID:        "forced",
+			Name:      "Forced Healthy Analyzer",
+			Weight:    0.5,
+			IsHealthy: false,
+			Override:  &models.HealthOverride{Mode: models.OverrideForcedHealthy},
+		},
+	}
+
+	lb := newTestLoadBalancer(analyzers, logger)
+	for i := 0; i < 10; i++ {
+		selected := lb.SelectAnalyzer("", nil)
+		require.NotNil(t, selected)
+		assert.Equal(t, "forced", selected.ID, "Draining analyzers should not receive new packets")
+	}
+}
+
+func TestLoadBalancer_EligibleAnalyzersOnly(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	analyzers := map[string]*models.Analyzer{
+		"general":    {ID: "general", Weight: 0.8, IsHealthy: true},
+		"security-1": {ID: "security-1", Weight: 0.3, IsHealthy: true},
+		"security-2": {ID: "security-2", Weight: 0.1, IsHealthy: true},
+	}
+	lb := newTestLoadBalancer(analyzers, logger)
+
+	security := func(analyzerID string) bool { return analyzerID != "general" }
+	selections := make(map[string]int)
+	for i := 0; i < 40; i++ {
+		selected := lb.SelectAnalyzer("", security)
+		require.NotNil(t, selected)
+		selections[selected.ID]++
+	}
+
+	// Weights are balanced within the eligible analyzers only
+	assert.Zero(t, selections["general"])
+	assert.Equal(t, 30, selections["security-1"])
+	assert.Equal(t, 10, selections["security-2"])
+
+	assert.Nil(t, lb.SelectAnalyzer("", func(string) bool { return false }), "Should return nil when no analyzer is eligible")
+}
+
+func TestLoadBalancer_Strategies(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	for _, strategy := range []implementations.LoadBalancerStrategy{"", implementations.LoadBalancerWeighted, implementations.LoadBalancerLatency, implementations.LoadBalancerHash, implementations.LoadBalancerAdaptive} {
+		lb, err := implementations.NewLoadBalancerForStrategy(strategy, logger)
+		require.NoError(t, err, "strategy %q", strategy)
+		lb.AddAnalyzer(&models.Analyzer{ID: "only", Weight: 1.0, IsHealthy: true})
+		assert.Equal(t, "only", lb.SelectAnalyzer("", nil).ID)
+	}
+
+	_, err := implementations.NewLoadBalancerForStrategy("random", logger)
+	assert.Error(t, err)
+}
+
+func TestLatencyLoadBalancer_AvoidsSlowAnalyzer(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	lb := implementations.NewLatencyLoadBalancer(time.Minute, logger)
+	for _, id := range []string{"fast-1", "fast-2", "slow"} {
+		lb.AddAnalyzer(&models.Analyzer{ID: id, Weight: 1.0, IsHealthy: true})
+	}
+	lb.RecordCompletion("slow", time.Second)
+
+	selections := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		selected := lb.SelectAnalyzer("", nil)
+		require.NotNil(t, selected)
+		selections[selected.ID]++
+		lb.RecordCompletion(selected.ID, time.Millisecond)
+	}
+
+	assert.Zero(t, selections["slow"], "A slow analyzer should lose every comparison")
+	assert.Greater(t, selections["fast-1"], 0)
+	assert.Greater(t, selections["fast-2"], 0)
+}
+
+func TestLatencyLoadBalancer_BalancesOutstandingPackets(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	lb := implementations.NewLatencyLoadBalancer(time.Minute, logger)
+	lb.AddAnalyzer(&models.Analyzer{ID: "heavy", Weight: 0.75, IsHealthy: true})
+	lb.AddAnalyzer(&models.Analyzer{ID: "light", Weight: 0.25, IsHealthy: true})
+
+	// Without completions the analyzers fill up in proportion to their weights
+	selections := make(map[string]int)
+	for i := 0; i < 40; i++ {
+		selections[lb.SelectAnalyzer("", nil).ID]++
+	}
+	assert.InDelta(t, 30, selections["heavy"], 1)
+	assert.InDelta(t, 10, selections["light"], 1)
+}
+
+func TestLatencyLoadBalancer_RetriesRecoveredAnalyzer(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	lb := implementations.NewLatencyLoadBalancer(10*time.Millisecond, logger)
+	lb.AddAnalyzer(&models.Analyzer{ID: "fast", Weight: 1.0, IsHealthy: true})
+	lb.AddAnalyzer(&models.Analyzer{ID: "slow", Weight: 1.0, IsHealthy: true})
+	lb.RecordCompletion("fast", time.Millisecond)
+	lb.RecordCompletion("slow", time.Second)
+	assert.Equal(t, "fast", lb.SelectAnalyzer("", nil).ID)
+	lb.RecordCompletion("fast", time.Millisecond)
+
+	// Once the slow estimate has faded, the analyzer is competitive again
+	time.Sleep(200 * time.Millisecond)
+	selections := map[string]bool{
+		lb.SelectAnalyzer("", nil).ID: true,
+		lb.SelectAnalyzer("", nil).ID: true,
+	}
+	assert.True(t, selections["slow"])
+	assert.True(t, selections["fast"])
+}
+
+func TestLatencyLoadBalancer_EligibleHealthyAnalyzersOnly(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	lb := implementations.NewLatencyLoadBalancer(time.Minute, logger)
+	assert.Nil(t, lb.SelectAnalyzer("", nil), "Should return nil with no analyzers")
+
+	lb.AddAnalyzer(&models.Analyzer{ID: "healthy", Weight: 0.5, IsHealthy: true})
+	lb.AddAnalyzer(&models.Analyzer{ID: "unhealthy", Weight: 0.5, IsHealthy: false})
+	lb.AddAnalyzer(&models.Analyzer{ID: "draining", Weight: 0.5, IsHealthy: true, Override: &models.HealthOverride{Mode: models.OverrideDraining}})
+	lb.AddAnalyzer(&models.Analyzer{ID: "unweighted", Weight: 0, IsHealthy: true})
+	lb.AddAnalyzer(&models.Analyzer{ID: "other-route", Weight: 0.5, IsHealthy: true})
+
+	notOtherRoute := func(analyzerID string) bool { return analyzerID != "other-route" }
+	for i := 0; i < 10; i++ {
+		selected := lb.SelectAnalyzer("", notOtherRoute)
+		require.NotNil(t, selected)
+		assert.Equal(t, "healthy", selected.ID)
+	}
+
+	lb.RemoveAnalyzer("healthy")
+	assert.Nil(t, lb.SelectAnalyzer("", notOtherRoute))
+}
+
+// BenchmarkLoadBalancer_DegradedAnalyzer sends packets to four analyzers that each process four
+// packets at a time, one of them ten times slower than the others, and reports the latency
+// percentiles, including time spent waiting for the analyzer, per strategy. The latency strategy's
+// gain shows at p90, and usually p95: it keeps sending the degraded analyzer a few percent of packets
+// to notice when it recovers, so p99 lands on the degraded analyzer for both strategies.
+func BenchmarkLoadBalancer_DegradedAnalyzer(b *testing.B) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	latencies := map[string]time.Duration{
+		"analyzer-a1": time.Millisecond,
+		"analyzer-a2": time.Millisecond,
+		"analyzer-a3": time.Millisecond,
+		"degraded":    10 * time.Millisecond,
+	}
+
+	for _, strategy := range []implementations.LoadBalancerStrategy{implementations.LoadBalancerWeighted, implementations.LoadBalancerLatency} {
+		b.Run(string(strategy), func(b *testing.B) {
+			lb, err := implementations.NewLoadBalancerForStrategy(strategy, logger)
+			require.NoError(b, err)
+			slots := make(map[string]chan struct{})
+			for id := range latencies {
+				lb.AddAnalyzer(&models.Analyzer{ID: id, Weight: 0.25, IsHealthy: true})
+				slots[id] = make(chan struct{}, 4)
+			}
+
+			var mu sync.Mutex
+			observed := make([]time.Duration, 0, b.N)
+			degraded := 0
+
+			b.SetParallelism(4)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					analyzer := lb.SelectAnalyzer("", nil)
+					start := time.Now()
+					slots[analyzer.ID] <- struct{}{}
+					time.Sleep(latencies[analyzer.ID])
+					<-slots[analyzer.ID]
+					latency := time.Since(start)
+					lb.RecordCompletion(analyzer.ID, latency)
+
+					mu.Lock()
+					observed = append(observed, latency)
+					if analyzer.ID == "degraded" {
+						degraded++
+					}
+					mu.Unlock()
+				}
+			})
+			b.StopTimer()
+
+			sort.Slice(observed, func(i, j int) bool { return observed[i] < observed[j] })
+			for _, p := range []float64{50, 90, 95, 99} {
+				index := int(float64(len(observed)-1) * p / 100)
+				b.ReportMetric(float64(observed[index])/float64(time.Millisecond), fmt.Sprintf("p%.0f-ms", p))
+			}
+			b.ReportMetric(100*float64(degraded)/float64(len(observed)), "degraded-%")
+		})
+	}
+}
+
+// newTestHashLoadBalancer creates a consistent-hash load balancer with analyzers of equal weight
+func newTestHashLoadBalancer(ids ...string) (interfaces.LoadBalancer, map[string]*models.Analyzer) {
+	lb := implementations.NewConsistentHashLoadBalancer(config.HashRingNodesPerWeight, config.HashLoadFactor, createTestLogger())
+	analyzers := make(map[string]*models.Analyzer)
+	for _, id := range ids {
+		analyzers[id] = &models.Analyzer{ID: id, Weight: 0.25, IsHealthy: true}
+		lb.AddAnalyzer(analyzers[id])
+	}
+	return lb, analyzers
+}
+
+// owners maps each key to the analyzer it is sent to when nothing is outstanding
+func owners(lb interfaces.LoadBalancer, keys []string) map[string]string {
+	owned := make(map[string]string, len(keys))
+	for _, key := range keys {
+		selected := lb.SelectAnalyzer(key, nil)
+		lb.RecordCompletion(selected.ID, 0)
+		owned[key] = selected.ID
+	}
+	return owned
+}
+
+func testKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("source-%d", i)
+	}
+	return keys
+}
+
+func TestConsistentHashLoadBalancer_OnlyLostAnalyzersKeysMove(t *testing.T) {
+	lb, analyzers := newTestHashLoadBalancer("analyzer-a1", "analyzer-a2", "analyzer-a3", "analyzer-a4")
+	keys := testKeys(500)
+	before := owners(lb, keys)
+	assert.Equal(t, before, owners(lb, keys), "A key should always reach the same analyzer")
+
+	// An unhealthy analyzer's keys move; every other key stays
+	analyzers["analyzer-a2"].IsHealthy = false
+	unhealthy := owners(lb, keys)
+	moved := 0
+	for _, key := range keys {
+		if before[key] == "analyzer-a2" {
+			assert.NotEqual(t, "analyzer-a2", unhealthy[key])
+			moved++
+		} else {
+			assert.Equal(t, before[key], unhealthy[key], "key %s moved", key)
+		}
+	}
+	assert.Greater(t, moved, 0)
+
+	// Keys return once it recovers
+	analyzers["analyzer-a2"].IsHealthy = true
+	assert.Equal(t, before, owners(lb, keys))
+
+	// Removing an analyzer moves only its keys, and adding it back restores them
+	lb.RemoveAnalyzer("analyzer-a3")
+	removed := owners(lb, keys)
+	for _, key := range keys {
+		if before[key] != "analyzer-a3" {
+			assert.Equal(t, before[key], removed[key], "key %s moved", key)
+		}
+	}
+	lb.AddAnalyzer(analyzers["analyzer-a3"])
+	assert.Equal(t, before, owners(lb, keys))
+}
+
+func TestConsistentHashLoadBalancer_VirtualNodesFollowWeight(t *testing.T) {
+	lb := implementations.NewConsistentHashLoadBalancer(config.HashRingNodesPerWeight, config.HashLoadFactor, createTestLogger())
+	lb.AddAnalyzer(&models.Analyzer{ID: "heavy", Weight: 0.75, IsHealthy: true})
+	lb.AddAnalyzer(&models.Analyzer{ID: "light", Weight: 0.25, IsHealthy: true})
+
+	counts := make(map[string]int)
+	for _, owner := range owners(lb, testKeys(4000)) {
+		counts[owner]++
+	}
+	assert.InDelta(t, 3000, counts["heavy"], 300)
+	assert.InDelta(t, 1000, counts["light"], 300)
+}
+
+func TestConsistentHashLoadBalancer_BoundedLoads(t *testing.T) {
+	lb, _ := newTestHashLoadBalancer("analyzer-a1", "analyzer-a2", "analyzer-a3", "analyzer-a4")
+	owner := owners(lb, []string{"hot-source"})["hot-source"]
+
+	// A hot key spills to the ring successors instead of piling up on its owner
+	counts := make(map[string]int)
+	for i := 0; i < 40; i++ {
+		counts[lb.SelectAnalyzer("hot-source", nil).ID]++
+	}
+	assert.LessOrEqual(t, counts[owner], 13, "The owner should stay within 1.25x its share")
+	assert.GreaterOrEqual(t, counts[owner], 10)
+	assert.Greater(t, len(counts), 1)
+
+	// Only analyzers passing the filter are considered
+	only := func(analyzerID string) bool { return analyzerID == "analyzer-a4" }
+	assert.Equal(t, "analyzer-a4", lb.SelectAnalyzer("hot-source", only).ID)
+	assert.Nil(t, lb.SelectAnalyzer("hot-source", func(string) bool { return false }))
+}
+
+// testAdaptiveConfig adjusts on every call so tests control the window by the counters they set
+func testAdaptiveConfig(window time.Duration) implementations.AdaptiveWeightConfig {
+	return implementations.AdaptiveWeightConfig{
+		Window:             window,
+		MinResults:         10,
+		TolerableErrorRate: 0.1,
+		Floor:              0.1,
+		Ceiling:            1.0,
+		RecoveryStep:       0.25,
+	}
+}
+
+func TestAdaptiveLoadBalancer_LowersWeightOnErrors(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	failing := &models.Analyzer{ID: "failing", Weight: 0.5, IsHealthy: true}
+	quiet := &models.Analyzer{ID: "quiet", Weight: 0.5, IsHealthy: true}
+	lb := implementations.NewAdaptiveLoadBalancer(testAdaptiveConfig(time.Hour), logger)
+	lb.AddAnalyzer(failing)
+	lb.AddAnalyzer(quiet)
+
+	weights := lb.EffectiveWeights()
+	assert.Equal(t, models.EffectiveWeight{Weight: 0.5, Reason: "configured weight"}, weights["failing"])
+
+	// 50% errors is 4/9 of the way from the tolerated rate to total failure
+	failing.ProcessedCount, failing.ErrorCount = 50, 50
+	quiet.ProcessedCount = 5
+	weights = lb.EffectiveWeights()
+	assert.InDelta(t, 0.5*5/9, weights["failing"].Weight, 0.001)
+	assert.Equal(t, 0.5, weights["failing"].ErrorRate)
+	assert.Equal(t, "lowered: 50% errors over 1h0m0s", weights["failing"].Reason)
+	assert.False(t, weights["failing"].ChangedAt.IsZero())
+	assert.Equal(t, models.EffectiveWeight{Weight: 0.5, Reason: "configured weight"}, weights["quiet"], "Too few results should leave the weight alone")
+
+	// The floor stops the weight falling to zero
+	failing.ErrorCount = 950
+	weights = lb.EffectiveWeights()
+	assert.InDelta(t, 0.05, weights["failing"].Weight, 0.0001)
+	assert.Equal(t, "lowered to floor: 95% errors over 1h0m0s", weights["failing"].Reason)
+
+	selections := make(map[string]int)
+	for i := 0; i < 110; i++ {
+		selections[lb.SelectAnalyzer("", nil).ID]++
+	}
+	assert.InDelta(t, 10, selections["failing"], 1, "Selections should follow the effective weights")
+
+	// A new configured weight starts over
+	failing.Weight = 0.4
+	lb.AddAnalyzer(failing)
+	assert.Equal(t, models.EffectiveWeight{Weight: 0.4, Reason: "configured weight"}, lb.EffectiveWeights()["failing"])
+}
+
+func TestAdaptiveLoadBalancer_RecoversGradually(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	analyzer := &models.Analyzer{ID: "flaky", Weight: 1.0, IsHealthy: true}
+	lb := implementations.NewAdaptiveLoadBalancer(testAdaptiveConfig(50*time.Millisecond), logger)
+	lb.AddAnalyzer(analyzer)
+	lb.EffectiveWeights()
+
+	analyzer.ErrorCount = 100
+	assert.InDelta(t, 0.1, lb.EffectiveWeights()["flaky"].Weight, 0.0001)
+
+	// Once the errors leave the window each adjustment restores at most a quarter of the weight
+	time.Sleep(60 * time.Millisecond)
+	lb.EffectiveWeights()
+	analyzer.ProcessedCount = 100
+	var steps []float64
+	var reasons []string
+	for i := 0; i < 4; i++ {
+		weight := lb.EffectiveWeights()["flaky"]
+		steps = append(steps, weight.Weight)
+		reasons = append(reasons, weight.Reason)
+	}
+	assert.InDeltaSlice(t, []float64{0.35, 0.6, 0.85, 1.0}, steps, 0.0001)
+	assert.Equal(t, "recovering: 0% errors over 50ms", reasons[0])
+	assert.Equal(t, "recovered: 0% errors over 50ms", reasons[3])
+}
+
+func TestLoadBalancer_EffectiveWeightsOnlyWhenAdaptive(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	for _, strategy := range []implementations.LoadBalancerStrategy{implementations.LoadBalancerWeighted, implementations.LoadBalancerLatency, implementations.LoadBalancerHash} {
+		lb, err := implementations.NewLoadBalancerForStrategy(strategy, logger)
+		require.NoError(t, err)
+		lb.AddAnalyzer(&models.Analyzer{ID: "only", Weight: 1.0, IsHealthy: true})
+		assert.Nil(t, lb.EffectiveWeights(), "strategy %s", strategy)
+	}
+
+	lb, err := implementations.NewLoadBalancerForStrategy(implementations.LoadBalancerAdaptive, logger)
+	require.NoError(t, err)
+	lb.AddAnalyzer(&models.Analyzer{ID: "only", Weight: 1.0, IsHealthy: true})
+	assert.Equal(t, 1.0, lb.EffectiveWeights()["only"].Weight)
+}
@@ -31,7 +31,7 @@ func TestPacketProcessor_ProcessPacket(t *testing.T) {
 		},
 	}
 
-	result := processor.ProcessPacket(analyzer, packet)
+	result := processor.ProcessPacket(context.Background(), analyzer, packet)
 
 	assert.Equal(t, "test-packet", result.PacketID)
 	assert.Equal(t, "test", result.AnalyzerID)
@@ -68,7 +68,7 @@ func TestPacketProcessor_MultipleMessages(t *testing.T) {
 		},
 	}
 
-	result := processor.ProcessPacket(analyzer, packet)
+	result := processor.ProcessPacket(context.Background(), analyzer, packet)
 
 	assert.Equal(t, "test-packet", result.PacketID)
 	assert.Equal(t, "test", result.AnalyzerID)
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"logs-distributor/distributor/implementations"
+	"logs-distributor/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createLevelPacket creates a packet with one message at the given level
+func createLevelPacket(id, level string) models.LogPacket {
+	return models.LogPacket{
+		ID:       id,
+		Messages: []models.LogMessage{models.NewLogMessage(level, "test message", "test-service", nil)},
+	}
+}
+
+func TestLogPacket_EffectivePriority(t *testing.T) {
+	tests := map[string]struct {
+		levels   []string
+		explicit models.Priority
+		expected models.Priority
+	}{
+		"error":              {[]string{"ERROR"}, "", models.PriorityHigh},
+		"fatal":              {[]string{"fatal"}, "", models.PriorityHigh},
+		"most severe wins":   {[]string{"DEBUG", "INFO", "ERROR"}, "", models.PriorityHigh},
+		"warn":               {[]string{"DEBUG", "WARN"}, "", models.PriorityNormal},
+		"unknown level":      {[]string{"NOTICE"}, "", models.PriorityNormal},
+		"debug only":         {[]string{"DEBUG", "TRACE"}, "", models.PriorityLow},
+		"explicit overrides": {[]string{"ERROR"}, models.PriorityLow, models.PriorityLow},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			packet := models.LogPacket{Priority: tt.explicit}
+			for _, level := range tt.levels {
+				packet.Messages = append(packet.Messages, models.NewLogMessage(level, "test", "test", nil))
+			}
+			assert.Equal(t, tt.expected, packet.EffectivePriority())
+		})
+	}
+}
+
+func TestPacketQueue_WeightedDequeue(t *testing.T) {
+	queue := implementations.NewPacketQueue(300, map[models.Priority]int{
+		models.PriorityHigh:   6,
+		models.PriorityNormal: 3,
+		models.PriorityLow:    1,
+	})
+
+	for i := 0; i < 30; i++ {
+		require.True(t, queue.TryEnqueue(createLevelPacket(fmt.Sprintf("low-%d", i), "DEBUG")))
+		require.True(t, queue.TryEnqueue(createLevelPacket(fmt.Sprintf("normal-%d", i), "INFO")))
+		require.True(t, queue.TryEnqueue(createLevelPacket(fmt.Sprintf("high-%d", i), "ERROR")))
+	}
+	assert.Equal(t, 90, queue.Depth())
+
+	// While every lane is backlogged, dequeues follow the weights, so the low lane is not starved
+	dequeued := make(map[models.Priority]int)
+	for i := 0; i < 20; i++ {
+		packet, ok := queue.Dequeue(context.Background())
+		require.True(t, ok)
+		dequeued[packet.EffectivePriority()]++
+	}
+	assert.Equal(t, map[models.Priority]int{models.PriorityHigh: 12, models.PriorityNormal: 6, models.PriorityLow: 2}, dequeued)
+
+	stats := queue.Stats()
+	assert.Equal(t, 18, stats[models.PriorityHigh].Depth)
+	assert.Equal(t, int64(2), stats[models.PriorityLow].Dequeued)
+	assert.Equal(t, 6, stats[models.PriorityHigh].Weight)
+}
+
+func TestPacketQueue_HighPriorityBypassesBacklog(t *testing.T) {
+	queue := implementations.NewPacketQueue(300, implementations.DefaultLaneWeights())
+
+	for i := 0; i < 50; i++ {
+		require.True(t, queue.TryEnqueue(createLevelPacket(fmt.Sprintf("debug-%d", i), "DEBUG")))
+	}
+	require.True(t, queue.TryEnqueue(createLevelPacket("critical", "FATAL")))
+
+	packet, ok := queue.Dequeue(context.Background())
+	require.True(t, ok)
+	assert.Equal(t, "critical", packet.ID, "A high priority packet should not wait behind the low priority backlog")
+
+	// The low lane is drained in order once it is the only backlogged lane
+	packet, ok = queue.Dequeue(context.Background())
+	require.True(t, ok)
+	assert.Equal(t, "debug-0", packet.ID)
+}
+
+func TestPacketQueue_BoundedLanes(t *testing.T) {
+	queue := implementations.NewPacketQueue(7, implementations.DefaultLaneWeights())
+	assert.Equal(t, 7, queue.Capacity(), "Lanes should share the queue's capacity")
+	stats := queue.Stats()
+	assert.Equal(t, 3, stats[models.PriorityHigh].Capacity, "The highest lane should get the remainder")
+	assert.Equal(t, 2, stats[models.PriorityNormal].Capacity)
+	assert.Equal(t, 2, stats[models.PriorityLow].Capacity)
+
+	require.True(t, queue.TryEnqueue(createLevelPacket("debug-1", "DEBUG")))
+	require.True(t, queue.TryEnqueue(createLevelPacket("debug-2", "DEBUG")))
+	assert.False(t, queue.TryEnqueue(createLevelPacket("debug-3", "DEBUG")), "A full lane should reject packets")
+	assert.True(t, queue.TryEnqueue(createLevelPacket("error-1", "ERROR")), "A full low lane should not block other lanes")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.False(t, queue.Enqueue(ctx, createLevelPacket("debug-4", "DEBUG")), "Enqueue should give up when its context is done")
+}
+
+func TestPacketQueue_DequeueWaitsForPackets(t *testing.T) {
+	queue := implementations.NewPacketQueue(10, implementations.DefaultLaneWeights())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, ok := queue.Dequeue(ctx)
+	assert.False(t, ok, "Dequeue should give up when its context is done")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		queue.TryEnqueue(createLevelPacket("late", "INFO"))
+	}()
+	packet, ok := queue.Dequeue(context.Background())
+	require.True(t, ok)
+	assert.Equal(t, "late", packet.ID)
+}
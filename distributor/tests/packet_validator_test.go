@@ -95,3 +95,22 @@ func TestPacketValidator_PacketTooLarge(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "packet size")
 }
+
+func TestPacketValidator_UnknownPriority(t *testing.T) {
+	validator := implementations.NewPacketValidator()
+
+	packet := models.LogPacket{
+		ID:       "test",
+		Priority: "urgent",
+		Messages: []models.LogMessage{
+			{ID: "msg1", Level: "ERROR", Message: "test", Source: "test"},
+		},
+	}
+
+	err := validator.ValidatePacket(packet)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown priority "urgent"`)
+
+	packet.Priority = models.PriorityLow
+	assert.NoError(t, validator.ValidatePacket(packet))
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"logs-distributor/config"
+	"logs-distributor/distributor/implementations"
+	"logs-distributor/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mixedSourcePacket has messages from two sources, interleaved. The sources hash to different analyzers
+// of the sticky routing test, so their parts never spill over each other's load bound.
+func mixedSourcePacket() models.LogPacket {
+	return models.NewLogPacket([]models.LogMessage{
+		models.NewLogMessage("INFO", "login", "auth", map[string]interface{}{"session": "s-1"}),
+		models.NewLogMessage("INFO", "page view", "nginx", map[string]interface{}{"session": "s-2"}),
+		models.NewLogMessage("INFO", "logout", "auth", map[string]interface{}{"session": "s-2"}),
+	})
+}
+
+func TestPartitioner_SplitsBySource(t *testing.T) {
+	partitioner, err := implementations.NewPartitioner(models.AffinityConfig{Key: "source"})
+	require.NoError(t, err)
+
+	packet := mixedSourcePacket()
+	packet.IdempotencyKey = "client-key"
+	parts := partitioner.Partition(packet)
+	require.Len(t, parts, 2)
+
+	assert.Equal(t, packet.ID+":key-1", parts[0].ID)
+	assert.Equal(t, "auth", parts[0].Affinity)
+	assert.Len(t, parts[0].Messages, 2)
+	assert.Empty(t, parts[0].IdempotencyKey, "Parts are deduplicated through the submitted packet")
+
+	assert.Equal(t, packet.ID+":key-2", parts[1].ID)
+	assert.Equal(t, "nginx", parts[1].Affinity)
+
+	// A packet from one source stays whole
+	single := models.NewLogPacket([]models.LogMessage{models.NewLogMessage("INFO", "login", "auth", nil)})
+	parts = partitioner.Partition(single)
+	require.Len(t, parts, 1)
+	assert.Equal(t, single.ID, parts[0].ID)
+	assert.Equal(t, "auth", parts[0].Affinity)
+}
+
+func TestPartitioner_MetadataKeyAndFirstMessage(t *testing.T) {
+	partitioner, err := implementations.NewPartitioner(models.AffinityConfig{Key: "metadata.session", Mixed: models.MixedAffinityFirst})
+	require.NoError(t, err)
+
+	packet := mixedSourcePacket()
+	parts := partitioner.Partition(packet)
+	require.Len(t, parts, 1, "The whole packet should follow its first message")
+	assert.Equal(t, packet.ID, parts[0].ID)
+	assert.Equal(t, "s-1", parts[0].Affinity)
+	assert.Len(t, parts[0].Messages, 3)
+
+	// Messages without the key share the empty key
+	partitioner, err = implementations.NewPartitioner(models.AffinityConfig{Key: "metadata.session"})
+	require.NoError(t, err)
+	packet.Messages = append(packet.Messages, models.NewLogMessage("INFO", "no session", "nginx", nil))
+	parts = partitioner.Partition(packet)
+	require.Len(t, parts, 3)
+	assert.Equal(t, []string{"s-1", "s-2", ""}, []string{parts[0].Affinity, parts[1].Affinity, parts[2].Affinity})
+}
+
+func TestPartitioner_Disabled(t *testing.T) {
+	partitioner, err := implementations.NewPartitioner(models.AffinityConfig{})
+	require.NoError(t, err)
+
+	packet := mixedSourcePacket()
+	parts := partitioner.Partition(packet)
+	require.Len(t, parts, 1)
+	assert.Equal(t, packet, parts[0])
+}
+
+func TestPartitioner_InvalidConfig(t *testing.T) {
+	tests := map[string]models.AffinityConfig{
+		"unknown key":        {Key: "level"},
+		"empty metadata key": {Key: "metadata."},
+		"unknown mixed mode": {Key: "source", Mixed: "majority"},
+	}
+
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := implementations.NewPartitioner(cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDistributor_StickyRoutingBySource(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	cfg := newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), testStatePath(t))
+	cfg.Analyzers = []config.AnalyzerConfig{
+		{ID: "analyzer-a1", Name: "A1", Weight: 0.5, ProcessingTimeMs: 1},
+		{ID: "analyzer-a2", Name: "A2", Weight: 0.5, ProcessingTimeMs: 1},
+		{ID: "analyzer-a3", Name: "A3", Weight: 0.5, ProcessingTimeMs: 1},
+	}
+	cfg.LoadBalancer = implementations.NewConsistentHashLoadBalancer(config.HashRingNodesPerWeight, config.HashLoadFactor, logger)
+	partitioner, err := implementations.NewPartitioner(models.AffinityConfig{Key: "source"})
+	require.NoError(t, err)
+	cfg.Partitioner = partitioner
+	d := implementations.NewDistributor(logger, cfg)
+
+	results, unsubscribe, err := d.SubscribeResults(models.ResultFilter{})
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	// Each source's part should reach the same analyzer in every packet
+	analyzerBySource := make(map[string]string)
+	for i := 0; i < 3; i++ {
+		packet := mixedSourcePacket()
+		require.NoError(t, d.SubmitPacket(packet))
+
+		status, found := d.GetPacketStatus("", packet.ID)
+		require.True(t, found)
+		assert.Equal(t, models.PacketStateSplit, status.State)
+
+		seen := 0
+		timeout := time.After(10 * time.Second) // allows for a simulated failure's retry
+		for seen < 2 {
+			select {
+			case result := <-results:
+				if !strings.HasPrefix(result.PacketID, packet.ID) || !result.Success {
+					continue
+				}
+				source := map[string]string{packet.ID + ":key-1": "auth", packet.ID + ":key-2": "nginx"}[result.PacketID]
+				if previous, exists := analyzerBySource[source]; exists {
+					assert.Equal(t, previous, result.AnalyzerID, "source %s moved", source)
+				}
+				analyzerBySource[source] = result.AnalyzerID
+				seen++
+			case <-timeout:
+				t.Fatalf("Timed out waiting for results of packet %d", i)
+			}
+		}
+	}
+}
@@ -2,9 +2,10 @@ package tests
 
 import (
 	"context"
+	"logs-distributor/config"
 	"logs-distributor/distributor/implementations"
 	"logs-distributor/models"
-	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -16,10 +17,7 @@ func TestPersistenceManager_SaveRecover(t *testing.T) {
 	logger := createTestLogger()
 	defer logger.Sync()
 
-	persistenceManager := implementations.NewPersistenceManager(logger)
-
-	// Clean up test file
-	defer os.Remove("distributor_state.json.gz")
+	persistenceManager := implementations.NewPersistenceManager(filepath.Join(t.TempDir(), config.StateFilePath), logger)
 
 	testState := &models.DistributorState{
 		Analyzers: map[string]*models.Analyzer{
@@ -49,10 +47,7 @@ func TestPersistenceManager_RecoverNonexistentFile(t *testing.T) {
 	logger := createTestLogger()
 	defer logger.Sync()
 
-	// Make sure file doesn't exist
-	os.Remove("distributor_state.json.gz")
-
-	persistenceManager := implementations.NewPersistenceManager(logger)
+	persistenceManager := implementations.NewPersistenceManager(filepath.Join(t.TempDir(), config.StateFilePath), logger)
 
 	_, err := persistenceManager.RecoverState()
 	assert.Error(t, err)
@@ -62,7 +57,7 @@ func TestPersistenceManager_StartCheckpointing(t *testing.T) {
 	logger := createTestLogger()
 	defer logger.Sync()
 
-	persistenceManager := implementations.NewPersistenceManager(logger)
+	persistenceManager := implementations.NewPersistenceManager(filepath.Join(t.TempDir(), config.StateFilePath), logger)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
 	defer cancel()
@@ -88,10 +83,7 @@ func TestPersistenceManager_SaveWithPendingPackets(t *testing.T) {
 	logger := createTestLogger()
 	defer logger.Sync()
 
-	persistenceManager := implementations.NewPersistenceManager(logger)
-
-	// Clean up test file
-	defer os.Remove("distributor_state.json.gz")
+	persistenceManager := implementations.NewPersistenceManager(filepath.Join(t.TempDir(), config.StateFilePath), logger)
 
 	testState := &models.DistributorState{
 		Analyzers: map[string]*models.Analyzer{},
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"errors"
+	"logs-distributor/distributor/implementations"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultHub_FilteredFanOut(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	hub := implementations.NewResultHub(10, 10, logger)
+
+	all, unsubscribeAll, err := hub.Subscribe(models.ResultFilter{})
+	require.NoError(t, err)
+	defer unsubscribeAll()
+
+	failed := false
+	failures, unsubscribeFailures, err := hub.Subscribe(models.ResultFilter{AnalyzerID: "analyzer-a1", Success: &failed})
+	require.NoError(t, err)
+	defer unsubscribeFailures()
+
+	tenant, unsubscribeTenant, err := hub.Subscribe(models.ResultFilter{TenantID: "tenant-a"})
+	require.NoError(t, err)
+	defer unsubscribeTenant()
+
+	hub.Publish(models.AnalysisResult{PacketID: "p1", AnalyzerID: "analyzer-a1", Success: true, TenantID: "tenant-a"})
+	hub.Publish(models.AnalysisResult{PacketID: "p2", AnalyzerID: "analyzer-a1", Success: false})
+	hub.Publish(models.AnalysisResult{PacketID: "p3", AnalyzerID: "analyzer-a2", Success: false, TenantID: "tenant-b"})
+
+	assert.Len(t, all, 3)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "p2", (<-failures).PacketID)
+	require.Len(t, tenant, 1, "Other tenants' results should be filtered out")
+	assert.Equal(t, "p1", (<-tenant).PacketID)
+	assert.Equal(t, 3, hub.SubscriberCount())
+}
+
+func TestResultHub_SlowSubscriberDoesNotBlock(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	hub := implementations.NewResultHub(10, 2, logger)
+	_, unsubscribe, err := hub.Subscribe(models.ResultFilter{})
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			hub.Publish(models.AnalysisResult{PacketID: "p", Success: true})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+	assert.Equal(t, int64(3), hub.DroppedCount())
+}
+
+func TestResultHub_UnsubscribeAndClose(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	hub := implementations.NewResultHub(1, 10, logger)
+
+	results, unsubscribe, err := hub.Subscribe(models.ResultFilter{})
+	require.NoError(t, err)
+
+	_, _, err = hub.Subscribe(models.ResultFilter{})
+	assert.True(t, errors.Is(err, interfaces.ErrTooManySubscribers))
+
+	unsubscribe()
+	unsubscribe() // safe to call twice
+	_, open := <-results
+	assert.False(t, open, "Channel should be closed on unsubscribe")
+
+	results, _, err = hub.Subscribe(models.ResultFilter{})
+	require.NoError(t, err)
+	hub.Close()
+	_, open = <-results
+	assert.False(t, open, "Channel should be closed when the hub closes")
+
+	_, _, err = hub.Subscribe(models.ResultFilter{})
+	assert.True(t, errors.Is(err, interfaces.ErrShuttingDown))
+}
@@ -2,13 +2,16 @@ package tests
 
 import (
 	"context"
+	"logs-distributor/config"
 	"logs-distributor/distributor/implementations"
+	"logs-distributor/metrics"
 	"logs-distributor/models"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRetryHandler_TrackUntrack(t *testing.T) {
@@ -19,7 +22,7 @@ func TestRetryHandler_TrackUntrack(t *testing.T) {
 	defer cancel()
 
 	retryChannel := make(chan models.LogPacket, 10)
-	retryHandler := implementations.NewRetryHandler(retryChannel, logger, ctx)
+	retryHandler := implementations.NewRetryHandler(retryChannel, implementations.NewLifecycleStore(100, 10), newTestDeadLetterStore(t, logger), metrics.New(), logger, ctx)
 
 	packet := models.LogPacket{
 		ID: "test-packet",
@@ -48,7 +51,8 @@ func TestRetryHandler_HandleFailedPacket(t *testing.T) {
 	defer cancel()
 
 	retryChannel := make(chan models.LogPacket, 10)
-	retryHandler := implementations.NewRetryHandler(retryChannel, logger, ctx)
+	lifecycle := implementations.NewLifecycleStore(100, 10)
+	retryHandler := implementations.NewRetryHandler(retryChannel, lifecycle, newTestDeadLetterStore(t, logger), metrics.New(), logger, ctx)
 
 	packet := models.LogPacket{
 		ID: "test-packet",
@@ -72,6 +76,12 @@ func TestRetryHandler_HandleFailedPacket(t *testing.T) {
 	// Should still be tracked for retry (since retry count < max)
 	trackedPackets := retryHandler.GetTrackedPackets()
 	assert.Len(t, trackedPackets, 1)
+
+	status, found := lifecycle.Get("", packet.ID)
+	assert.True(t, found)
+	assert.Equal(t, models.PacketStateRetrying, status.State)
+	assert.Equal(t, 1, status.RetryCount)
+	assert.Equal(t, "test error", status.LastError)
 }
 
 func TestRetryHandler_ProcessRetries(t *testing.T) {
@@ -82,11 +92,11 @@ func TestRetryHandler_ProcessRetries(t *testing.T) {
 	defer cancel()
 
 	retryChannel := make(chan models.LogPacket, 10)
-	packetChannel := make(chan models.LogPacket, 10)
-	retryHandler := implementations.NewRetryHandler(retryChannel, logger, ctx)
+	packetQueue := implementations.NewPacketQueue(10, implementations.DefaultLaneWeights())
+	retryHandler := implementations.NewRetryHandler(retryChannel, implementations.NewLifecycleStore(100, 10), newTestDeadLetterStore(t, logger), metrics.New(), logger, ctx)
 
 	var wg sync.WaitGroup
-	retryHandler.ProcessRetries(ctx, &wg, packetChannel)
+	retryHandler.ProcessRetries(ctx, &wg, packetQueue)
 
 	// Add a packet to retry
 	packet := models.LogPacket{ID: "test", Messages: []models.LogMessage{{ID: "msg1", Level: "INFO", Message: "test", Source: "test"}}}
@@ -108,7 +118,7 @@ func TestRetryHandler_GetFailedPacketsCount(t *testing.T) {
 	defer cancel()
 
 	retryChannel := make(chan models.LogPacket, 10)
-	retryHandler := implementations.NewRetryHandler(retryChannel, logger, ctx)
+	retryHandler := implementations.NewRetryHandler(retryChannel, implementations.NewLifecycleStore(100, 10), newTestDeadLetterStore(t, logger), metrics.New(), logger, ctx)
 
 	analyzers := map[string]*models.Analyzer{
 		"test": {
@@ -121,3 +131,108 @@ func TestRetryHandler_GetFailedPacketsCount(t *testing.T) {
 	count := retryHandler.GetFailedPacketsCount(analyzers)
 	assert.Equal(t, 5, count)
 }
+
+func TestRetryHandler_DeadLetters(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deadLetters := newTestDeadLetterStore(t, logger)
+	retryHandler := implementations.NewRetryHandler(make(chan models.LogPacket, 10), implementations.NewLifecycleStore(100, 10), deadLetters, metrics.New(), logger, ctx)
+
+	packet := createTestPacket()
+	packet.RetryCount = config.MaxRetries
+	retryHandler.TrackPacket(packet)
+	retryHandler.HandleFailedPacket(models.AnalysisResult{
+		PacketID:   packet.ID,
+		AnalyzerID: "analyzer-1",
+		Error:      "simulated processing error",
+	})
+
+	page, err := deadLetters.List(models.DeadLetterFilter{}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, packet.ID, page.Entries[0].Packet.ID)
+	assert.Equal(t, "analyzer-1", page.Entries[0].AnalyzerID)
+	assert.Equal(t, "simulated processing error", page.Entries[0].FinalError)
+	assert.Nil(t, page.Entries[0].ReplayedAt)
+	assert.Empty(t, retryHandler.GetTrackedPackets())
+}
+
+func TestRetryHandler_DeliveryLegs(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lifecycle := implementations.NewLifecycleStore(100, 10)
+	retryHandler := implementations.NewRetryHandler(make(chan models.LogPacket, 10), lifecycle, newTestDeadLetterStore(t, logger), metrics.New(), logger, ctx)
+
+	packet := createTestPacket()
+	retryHandler.TrackPacket(packet)
+	retryHandler.StartLegs(packet.ID, 2, []string{"analyzer-1", "analyzer-2", "analyzer-3"})
+
+	// A leg alone does not complete a 2-of-3 delivery
+	assert.False(t, retryHandler.HandleSucceededPacket(models.AnalysisResult{PacketID: packet.ID, AnalyzerID: "analyzer-1", Success: true}))
+
+	// Only the failed leg is released for redispatch
+	retryHandler.HandleFailedPacket(models.AnalysisResult{PacketID: packet.ID, AnalyzerID: "analyzer-2", Error: "test error"})
+	progress := retryHandler.DeliveryProgress(packet.ID)
+	assert.Equal(t, models.DeliveryProgress{Quorum: 2, Pending: []string{"analyzer-3"}, Succeeded: []string{"analyzer-1"}}, progress)
+
+	status, found := lifecycle.Get("", packet.ID)
+	require.True(t, found)
+	assert.Equal(t, models.PacketStateRetrying, status.State)
+
+	// Redispatched legs keep the quorum of the first dispatch
+	retryHandler.StartLegs(packet.ID, 1, []string{"analyzer-4"})
+	assert.Equal(t, 2, retryHandler.DeliveryProgress(packet.ID).Quorum)
+
+	assert.True(t, retryHandler.HandleSucceededPacket(models.AnalysisResult{PacketID: packet.ID, AnalyzerID: "analyzer-3", Success: true}))
+	assert.Empty(t, retryHandler.GetTrackedPackets())
+
+	// Legs finishing after the quorum are ignored
+	assert.False(t, retryHandler.HandleSucceededPacket(models.AnalysisResult{PacketID: packet.ID, AnalyzerID: "analyzer-4", Success: true}))
+	retryHandler.HandleFailedPacket(models.AnalysisResult{PacketID: packet.ID, AnalyzerID: "analyzer-4", Error: "late"})
+	assert.Empty(t, retryHandler.GetTrackedPackets())
+	assert.Equal(t, models.DeliveryProgress{}, retryHandler.DeliveryProgress(packet.ID))
+}
+
+func TestRetryHandler_HedgedLegs(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lifecycle := implementations.NewLifecycleStore(100, 10)
+	retryHandler := implementations.NewRetryHandler(make(chan models.LogPacket, 10), lifecycle, newTestDeadLetterStore(t, logger), metrics.New(), logger, ctx)
+
+	packet := createTestPacket()
+	retryHandler.TrackPacket(packet)
+	retryHandler.StartLegs(packet.ID, 1, []string{"slow"})
+	retryHandler.StartHedge(packet.ID, "fast")
+
+	// A failed leg is not retried while the other leg can still win
+	retryHandler.HandleFailedPacket(models.AnalysisResult{PacketID: packet.ID, AnalyzerID: "fast", Error: "test error"})
+	assert.Equal(t, models.DeliveryProgress{Quorum: 1, Pending: []string{"slow"}}, retryHandler.DeliveryProgress(packet.ID))
+	_, found := lifecycle.Get("", packet.ID)
+	assert.False(t, found, "No retry should be recorded")
+
+	// Once both legs failed the packet is retried
+	retryHandler.HandleFailedPacket(models.AnalysisResult{PacketID: packet.ID, AnalyzerID: "slow", Error: "test error"})
+	status, found := lifecycle.Get("", packet.ID)
+	require.True(t, found)
+	assert.Equal(t, models.PacketStateRetrying, status.State)
+	assert.Equal(t, 1, status.RetryCount)
+
+	// On the next attempt the first success wins and the duplicate is ignored
+	retryHandler.StartLegs(packet.ID, 1, []string{"slow"})
+	retryHandler.StartHedge(packet.ID, "fast")
+	assert.True(t, retryHandler.HandleSucceededPacket(models.AnalysisResult{PacketID: packet.ID, AnalyzerID: "fast", Success: true}))
+	assert.False(t, retryHandler.HandleSucceededPacket(models.AnalysisResult{PacketID: packet.ID, AnalyzerID: "slow", Success: true}))
+	assert.Empty(t, retryHandler.GetTrackedPackets())
+}
@@ -0,0 +1,249 @@
+package tests
+
+import (
+	"logs-distributor/config"
+	"logs-distributor/distributor/implementations"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRoutingConfig sends auth sources to the security group and errors to the error-triage analyzer
+func testRoutingConfig() models.RoutingConfig {
+	return models.RoutingConfig{
+		Groups: map[string][]string{
+			"security": {"security-1", "security-2"},
+		},
+		Rules: []models.RoutingRule{
+			{Name: "security", Sources: []string{"auth-*"}, Group: "security"},
+			{Name: "errors", Levels: []string{"error", "fatal"}, Analyzer: "error-triage"},
+		},
+	}
+}
+
+// newTestRouter creates a router from the config, failing the test on invalid rules
+func newTestRouter(t *testing.T, cfg models.RoutingConfig) interfaces.Router {
+	router, err := implementations.NewRouter(cfg)
+	require.NoError(t, err)
+	return router
+}
+
+func TestRouter_SplitsPacketByRoute(t *testing.T) {
+	router := newTestRouter(t, testRoutingConfig())
+
+	packet := models.NewLogPacket([]models.LogMessage{
+		models.NewLogMessage("INFO", "login ok", "auth-service", nil),
+		models.NewLogMessage("ERROR", "disk full", "storage", nil),
+		models.NewLogMessage("INFO", "request served", "web", nil),
+		models.NewLogMessage("ERROR", "bad password", "auth-ldap", nil), // the first matching rule wins
+	})
+	packet.IdempotencyKey = "client-key"
+
+	parts := router.Split(packet)
+	require.Len(t, parts, 3)
+
+	assert.Equal(t, packet.ID+":security", parts[0].ID)
+	assert.Equal(t, "security", parts[0].Route)
+	assert.Len(t, parts[0].Messages, 2)
+	assert.Empty(t, parts[0].IdempotencyKey, "Parts are deduplicated through the submitted packet")
+
+	assert.Equal(t, packet.ID+":errors", parts[1].ID)
+	assert.Equal(t, "errors", parts[1].Route)
+	assert.Equal(t, "disk full", parts[1].Messages[0].Message)
+
+	assert.Equal(t, packet.ID+":default", parts[2].ID)
+	assert.Empty(t, parts[2].Route)
+	assert.Equal(t, "web", parts[2].Messages[0].Source)
+
+	// A packet whose messages share a route stays whole
+	single := models.NewLogPacket([]models.LogMessage{models.NewLogMessage("FATAL", "panic", "worker", nil)})
+	parts = router.Split(single)
+	require.Len(t, parts, 1)
+	assert.Equal(t, single.ID, parts[0].ID)
+	assert.Equal(t, "errors", parts[0].Route)
+
+	stats := router.Stats()
+	require.Len(t, stats, 3)
+	assert.Equal(t, models.RouteStats{Name: "security", Analyzers: []string{"security-1", "security-2"}, Delivery: models.Delivery{Mode: models.DeliveryOne}, Packets: 1, Messages: 2}, stats[0])
+	assert.Equal(t, models.RouteStats{Name: "errors", Analyzers: []string{"error-triage"}, Delivery: models.Delivery{Mode: models.DeliveryOne}, Packets: 2, Messages: 2}, stats[1])
+	assert.Equal(t, models.RouteStats{Name: "default", Delivery: models.Delivery{Mode: models.DeliveryOne}, Packets: 1, Messages: 1}, stats[2])
+}
+
+func TestRouter_MessageAndMetadataConditions(t *testing.T) {
+	router := newTestRouter(t, models.RoutingConfig{
+		Rules: []models.RoutingRule{
+			{Name: "payments", Metadata: map[string]string{"team": "pay*", "region": "*"}, Message: `(?i)card`, Analyzer: "fraud"},
+		},
+	})
+
+	matching := models.NewLogMessage("WARN", "Card declined", "api", map[string]interface{}{"team": "payments", "region": "eu"})
+	missingKey := models.NewLogMessage("WARN", "card declined", "api", map[string]interface{}{"team": "payments"})
+	otherMessage := models.NewLogMessage("WARN", "timeout", "api", map[string]interface{}{"team": "payments", "region": "eu"})
+
+	assert.Equal(t, "payments", router.Split(models.NewLogPacket([]models.LogMessage{matching}))[0].Route)
+	assert.Empty(t, router.Split(models.NewLogPacket([]models.LogMessage{missingKey}))[0].Route)
+	assert.Empty(t, router.Split(models.NewLogPacket([]models.LogMessage{otherMessage}))[0].Route)
+}
+
+func TestRouter_Allows(t *testing.T) {
+	router := newTestRouter(t, testRoutingConfig())
+
+	assert.True(t, router.Allows("security", "security-2"))
+	assert.False(t, router.Allows("security", "error-triage"))
+	assert.True(t, router.Allows("errors", "error-triage"))
+
+	// Analyzers that a rule routes to only see that rule's messages
+	assert.True(t, router.Allows("", "analyzer-a1"))
+	assert.False(t, router.Allows("", "security-1"))
+	assert.False(t, router.Allows("", "error-triage"))
+	assert.True(t, router.Allows("removed-rule", "analyzer-a1"), "Unknown routes should fall back to the default route")
+
+	// A default group replaces the unrouted analyzers
+	cfg := testRoutingConfig()
+	cfg.Groups["general"] = []string{"analyzer-a1", "security-1"}
+	cfg.Default = "general"
+	router = newTestRouter(t, cfg)
+	assert.True(t, router.Allows("", "security-1"))
+	assert.False(t, router.Allows("", "analyzer-a2"))
+
+	// Without rules every analyzer is allowed and packets stay whole
+	router = newTestRouter(t, models.RoutingConfig{})
+	assert.True(t, router.Allows("", "security-1"))
+	assert.Nil(t, router.Stats())
+}
+
+func TestRouter_InvalidRules(t *testing.T) {
+	tests := map[string]models.RoutingConfig{
+		"missing target":         {Rules: []models.RoutingRule{{Name: "a", Levels: []string{"ERROR"}}}},
+		"both targets":           {Groups: map[string][]string{"g": {"x"}}, Rules: []models.RoutingRule{{Name: "a", Levels: []string{"ERROR"}, Analyzer: "x", Group: "g"}}},
+		"unknown group":          {Rules: []models.RoutingRule{{Name: "a", Levels: []string{"ERROR"}, Group: "missing"}}},
+		"empty group":            {Groups: map[string][]string{"g": {}}, Rules: []models.RoutingRule{{Name: "a", Levels: []string{"ERROR"}, Group: "g"}}},
+		"no conditions":          {Rules: []models.RoutingRule{{Name: "a", Analyzer: "x"}}},
+		"bad regex":              {Rules: []models.RoutingRule{{Name: "a", Message: "(", Analyzer: "x"}}},
+		"reserved name":          {Rules: []models.RoutingRule{{Name: "default", Levels: []string{"ERROR"}, Analyzer: "x"}}},
+		"unsafe name":            {Rules: []models.RoutingRule{{Name: "a/b", Levels: []string{"ERROR"}, Analyzer: "x"}}},
+		"duplicate name":         {Rules: []models.RoutingRule{{Name: "a", Levels: []string{"ERROR"}, Analyzer: "x"}, {Name: "a", Levels: []string{"INFO"}, Analyzer: "y"}}},
+		"unknown default":        {Default: "missing"},
+		"unknown delivery":       {Delivery: models.Delivery{Mode: "some"}},
+		"quorum over fan_out":    {Delivery: models.Delivery{Mode: models.DeliveryKOfN, FanOut: 2, Quorum: 3}},
+		"missing quorum":         {Delivery: models.Delivery{Mode: models.DeliveryKOfN, FanOut: 2}},
+		"fan_out with all":       {Delivery: models.Delivery{Mode: models.DeliveryAll, FanOut: 2}},
+		"fan_out over group":     {Groups: map[string][]string{"g": {"x", "y"}}, Rules: []models.RoutingRule{{Name: "a", Levels: []string{"ERROR"}, Group: "g", Delivery: models.Delivery{Mode: models.DeliveryKOfN, FanOut: 3, Quorum: 2}}}},
+		"unknown hedged group":   {Hedging: map[string]models.HedgeConfig{"missing": {}}},
+		"hedged single analyzer": {Groups: map[string][]string{"g": {"x"}}, Hedging: map[string]models.HedgeConfig{"g": {}}},
+		"hedge percentile":       {Groups: map[string][]string{"g": {"x", "y"}}, Hedging: map[string]models.HedgeConfig{"g": {Percentile: 100}}},
+		"hedge rate":             {Groups: map[string][]string{"g": {"x", "y"}}, Hedging: map[string]models.HedgeConfig{"g": {MaxRate: 1.5}}},
+		"hedged fan-out":         {Groups: map[string][]string{"g": {"x", "y"}}, Hedging: map[string]models.HedgeConfig{"g": {}}, Rules: []models.RoutingRule{{Name: "a", Levels: []string{"ERROR"}, Group: "g", Delivery: models.Delivery{Mode: models.DeliveryAll}}}},
+	}
+
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := implementations.NewRouter(cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRouter_Hedging(t *testing.T) {
+	cfg := testRoutingConfig()
+	cfg.Hedging = map[string]models.HedgeConfig{"security": {Percentile: 99}}
+	router := newTestRouter(t, cfg)
+
+	hedge, hedged := router.Hedging("security")
+	require.True(t, hedged)
+	assert.Equal(t, models.HedgeConfig{
+		Percentile: 99,
+		MinDelayMs: int(config.HedgeMinDelay / time.Millisecond),
+		MaxRate:    config.HedgeMaxRate,
+	}, hedge)
+
+	_, hedged = router.Hedging("errors")
+	assert.False(t, hedged)
+	_, hedged = router.Hedging("")
+	assert.False(t, hedged)
+
+	// The default route is hedged through its default group
+	cfg.Default = "security"
+	router = newTestRouter(t, cfg)
+	_, hedged = router.Hedging("")
+	assert.True(t, hedged)
+}
+
+func TestRouter_LoadRoutingConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing_rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"groups": {"security": ["security-1"]},
+		"rules": [{"name": "security", "sources": ["auth-*"], "group": "security", "delivery": "k-of-n", "fan_out": 1, "quorum": 1}],
+		"delivery": "all"
+	}`), 0644))
+
+	cfg, err := implementations.LoadRoutingConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Rules, 1)
+	assert.Equal(t, []string{"auth-*"}, cfg.Rules[0].Sources)
+	assert.Equal(t, []string{"security-1"}, cfg.Groups["security"])
+	assert.Equal(t, models.Delivery{Mode: models.DeliveryKOfN, FanOut: 1, Quorum: 1}, cfg.Rules[0].Delivery)
+	assert.Equal(t, models.DeliveryAll, cfg.Delivery.Mode)
+
+	_, err = implementations.LoadRoutingConfig(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestDistributor_RoutesPacketsByContent(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	cfg := newTestDistributorConfig(logger, newTestDeadLetterStore(t, logger), testStatePath(t))
+	cfg.Analyzers = []config.AnalyzerConfig{
+		{ID: "security-1", Name: "Security", Weight: 1.0, ProcessingTimeMs: 1},
+		{ID: "general", Name: "General", Weight: 1.0, ProcessingTimeMs: 1},
+	}
+	cfg.Router = newTestRouter(t, models.RoutingConfig{
+		Rules: []models.RoutingRule{{Name: "security", Sources: []string{"auth-*"}, Analyzer: "security-1"}},
+	})
+	d := implementations.NewDistributor(logger, cfg)
+
+	results, unsubscribe, err := d.SubscribeResults(models.ResultFilter{})
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	packet := models.NewLogPacket([]models.LogMessage{
+		models.NewLogMessage("INFO", "login ok", "auth-service", nil),
+		models.NewLogMessage("INFO", "request served", "web", nil),
+	})
+	require.NoError(t, d.SubmitPacket(packet))
+
+	status, found := d.GetPacketStatus("", packet.ID)
+	require.True(t, found)
+	assert.Equal(t, models.PacketStateSplit, status.State)
+	assert.Equal(t, []string{packet.ID + ":security", packet.ID + ":default"}, status.Parts)
+
+	analyzers := make(map[string]string)
+	timeout := time.After(3 * time.Second)
+	for len(analyzers) < 2 {
+		select {
+		case result := <-results:
+			if strings.HasPrefix(result.PacketID, packet.ID) {
+				analyzers[result.PacketID] = result.AnalyzerID
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for results, got %v", analyzers)
+		}
+	}
+	assert.Equal(t, "security-1", analyzers[packet.ID+":security"])
+	assert.Equal(t, "general", analyzers[packet.ID+":default"])
+
+	routes := d.GetStats().Routes
+	require.Len(t, routes, 2)
+	assert.Equal(t, int64(1), routes[0].Messages)
+}
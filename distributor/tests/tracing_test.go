@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"logs-distributor/config"
+	"logs-distributor/distributor/implementations"
+	"logs-distributor/metrics"
+	"logs-distributor/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testTraceParent = "00-" + testTraceID + "-00f067aa0ba902b7-01"
+)
+
+// recordSpans installs a tracer provider that records spans in memory for the duration of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	return recorder
+}
+
+// spanNames returns the names of spans in the given trace
+func spanNames(spans []sdktrace.ReadOnlySpan, traceID string) []string {
+	var names []string
+	for _, span := range spans {
+		if span.SpanContext().TraceID().String() == traceID {
+			names = append(names, span.Name())
+		}
+	}
+	return names
+}
+
+func TestTracing_PacketJoinsCallerTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	d := createTestDistributor(t, logger)
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	packet := createTestPacket()
+	packet.TraceParent = testTraceParent
+	require.NoError(t, d.SubmitPacket(packet))
+
+	// The result span ends last; wait for it
+	require.Eventually(t, func() bool {
+		return contains(spanNames(recorder.Ended(), testTraceID), "distributor.handle_result")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	names := spanNames(recorder.Ended(), testTraceID)
+	for _, name := range []string{
+		"distributor.submit",
+		"distributor.queue_wait",
+		"distributor.select_analyzer",
+		"analyzer.process",
+		"distributor.handle_result",
+	} {
+		assert.Contains(t, names, name, "Every pipeline stage should join the submitter's trace")
+	}
+}
+
+func TestTracing_RetriesJoinPacketTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	retryHandler := implementations.NewRetryHandler(make(chan models.LogPacket, 10), implementations.NewLifecycleStore(100, 10), newTestDeadLetterStore(t, logger), metrics.New(), logger, ctx)
+
+	retried := createTestPacket()
+	retried.TraceParent = testTraceParent
+	retryHandler.TrackPacket(retried)
+	retryHandler.HandleFailedPacket(models.AnalysisResult{PacketID: retried.ID, AnalyzerID: "test-analyzer", Error: "test error"})
+
+	exhausted := createTestPacket()
+	exhausted.ID = "exhausted-packet"
+	exhausted.TraceParent = testTraceParent
+	exhausted.RetryCount = config.MaxRetries
+	retryHandler.TrackPacket(exhausted)
+	retryHandler.HandleFailedPacket(models.AnalysisResult{PacketID: exhausted.ID, AnalyzerID: "test-analyzer", Error: "test error"})
+
+	// The backoff span stays open until the retry is requeued
+	require.Eventually(t, func() bool {
+		var started []sdktrace.ReadOnlySpan
+		for _, span := range recorder.Started() {
+			started = append(started, span)
+		}
+		return contains(spanNames(started, testTraceID), "retry.backoff")
+	}, time.Second, 10*time.Millisecond)
+	assert.Contains(t, spanNames(recorder.Ended(), testTraceID), "retry.dead_letter")
+}
+
+// contains reports whether name is in names
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package ingestion
+
+import (
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PacketBatcher groups individual log messages into LogPackets for the distributor.
+// Packets are flushed when they reach the batch size or when the flush interval elapses.
+type PacketBatcher struct {
+	distributor   interfaces.Distributor
+	logger        *zap.Logger
+	name          string
+	batchSize     int
+	flushInterval time.Duration
+	messages      chan models.LogMessage
+	stop          chan struct{}
+	stopOnce      sync.Once
+	wg            sync.WaitGroup
+
+	// Atomic counters
+	submittedPackets int64
+	droppedMessages  int64
+}
+
+// NewPacketBatcher creates a batcher that submits packets to the given distributor
+func NewPacketBatcher(d interfaces.Distributor, logger *zap.Logger, name string, batchSize, bufferSize int, flushInterval time.Duration) *PacketBatcher {
+	return &PacketBatcher{
+		distributor:   d,
+		logger:        logger,
+		name:          name,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		messages:      make(chan models.LogMessage, bufferSize),
+		stop:          make(chan struct{}),
+	}
+}
+
+// Start begins batching messages in the background
+func (b *PacketBatcher) Start() {
+	b.wg.Add(1)
+	go b.run()
+}
+
+// Stop flushes any buffered messages and waits for the batcher to exit
+func (b *PacketBatcher) Stop() {
+	b.stopOnce.Do(func() { close(b.stop) })
+	b.wg.Wait()
+}
+
+// Add queues a message for batching, returning false once the batcher is stopped
+func (b *PacketBatcher) Add(msg models.LogMessage) bool {
+	select {
+	case <-b.stop:
+		atomic.AddInt64(&b.droppedMessages, 1)
+		return false
+	default:
+	}
+
+	select {
+	case b.messages <- msg:
+		return true
+	case <-b.stop:
+		atomic.AddInt64(&b.droppedMessages, 1)
+		return false
+	}
+}
+
+// GetSubmittedPackets returns the number of packets accepted by the distributor
+func (b *PacketBatcher) GetSubmittedPackets() int64 {
+	return atomic.LoadInt64(&b.submittedPackets)
+}
+
+// GetDroppedMessages returns the number of messages that never reached the distributor
+func (b *PacketBatcher) GetDroppedMessages() int64 {
+	return atomic.LoadInt64(&b.droppedMessages)
+}
+
+// run collects messages and flushes them as packets
+func (b *PacketBatcher) run() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.LogMessage, 0, b.batchSize)
+	for {
+		select {
+		case msg := <-b.messages:
+			batch = append(batch, msg)
+			if len(batch) >= b.batchSize {
+				batch = b.flush(batch)
+			}
+		case <-ticker.C:
+			batch = b.flush(batch)
+		case <-b.stop:
+			// Drain whatever producers managed to queue before stopping
+			for {
+				select {
+				case msg := <-b.messages:
+					batch = append(batch, msg)
+					if len(batch) >= b.batchSize {
+						batch = b.flush(batch)
+					}
+				default:
+					b.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush submits the current batch and returns an empty batch for reuse
+func (b *PacketBatcher) flush(batch []models.LogMessage) []models.LogMessage {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	messages := make([]models.LogMessage, len(batch))
+	copy(messages, batch)
+	packet := models.NewLogPacket(messages)
+
+	if err := b.distributor.SubmitPacket(packet); err != nil {
+		atomic.AddInt64(&b.droppedMessages, int64(len(messages)))
+		b.logger.Error("Failed to submit batched packet",
+			zap.String("source", b.name),
+			zap.String("packet_id", packet.ID),
+			zap.Int("messages", len(messages)),
+			zap.Error(err),
+		)
+	} else {
+		atomic.AddInt64(&b.submittedPackets, 1)
+	}
+
+	return batch[:0]
+}
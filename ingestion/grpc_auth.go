@@ -0,0 +1,69 @@
+package ingestion
+
+import (
+	"context"
+	"logs-distributor/auth"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadata carries the API key; "authorization: Bearer <key>" is accepted as well
+const apiKeyMetadata = "x-api-key"
+
+// unaryAuthInterceptor requires the ingest scope on unary RPCs
+func (s *GRPCServer) unaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamAuthInterceptor requires the ingest scope on streaming RPCs
+func (s *GRPCServer) streamAuthInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticate checks the caller's API key and returns a context carrying its principal
+func (s *GRPCServer) authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var key string
+	if values := md.Get(apiKeyMetadata); len(values) > 0 {
+		key = values[0]
+	} else if values := md.Get("authorization"); len(values) > 0 {
+		key = auth.KeyFromBearer(values[0])
+	}
+
+	principal, ok := s.keyStore.Authenticate(key)
+	if !ok {
+		s.logger.Error("Rejected gRPC call with missing or invalid API key",
+			zap.String("method", method),
+			zap.String("client_addr", clientAddr(ctx)),
+		)
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid API key")
+	}
+	if !principal.HasScope(auth.ScopeIngest) {
+		return nil, status.Error(codes.PermissionDenied, "API key does not grant the ingest scope")
+	}
+
+	return auth.NewContext(ctx, principal), nil
+}
+
+// authenticatedStream overrides the stream context with one carrying the principal
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,313 @@
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"logs-distributor/auth"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"logs-distributor/proto/logingest"
+	"logs-distributor/ratelimit"
+	"logs-distributor/tracing"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServer implements the LogIngest gRPC service on top of the distributor
+type GRPCServer struct {
+	logingest.UnimplementedLogIngestServer
+
+	distributor interfaces.Distributor
+	keyStore    *auth.KeyStore
+	rateLimiter *ratelimit.Limiter
+	logger      *zap.Logger
+	server      *grpc.Server
+}
+
+// NewGRPCServer creates a gRPC server with the LogIngest service registered.
+// Calls must carry an API key with the ingest scope unless keyStore is nil,
+// and are rate limited unless rateLimiter is nil.
+func NewGRPCServer(d interfaces.Distributor, keyStore *auth.KeyStore, rateLimiter *ratelimit.Limiter, logger *zap.Logger) *GRPCServer {
+	s := &GRPCServer{
+		distributor: d,
+		keyStore:    keyStore,
+		rateLimiter: rateLimiter,
+		logger:      logger,
+	}
+
+	options := []grpc.ServerOption{grpc.MaxRecvMsgSize(config.GRPCMaxRecvMsgBytes)}
+	if keyStore != nil {
+		options = append(options,
+			grpc.UnaryInterceptor(s.unaryAuthInterceptor),
+			grpc.StreamInterceptor(s.streamAuthInterceptor),
+		)
+	}
+
+	s.server = grpc.NewServer(options...)
+	logingest.RegisterLogIngestServer(s.server, s)
+	return s
+}
+
+// Serve accepts connections on the listener until the server is stopped
+func (s *GRPCServer) Serve(listener net.Listener) error {
+	return s.server.Serve(listener)
+}
+
+// Shutdown stops accepting new RPCs and waits for in-flight ones,
+// forcing the server closed if the context expires first
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return fmt.Errorf("gRPC server did not stop gracefully: %w", ctx.Err())
+	}
+}
+
+// SubmitPackets submits a batch of packets, mirroring POST /api/v1/logs
+func (s *GRPCServer) SubmitPackets(ctx context.Context, req *logingest.SubmitPacketsRequest) (*logingest.SubmitPacketsResponse, error) {
+	ctx, span := s.startSpan(ctx, "SubmitPackets")
+	defer span.End()
+
+	if len(req.GetPackets()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "request must contain at least one packet")
+	}
+	if len(req.GetPackets()) > config.MaxMessagesPerPacket {
+		return nil, status.Errorf(codes.InvalidArgument, "too many packets: %d, maximum allowed: %d",
+			len(req.GetPackets()), config.MaxMessagesPerPacket)
+	}
+
+	messages := 0
+	for _, packet := range req.GetPackets() {
+		messages += len(packet.GetMessages())
+	}
+	if decision := s.checkRateLimit(ctx, len(req.GetPackets()), messages); !decision.Allowed {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfterSeconds(decision.RetryAfter))))
+		return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded for %s, retry after %s",
+			decision.Key, decision.RetryAfter.Round(time.Millisecond))
+	}
+
+	resp := &logingest.SubmitPacketsResponse{}
+	for _, packet := range req.GetPackets() {
+		s.submit(ctx, packet, resp)
+	}
+
+	s.logger.Info("gRPC log packets processed",
+		zap.Int32("total", resp.TotalPackets),
+		zap.Int32("successful", resp.Successful),
+		zap.Int32("failed", resp.Failed),
+		zap.String("client_addr", clientAddr(ctx)),
+	)
+
+	return resp, nil
+}
+
+// StreamPackets submits each packet as it arrives and reports once the client closes the stream
+func (s *GRPCServer) StreamPackets(stream logingest.LogIngest_StreamPacketsServer) error {
+	ctx, span := s.startSpan(stream.Context(), "StreamPackets")
+	defer span.End()
+	resp := &logingest.SubmitPacketsResponse{}
+
+	for {
+		packet, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// Streamed packets are limited one at a time so the rest of the stream is not lost
+		if decision := s.checkRateLimit(ctx, 1, len(packet.GetMessages())); !decision.Allowed {
+			resp.TotalPackets++
+			resp.Failed++
+			resp.Statuses = append(resp.Statuses, &logingest.PacketStatus{
+				PacketId: packet.GetId(),
+				Status:   logingest.PacketStatus_STATUS_FAILED,
+				Error: fmt.Sprintf("rate limit exceeded for %s, retry after %s",
+					decision.Key, decision.RetryAfter.Round(time.Millisecond)),
+			})
+			continue
+		}
+		s.submit(ctx, packet, resp)
+	}
+
+	s.logger.Info("gRPC log stream processed",
+		zap.Int32("total", resp.TotalPackets),
+		zap.Int32("successful", resp.Successful),
+		zap.Int32("failed", resp.Failed),
+		zap.String("client_addr", clientAddr(ctx)),
+	)
+
+	return stream.SendAndClose(resp)
+}
+
+// startSpan starts a server span for a call, continuing the client's traceparent metadata
+func (s *GRPCServer) startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = tracing.Extract(ctx, metadataCarrier(md))
+	return tracing.Tracer().Start(ctx, "logingest.v1.LogIngest/"+method, trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// metadataCarrier adapts gRPC metadata to the OpenTelemetry propagation carrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// submit hands a single packet to the distributor and records its outcome in resp
+func (s *GRPCServer) submit(ctx context.Context, pbPacket *logingest.LogPacket, resp *logingest.SubmitPacketsResponse) {
+	tenantID := auth.TenantFromContext(ctx)
+	packet := packetFromProto(pbPacket)
+	// Like the HTTP API, a packet without an ID gets one derived from its idempotency key,
+	// so a retried call reuses the same ID
+	if packet.ID == "" {
+		priority := packet.Priority
+		if packet.IdempotencyKey != "" {
+			packet = models.NewIdempotentLogPacket(packet.Messages, tenantID, packet.IdempotencyKey)
+		} else {
+			packet = models.NewLogPacket(packet.Messages)
+		}
+		packet.Priority = priority
+	}
+	packet.TenantID = tenantID
+	packet.TraceParent = tracing.TraceParent(ctx)
+
+	packetStatus := &logingest.PacketStatus{PacketId: packet.ID}
+
+	err := s.distributor.SubmitPacket(packet)
+	switch {
+	case err == nil:
+		packetStatus.Status = logingest.PacketStatus_STATUS_ACCEPTED
+	case errors.Is(err, interfaces.ErrDuplicatePacket):
+		// Already accepted earlier - acknowledge without counting it as a failure
+		packetStatus.Status = logingest.PacketStatus_STATUS_DUPLICATE
+		err = nil
+	case errors.Is(err, interfaces.ErrInvalidPacket):
+		packetStatus.Status = logingest.PacketStatus_STATUS_REJECTED
+	case errors.Is(err, interfaces.ErrSubmissionTimeout):
+		packetStatus.Status = logingest.PacketStatus_STATUS_TIMED_OUT
+	default:
+		packetStatus.Status = logingest.PacketStatus_STATUS_FAILED
+	}
+
+	resp.TotalPackets++
+	if err != nil {
+		packetStatus.Error = err.Error()
+		resp.Failed++
+		s.logger.Error("Failed to submit gRPC packet",
+			zap.String("packet_id", packet.ID),
+			zap.Error(err),
+			zap.String("client_addr", clientAddr(ctx)),
+		)
+	} else {
+		resp.Successful++
+		resp.ProcessedPackets = append(resp.ProcessedPackets, packet.ID)
+	}
+	resp.Statuses = append(resp.Statuses, packetStatus)
+}
+
+// packetFromProto converts a protobuf packet into the distributor model
+func packetFromProto(pbPacket *logingest.LogPacket) models.LogPacket {
+	packet := models.LogPacket{
+		ID:             pbPacket.GetId(),
+		Messages:       make([]models.LogMessage, 0, len(pbPacket.GetMessages())),
+		Priority:       models.Priority(pbPacket.GetPriority()),
+		IdempotencyKey: pbPacket.GetIdempotencyKey(),
+	}
+
+	for _, pbMsg := range pbPacket.GetMessages() {
+		msg := models.LogMessage{
+			ID:      pbMsg.GetId(),
+			Level:   pbMsg.GetLevel(),
+			Message: pbMsg.GetMessage(),
+			Source:  pbMsg.GetSource(),
+		}
+		if pbMsg.GetTimestamp() != nil {
+			msg.Timestamp = pbMsg.GetTimestamp().AsTime()
+		}
+		if pbMsg.GetMetadata() != nil {
+			msg.Metadata = pbMsg.GetMetadata().AsMap()
+		}
+		packet.Messages = append(packet.Messages, msg)
+	}
+
+	return packet
+}
+
+// checkRateLimit counts a submission against the caller's rate limits
+func (s *GRPCServer) checkRateLimit(ctx context.Context, packets, messages int) ratelimit.Decision {
+	if s.rateLimiter == nil {
+		return ratelimit.Decision{Allowed: true}
+	}
+
+	caller := ratelimit.Caller{ClientIP: clientAddr(ctx)}
+	if host, _, err := net.SplitHostPort(caller.ClientIP); err == nil {
+		caller.ClientIP = host
+	}
+	if principal, ok := auth.FromContext(ctx); ok {
+		caller.KeyName = principal.KeyName
+		caller.TenantID = principal.TenantID
+	}
+
+	decision := s.rateLimiter.Allow(caller, packets, messages)
+	if !decision.Allowed {
+		s.logger.Warn("gRPC submission rate limited",
+			zap.String("rule", string(decision.Rule)),
+			zap.String("key", decision.Key),
+			zap.Duration("retry_after", decision.RetryAfter),
+			zap.String("client_addr", clientAddr(ctx)),
+		)
+	}
+	return decision
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds, never less than one
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Max(1, math.Ceil(wait.Seconds())))
+}
+
+// clientAddr returns the remote address of the RPC caller for logging
+func clientAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
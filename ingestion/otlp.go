@@ -0,0 +1,229 @@
+package ingestion
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"logs-distributor/config"
+	"logs-distributor/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpServiceNameKey is the resource attribute that identifies the emitting service
+const otlpServiceNameKey = "service.name"
+
+// otlpResourcePrefix namespaces resource attributes inside LogMessage.Metadata
+const otlpResourcePrefix = "resource."
+
+// DecodeOTLPLogsRequest decodes an OTLP/HTTP logs export body in protobuf or JSON encoding
+func DecodeOTLPLogsRequest(body []byte, isJSON bool) (*collogspb.ExportLogsServiceRequest, error) {
+	req := &collogspb.ExportLogsServiceRequest{}
+
+	if isJSON {
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, req); err != nil {
+			return nil, fmt.Errorf("invalid OTLP JSON payload: %w", err)
+		}
+		// OTLP/JSON encodes trace and span IDs as hex rather than protobuf's base64, so
+		// protojson decoded the hex text as base64; re-encoding recovers the original text
+		forEachLogRecord(req, func(record *logspb.LogRecord) {
+			record.TraceId = hexIDFromJSON(record.TraceId)
+			record.SpanId = hexIDFromJSON(record.SpanId)
+		})
+		return req, nil
+	}
+
+	if err := proto.Unmarshal(body, req); err != nil {
+		return nil, fmt.Errorf("invalid OTLP protobuf payload: %w", err)
+	}
+	return req, nil
+}
+
+// TranslateOTLPLogs converts an OTLP export request into LogPackets the validator will accept
+func TranslateOTLPLogs(req *collogspb.ExportLogsServiceRequest) []models.LogPacket {
+	var messages []models.LogMessage
+
+	for _, resourceLogs := range req.GetResourceLogs() {
+		resourceAttrs := attributesToMap(resourceLogs.GetResource().GetAttributes())
+		source, _ := resourceAttrs[otlpServiceNameKey].(string)
+		if source == "" {
+			source = "otlp"
+		}
+
+		for _, scopeLogs := range resourceLogs.GetScopeLogs() {
+			scope := scopeLogs.GetScope()
+			for _, record := range scopeLogs.GetLogRecords() {
+				metadata := attributesToMap(record.GetAttributes())
+				for key, value := range resourceAttrs {
+					metadata[otlpResourcePrefix+key] = value
+				}
+				if scope.GetName() != "" {
+					metadata["scope.name"] = scope.GetName()
+				}
+				if scope.GetVersion() != "" {
+					metadata["scope.version"] = scope.GetVersion()
+				}
+				if len(record.GetTraceId()) > 0 {
+					metadata["trace_id"] = hex.EncodeToString(record.GetTraceId())
+				}
+				if len(record.GetSpanId()) > 0 {
+					metadata["span_id"] = hex.EncodeToString(record.GetSpanId())
+				}
+				if record.GetSeverityNumber() != logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+					metadata["severity_number"] = int32(record.GetSeverityNumber())
+				}
+
+				msg := models.LogMessage{
+					ID:        uuid.New().String(),
+					Timestamp: otlpTimestamp(record),
+					Level:     OTLPSeverityToLevel(record.GetSeverityText(), record.GetSeverityNumber()),
+					Message:   anyValueToString(record.GetBody()),
+					Source:    source,
+					Metadata:  metadata,
+				}
+				if len(msg.Message) > config.MaxLogMessageLength {
+					msg.Message = msg.Message[:config.MaxLogMessageLength]
+					msg.Metadata["truncated"] = true
+				}
+
+				messages = append(messages, msg)
+			}
+		}
+	}
+
+	return splitIntoPackets(messages)
+}
+
+// splitIntoPackets groups messages into packets that stay within the validator's count and size limits
+func splitIntoPackets(messages []models.LogMessage) []models.LogPacket {
+	var packets []models.LogPacket
+	start, size := 0, 0
+
+	for i, msg := range messages {
+		if i > start && (i-start >= config.MaxMessagesPerPacket || size+len(msg.Message) > config.MaxPacketSizeBytes) {
+			packets = append(packets, models.NewLogPacket(messages[start:i]))
+			start, size = i, 0
+		}
+		size += len(msg.Message)
+	}
+	if start < len(messages) {
+		packets = append(packets, models.NewLogPacket(messages[start:]))
+	}
+
+	return packets
+}
+
+// OTLPSeverityToLevel prefers the severity text and falls back to the severity number ranges
+func OTLPSeverityToLevel(severityText string, severityNumber logspb.SeverityNumber) string {
+	if severityText != "" {
+		return strings.ToUpper(severityText)
+	}
+
+	switch {
+	case severityNumber >= logspb.SeverityNumber_SEVERITY_NUMBER_FATAL:
+		return "FATAL"
+	case severityNumber >= logspb.SeverityNumber_SEVERITY_NUMBER_ERROR:
+		return "ERROR"
+	case severityNumber >= logspb.SeverityNumber_SEVERITY_NUMBER_WARN:
+		return "WARN"
+	case severityNumber >= logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+		severityNumber == logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED:
+		return "INFO"
+	default: // TRACE and DEBUG ranges
+		return "DEBUG"
+	}
+}
+
+// otlpTimestamp uses the event time, then the observed time, then the receive time
+func otlpTimestamp(record *logspb.LogRecord) time.Time {
+	if record.GetTimeUnixNano() != 0 {
+		return time.Unix(0, int64(record.GetTimeUnixNano()))
+	}
+	if record.GetObservedTimeUnixNano() != 0 {
+		return time.Unix(0, int64(record.GetObservedTimeUnixNano()))
+	}
+	return time.Now()
+}
+
+// attributesToMap flattens OTLP key/value attributes into plain Go values
+func attributesToMap(attrs []*commonpb.KeyValue) map[string]interface{} {
+	result := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		result[attr.GetKey()] = anyValueToInterface(attr.GetValue())
+	}
+	return result
+}
+
+// anyValueToInterface converts an OTLP AnyValue into the equivalent JSON-compatible value
+func anyValueToInterface(value *commonpb.AnyValue) interface{} {
+	switch v := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return v.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return v.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return v.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return base64.StdEncoding.EncodeToString(v.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		values := make([]interface{}, 0, len(v.ArrayValue.GetValues()))
+		for _, item := range v.ArrayValue.GetValues() {
+			values = append(values, anyValueToInterface(item))
+		}
+		return values
+	case *commonpb.AnyValue_KvlistValue:
+		return attributesToMap(v.KvlistValue.GetValues())
+	default:
+		return nil
+	}
+}
+
+// anyValueToString renders a log body; structured bodies are encoded as JSON
+func anyValueToString(value *commonpb.AnyValue) string {
+	converted := anyValueToInterface(value)
+	switch v := converted.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+}
+
+// hexIDFromJSON undoes protojson's base64 decoding of a hex-encoded trace or span ID
+func hexIDFromJSON(decoded []byte) []byte {
+	if len(decoded) == 0 {
+		return decoded
+	}
+	id, err := hex.DecodeString(base64.StdEncoding.EncodeToString(decoded))
+	if err != nil {
+		return decoded
+	}
+	return id
+}
+
+// forEachLogRecord visits every log record in an export request
+func forEachLogRecord(req *collogspb.ExportLogsServiceRequest, visit func(record *logspb.LogRecord)) {
+	for _, resourceLogs := range req.GetResourceLogs() {
+		for _, scopeLogs := range resourceLogs.GetScopeLogs() {
+			for _, record := range scopeLogs.GetLogRecords() {
+				visit(record)
+			}
+		}
+	}
+}
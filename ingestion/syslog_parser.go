@@ -0,0 +1,253 @@
+package ingestion
+
+import (
+	"bytes"
+	"fmt"
+	"logs-distributor/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultSyslogPriority is user.notice, which RFC 3164 relays assume for frames without a PRI
+const defaultSyslogPriority = 13
+
+// rfc3164TimestampLayout is the BSD syslog timestamp, e.g. "Jan  2 15:04:05"
+const rfc3164TimestampLayout = "Jan _2 15:04:05"
+
+// syslogNilValue marks an absent field in RFC 5424 headers
+const syslogNilValue = "-"
+
+// ParseSyslogMessage parses a single RFC 5424 or RFC 3164 frame into a LogMessage.
+// The format is detected from the version digit that follows the PRI in RFC 5424.
+func ParseSyslogMessage(frame []byte) (models.LogMessage, error) {
+	frame = bytes.TrimRight(frame, "\r\n\x00")
+	if len(frame) == 0 {
+		return models.LogMessage{}, fmt.Errorf("empty syslog frame")
+	}
+
+	priority, rest, err := parsePriority(frame)
+	if err != nil {
+		return models.LogMessage{}, err
+	}
+
+	// RFC 5424 frames carry a version ("1") right after the PRI, followed by a space
+	if len(rest) > 1 && rest[0] == '1' && rest[1] == ' ' {
+		return parseRFC5424(priority, string(rest[2:]))
+	}
+
+	return parseRFC3164(priority, string(rest), time.Now()), nil
+}
+
+// parsePriority extracts the <PRI> header; frames without one get the RFC 3164 default
+func parsePriority(frame []byte) (int, []byte, error) {
+	if frame[0] != '<' {
+		return defaultSyslogPriority, frame, nil
+	}
+
+	end := bytes.IndexByte(frame, '>')
+	if end < 2 || end > 4 {
+		return 0, nil, fmt.Errorf("invalid syslog priority header")
+	}
+
+	priority, err := strconv.Atoi(string(frame[1:end]))
+	if err != nil || priority < 0 || priority > 191 {
+		return 0, nil, fmt.Errorf("invalid syslog priority %q", frame[1:end])
+	}
+
+	return priority, frame[end+1:], nil
+}
+
+// parseRFC5424 parses everything after "<PRI>1 "
+func parseRFC5424(priority int, rest string) (models.LogMessage, error) {
+	fields := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		field, remaining, ok := strings.Cut(rest, " ")
+		if !ok {
+			return models.LogMessage{}, fmt.Errorf("truncated RFC 5424 header")
+		}
+		fields = append(fields, field)
+		rest = remaining
+	}
+	timestamp, hostname, appName, procID, msgID := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	structuredData, msg, err := splitStructuredData(rest)
+	if err != nil {
+		return models.LogMessage{}, err
+	}
+	// RFC 5424 allows a UTF-8 BOM in front of the free-form message
+	msg = strings.TrimPrefix(msg, "\ufeff")
+
+	logTime := time.Now()
+	if timestamp != syslogNilValue {
+		parsed, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			return models.LogMessage{}, fmt.Errorf("invalid RFC 5424 timestamp %q: %w", timestamp, err)
+		}
+		logTime = parsed
+	}
+
+	metadata := syslogMetadata(priority, "rfc5424")
+	setIfPresent(metadata, "hostname", hostname)
+	setIfPresent(metadata, "app_name", appName)
+	setIfPresent(metadata, "proc_id", procID)
+	setIfPresent(metadata, "msg_id", msgID)
+	if structuredData != syslogNilValue {
+		metadata["structured_data"] = structuredData
+	}
+
+	return newSyslogLogMessage(priority, logTime, hostname, appName, msg, metadata), nil
+}
+
+// splitStructuredData separates the STRUCTURED-DATA element from the message,
+// honouring the \" \\ and \] escapes allowed inside parameter values
+func splitStructuredData(rest string) (string, string, error) {
+	if strings.HasPrefix(rest, syslogNilValue) {
+		return syslogNilValue, strings.TrimPrefix(strings.TrimPrefix(rest, syslogNilValue), " "), nil
+	}
+	if !strings.HasPrefix(rest, "[") {
+		return "", "", fmt.Errorf("invalid RFC 5424 structured data")
+	}
+
+	inElement, inQuotes, escaped := false, false, false
+	for i := 0; i < len(rest); i++ {
+		ch := rest[i]
+		switch {
+		case escaped:
+			escaped = false
+		case ch == '\\' && inQuotes:
+			escaped = true
+		case ch == '"' && inElement:
+			inQuotes = !inQuotes
+		case ch == '[' && !inQuotes:
+			inElement = true
+		case ch == ']' && !inQuotes:
+			inElement = false
+			// Structured data ends at the first closing bracket not followed by another element
+			if i+1 == len(rest) || rest[i+1] != '[' {
+				return rest[:i+1], strings.TrimPrefix(rest[i+1:], " "), nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated RFC 5424 structured data")
+}
+
+// parseRFC3164 parses a BSD syslog frame leniently, since real-world senders rarely follow it exactly
+func parseRFC3164(priority int, rest string, now time.Time) models.LogMessage {
+	logTime := now
+	hostname := ""
+
+	if len(rest) >= len(rfc3164TimestampLayout) {
+		if parsed, err := time.ParseInLocation(rfc3164TimestampLayout, rest[:len(rfc3164TimestampLayout)], now.Location()); err == nil {
+			logTime = withInferredYear(parsed, now)
+			rest = strings.TrimPrefix(rest[len(rfc3164TimestampLayout):], " ")
+
+			// The hostname follows the timestamp unless the sender omitted it
+			if host, remaining, ok := strings.Cut(rest, " "); ok && !strings.HasSuffix(host, ":") {
+				hostname = host
+				rest = remaining
+			}
+		}
+	}
+
+	appName, procID, msg := splitTag(rest)
+
+	metadata := syslogMetadata(priority, "rfc3164")
+	setIfPresent(metadata, "hostname", hostname)
+	setIfPresent(metadata, "app_name", appName)
+	setIfPresent(metadata, "proc_id", procID)
+
+	return newSyslogLogMessage(priority, logTime, hostname, appName, msg, metadata)
+}
+
+// splitTag splits "app[pid]: message" into its parts; frames without a tag return the whole content
+func splitTag(content string) (string, string, string) {
+	end := strings.IndexAny(content, ":[ ")
+	if end <= 0 || end > 48 {
+		return "", "", content
+	}
+
+	tag := content[:end]
+	rest := content[end:]
+	procID := ""
+
+	if rest[0] == '[' {
+		closing := strings.IndexByte(rest, ']')
+		if closing < 0 {
+			return "", "", content
+		}
+		procID = rest[1:closing]
+		rest = rest[closing+1:]
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return "", "", content
+	}
+
+	return tag, procID, strings.TrimPrefix(rest[1:], " ")
+}
+
+// withInferredYear fills in the year RFC 3164 timestamps leave out,
+// rolling back a year for timestamps that would otherwise be in the future
+func withInferredYear(t, now time.Time) time.Time {
+	withYear := time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+	if withYear.After(now.Add(24 * time.Hour)) {
+		withYear = withYear.AddDate(-1, 0, 0)
+	}
+	return withYear
+}
+
+// newSyslogLogMessage builds the LogMessage, preferring the app name over the hostname as Source
+func newSyslogLogMessage(priority int, timestamp time.Time, hostname, appName, msg string, metadata map[string]interface{}) models.LogMessage {
+	source := hostname
+	if appName != "" && appName != syslogNilValue {
+		source = appName
+	}
+	if source == "" || source == syslogNilValue {
+		source = "syslog"
+	}
+
+	return models.LogMessage{
+		ID:        uuid.New().String(),
+		Timestamp: timestamp,
+		Level:     SyslogSeverityToLevel(priority % 8),
+		Message:   msg,
+		Source:    source,
+		Metadata:  metadata,
+	}
+}
+
+// SyslogSeverityToLevel maps a syslog severity (0-7) onto the distributor's log levels
+func SyslogSeverityToLevel(severity int) string {
+	switch {
+	case severity <= 2: // emergency, alert, critical
+		return "FATAL"
+	case severity == 3:
+		return "ERROR"
+	case severity == 4:
+		return "WARN"
+	case severity <= 6: // notice, informational
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// syslogMetadata returns the metadata shared by both syslog formats
+func syslogMetadata(priority int, format string) map[string]interface{} {
+	return map[string]interface{}{
+		"syslog_format":   format,
+		"syslog_facility": priority / 8,
+		"syslog_severity": priority % 8,
+	}
+}
+
+// setIfPresent stores a header field unless it is empty or the RFC 5424 nil value
+func setIfPresent(metadata map[string]interface{}, key, value string) {
+	if value != "" && value != syslogNilValue {
+		metadata[key] = value
+	}
+}
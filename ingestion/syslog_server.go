@@ -0,0 +1,290 @@
+package ingestion
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"net"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// maxOctetCountDigits bounds the length prefix of octet-counted frames
+const maxOctetCountDigits = 10
+
+// SyslogServer accepts syslog frames over UDP and TCP and forwards them to the distributor
+type SyslogServer struct {
+	logger  *zap.Logger
+	batcher *PacketBatcher
+
+	mu          sync.Mutex
+	udpConn     net.PacketConn
+	tcpListener net.Listener
+	tcpConns    map[net.Conn]struct{}
+	closed      bool
+	wg          sync.WaitGroup
+}
+
+// NewSyslogServer creates a syslog server that batches parsed messages into packets
+func NewSyslogServer(d interfaces.Distributor, logger *zap.Logger) *SyslogServer {
+	batcher := NewPacketBatcher(d, logger, "syslog",
+		config.SyslogBatchSize, config.SyslogBufferSize, config.SyslogFlushInterval)
+	batcher.Start()
+
+	return &SyslogServer{
+		logger:   logger,
+		batcher:  batcher,
+		tcpConns: make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenUDP starts receiving one syslog frame per datagram on addr
+func (s *SyslogServer) ListenUDP(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for syslog on udp %s: %w", addr, err)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return fmt.Errorf("syslog server is shut down")
+	}
+	s.udpConn = conn
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go s.serveUDP(conn)
+	return nil
+}
+
+// ListenTCP starts accepting syslog connections on addr
+func (s *SyslogServer) ListenTCP(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for syslog on tcp %s: %w", addr, err)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return fmt.Errorf("syslog server is shut down")
+	}
+	s.tcpListener = listener
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go s.acceptTCP(listener)
+	return nil
+}
+
+// UDPAddr returns the bound UDP address, or nil if UDP is not listening
+func (s *SyslogServer) UDPAddr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.udpConn == nil {
+		return nil
+	}
+	return s.udpConn.LocalAddr()
+}
+
+// TCPAddr returns the bound TCP address, or nil if TCP is not listening
+func (s *SyslogServer) TCPAddr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tcpListener == nil {
+		return nil
+	}
+	return s.tcpListener.Addr()
+}
+
+// Shutdown stops the listeners, closes open connections and flushes buffered messages
+func (s *SyslogServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	if s.udpConn != nil {
+		s.udpConn.Close()
+	}
+	if s.tcpListener != nil {
+		s.tcpListener.Close()
+	}
+	for conn := range s.tcpConns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("syslog listeners did not stop: %w", ctx.Err())
+	}
+
+	s.batcher.Stop()
+	return nil
+}
+
+// serveUDP reads datagrams until the connection is closed
+func (s *SyslogServer) serveUDP(conn net.PacketConn) {
+	defer s.wg.Done()
+
+	buf := make([]byte, config.SyslogMaxFrameBytes)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Error("Syslog UDP read failed", zap.Error(err))
+			}
+			return
+		}
+		s.handleFrame(buf[:n], "udp")
+	}
+}
+
+// acceptTCP accepts connections until the listener is closed
+func (s *SyslogServer) acceptTCP(listener net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Error("Syslog TCP accept failed", zap.Error(err))
+			}
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.tcpConns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serveTCPConn(conn)
+	}
+}
+
+// serveTCPConn reads framed syslog messages from a single connection
+func (s *SyslogServer) serveTCPConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.tcpConns, conn)
+		s.mu.Unlock()
+		conn.Close()
+		s.wg.Done()
+	}()
+
+	reader := bufio.NewReaderSize(conn, config.SyslogMaxFrameBytes)
+	for {
+		frame, err := ReadSyslogFrame(reader)
+		if len(frame) > 0 {
+			s.handleFrame(frame, "tcp")
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.logger.Error("Syslog TCP connection closed",
+					zap.String("remote_addr", conn.RemoteAddr().String()),
+					zap.Error(err),
+				)
+			}
+			return
+		}
+	}
+}
+
+// handleFrame parses a frame and hands the message to the batcher
+func (s *SyslogServer) handleFrame(frame []byte, transport string) {
+	msg, err := ParseSyslogMessage(frame)
+	if err != nil {
+		s.logger.Error("Failed to parse syslog frame",
+			zap.String("transport", transport),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if len(msg.Message) > config.MaxLogMessageLength {
+		msg.Message = msg.Message[:config.MaxLogMessageLength]
+		msg.Metadata["truncated"] = true
+	}
+
+	s.batcher.Add(msg)
+}
+
+// ReadSyslogFrame reads one frame from a TCP syslog stream. It supports both
+// octet-counted framing ("LEN SP MSG", RFC 6587) and newline-delimited framing.
+func ReadSyslogFrame(reader *bufio.Reader) ([]byte, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if first[0] >= '0' && first[0] <= '9' {
+		return readOctetCountedFrame(reader)
+	}
+
+	line, err := reader.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		// Oversized frame - skip the rest of the line so the stream stays in sync
+		for errors.Is(err, bufio.ErrBufferFull) {
+			_, err = reader.ReadSlice('\n')
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	frame := make([]byte, len(line))
+	copy(frame, line)
+	return frame, err
+}
+
+// readOctetCountedFrame reads a "LEN SP MSG" frame
+func readOctetCountedFrame(reader *bufio.Reader) ([]byte, error) {
+	var lengthField []byte
+	for {
+		ch, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if ch == ' ' {
+			break
+		}
+		if ch < '0' || ch > '9' || len(lengthField) >= maxOctetCountDigits {
+			return nil, fmt.Errorf("invalid octet count %q", append(lengthField, ch))
+		}
+		lengthField = append(lengthField, ch)
+	}
+
+	length, err := strconv.Atoi(string(lengthField))
+	if err != nil || length <= 0 {
+		return nil, fmt.Errorf("invalid octet count %q", lengthField)
+	}
+	if length > config.SyslogMaxFrameBytes {
+		return nil, fmt.Errorf("frame of %d bytes exceeds maximum %d", length, config.SyslogMaxFrameBytes)
+	}
+
+	frame := make([]byte, length)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"logs-distributor/auth"
+	"logs-distributor/distributor/implementations"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/ingestion"
+	"logs-distributor/models"
+	"logs-distributor/proto/logingest"
+	"logs-distributor/ratelimit"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// startTestGRPCServer serves LogIngest over an in-memory listener and returns a connected client
+func startTestGRPCServer(t *testing.T, dist interfaces.Distributor) logingest.LogIngestClient {
+	return startAuthenticatedTestGRPCServer(t, dist, nil)
+}
+
+// startAuthenticatedTestGRPCServer is startTestGRPCServer with API key authentication enabled
+func startAuthenticatedTestGRPCServer(t *testing.T, dist interfaces.Distributor, keyStore *auth.KeyStore) logingest.LogIngestClient {
+	return startLimitedTestGRPCServer(t, dist, keyStore, nil)
+}
+
+// startLimitedTestGRPCServer is startAuthenticatedTestGRPCServer with rate limiting enabled
+func startLimitedTestGRPCServer(t *testing.T, dist interfaces.Distributor, keyStore *auth.KeyStore, limiter *ratelimit.Limiter) logingest.LogIngestClient {
+	logger := createTestLogger()
+	listener := bufconn.Listen(1024 * 1024)
+	server := ingestion.NewGRPCServer(dist, keyStore, limiter, logger)
+	go server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		server.Shutdown(context.Background())
+	})
+
+	return logingest.NewLogIngestClient(conn)
+}
+
+// rejectEmptyPackets mimics the validator's rejection of packets without messages
+func rejectEmptyPackets(packet models.LogPacket) error {
+	if len(packet.Messages) == 0 {
+		return fmt.Errorf("%w: packet must contain at least one message", interfaces.ErrInvalidPacket)
+	}
+	return nil
+}
+
+func TestGRPCServer_SubmitPackets(t *testing.T) {
+	dist := &recordingDistributor{submitErr: rejectEmptyPackets}
+	client := startTestGRPCServer(t, dist)
+
+	metadata, err := structpb.NewStruct(map[string]interface{}{"user_id": "user_12345"})
+	require.NoError(t, err)
+
+	resp, err := client.SubmitPackets(context.Background(), &logingest.SubmitPacketsRequest{
+		Packets: []*logingest.LogPacket{
+			{
+				Id: "packet-1",
+				Messages: []*logingest.LogMessage{
+					{Level: "ERROR", Message: "Database connection failed", Source: "api-service", Metadata: metadata},
+				},
+			},
+			{Id: "packet-2"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), resp.GetTotalPackets())
+	assert.Equal(t, int32(1), resp.GetSuccessful())
+	assert.Equal(t, int32(1), resp.GetFailed())
+	assert.Equal(t, []string{"packet-1"}, resp.GetProcessedPackets())
+	require.Len(t, resp.GetStatuses(), 2)
+	assert.Equal(t, logingest.PacketStatus_STATUS_ACCEPTED, resp.GetStatuses()[0].GetStatus())
+	assert.Equal(t, logingest.PacketStatus_STATUS_REJECTED, resp.GetStatuses()[1].GetStatus())
+	assert.Contains(t, resp.GetStatuses()[1].GetError(), "at least one message")
+
+	messages := dist.messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "api-service", messages[0].Source)
+	assert.Equal(t, "user_12345", messages[0].Metadata["user_id"])
+}
+
+func TestGRPCServer_PriorityAndIdempotencyKey(t *testing.T) {
+	validator := implementations.NewPacketValidator()
+	dist := &recordingDistributor{submitErr: func(packet models.LogPacket) error {
+		if err := validator.ValidatePacket(packet); err != nil {
+			return fmt.Errorf("%w: %v", interfaces.ErrInvalidPacket, err)
+		}
+		return nil
+	}}
+	client := startTestGRPCServer(t, dist)
+
+	messages := []*logingest.LogMessage{{Level: "DEBUG", Message: "cache warmed", Source: "api-service"}}
+	request := &logingest.SubmitPacketsRequest{
+		Packets: []*logingest.LogPacket{
+			{Messages: messages, Priority: "high", IdempotencyKey: "client-key"},
+			{Messages: messages, Priority: "urgent"},
+		},
+	}
+
+	resp, err := client.SubmitPackets(context.Background(), request)
+	require.NoError(t, err)
+	require.Len(t, resp.GetStatuses(), 2)
+	assert.Equal(t, logingest.PacketStatus_STATUS_ACCEPTED, resp.GetStatuses()[0].GetStatus())
+	assert.Equal(t, logingest.PacketStatus_STATUS_REJECTED, resp.GetStatuses()[1].GetStatus())
+	assert.Contains(t, resp.GetStatuses()[1].GetError(), "unknown priority")
+
+	dist.mu.Lock()
+	packet := dist.packets[0]
+	dist.mu.Unlock()
+	assert.Equal(t, models.PriorityHigh, packet.Priority, "An explicit priority should override the message levels")
+	assert.Equal(t, "client-key", packet.IdempotencyKey)
+
+	// A retried call reuses the ID derived from the idempotency key
+	retried, err := client.SubmitPackets(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, resp.GetProcessedPackets(), retried.GetProcessedPackets())
+}
+
+func TestGRPCServer_SubmitPacketsEmptyRequest(t *testing.T) {
+	client := startTestGRPCServer(t, &recordingDistributor{})
+
+	_, err := client.SubmitPackets(context.Background(), &logingest.SubmitPacketsRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCServer_StreamPackets(t *testing.T) {
+	dist := &recordingDistributor{}
+	client := startTestGRPCServer(t, dist)
+
+	stream, err := client.StreamPackets(context.Background())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, stream.Send(&logingest.LogPacket{
+			Messages: []*logingest.LogMessage{{Level: "INFO", Message: "streamed", Source: "producer"}},
+		}))
+	}
+
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), resp.GetTotalPackets())
+	assert.Equal(t, int32(3), resp.GetSuccessful())
+	assert.Len(t, resp.GetProcessedPackets(), 3)
+	for _, id := range resp.GetProcessedPackets() {
+		assert.NotEmpty(t, id, "Packets without an ID should get a generated one")
+	}
+	assert.Len(t, dist.messages(), 3)
+}
+
+func TestGRPCServer_Authentication(t *testing.T) {
+	keyStore, err := auth.NewKeyStore([]auth.KeyEntry{
+		{Name: "ingest", KeyHash: auth.HashKey("ingest-key"), TenantID: "tenant-a", Scopes: []auth.Scope{auth.ScopeIngest}},
+		{Name: "reader", KeyHash: auth.HashKey("read-key"), TenantID: "tenant-a", Scopes: []auth.Scope{auth.ScopeRead}},
+	})
+	require.NoError(t, err)
+
+	dist := &recordingDistributor{}
+	client := startAuthenticatedTestGRPCServer(t, dist, keyStore)
+	req := &logingest.SubmitPacketsRequest{
+		Packets: []*logingest.LogPacket{
+			{Messages: []*logingest.LogMessage{{Level: "INFO", Message: "hello", Source: "producer"}}},
+		},
+	}
+
+	_, err = client.SubmitPackets(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	readCtx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "read-key")
+	_, err = client.SubmitPackets(readCtx, req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ingestCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer ingest-key")
+	_, err = client.SubmitPackets(ingestCtx, req)
+	require.NoError(t, err)
+
+	stream, err := client.StreamPackets(ingestCtx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(req.Packets[0]))
+	_, err = stream.CloseAndRecv()
+	require.NoError(t, err)
+
+	dist.mu.Lock()
+	defer dist.mu.Unlock()
+	require.Len(t, dist.packets, 2)
+	for _, packet := range dist.packets {
+		assert.Equal(t, "tenant-a", packet.TenantID, "Packets should be stamped with the caller's tenant")
+	}
+}
+
+func TestGRPCServer_RateLimiting(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter([]ratelimit.Rule{
+		{Key: ratelimit.KeyClientIP, PacketsPerSecond: 0.01, PacketBurst: 2},
+	}, time.Minute, 10)
+	require.NoError(t, err)
+
+	dist := &recordingDistributor{}
+	client := startLimitedTestGRPCServer(t, dist, nil, limiter)
+	packet := &logingest.LogPacket{
+		Messages: []*logingest.LogMessage{{Level: "INFO", Message: "hello", Source: "producer"}},
+	}
+
+	_, err = client.SubmitPackets(context.Background(), &logingest.SubmitPacketsRequest{
+		Packets: []*logingest.LogPacket{packet},
+	})
+	require.NoError(t, err)
+
+	stream, err := client.StreamPackets(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(packet))
+	require.NoError(t, stream.Send(packet))
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), resp.GetSuccessful())
+	assert.Equal(t, int32(1), resp.GetFailed())
+	assert.Contains(t, resp.GetStatuses()[1].GetError(), "rate limit exceeded")
+
+	var header metadata.MD
+	_, err = client.SubmitPackets(context.Background(), &logingest.SubmitPacketsRequest{
+		Packets: []*logingest.LogPacket{packet},
+	}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NotEmpty(t, header.Get("retry-after"))
+	assert.Len(t, dist.messages(), 2)
+}
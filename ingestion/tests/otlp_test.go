@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"logs-distributor/config"
+	"logs-distributor/ingestion"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const otlpJSONPayload = `{
+  "resourceLogs": [{
+    "resource": {"attributes": [
+      {"key": "service.name", "value": {"stringValue": "checkout"}},
+      {"key": "host.name", "value": {"stringValue": "node-7"}}
+    ]},
+    "scopeLogs": [{
+      "scope": {"name": "checkout.logger", "version": "1.2.0"},
+      "logRecords": [{
+        "timeUnixNano": "1737837045000000000",
+        "severityNumber": 17,
+        "severityText": "Error",
+        "body": {"stringValue": "payment declined"},
+        "attributes": [
+          {"key": "order_id", "value": {"stringValue": "o-42"}},
+          {"key": "attempt", "value": {"intValue": "3"}}
+        ],
+        "traceId": "5b8efff798038103d269b633813fc60c",
+        "spanId": "eee19b7ec3c1b174"
+      }]
+    }]
+  }]
+}`
+
+func stringAttr(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func TestOTLP_DecodeAndTranslateJSON(t *testing.T) {
+	req, err := ingestion.DecodeOTLPLogsRequest([]byte(otlpJSONPayload), true)
+	require.NoError(t, err)
+
+	packets := ingestion.TranslateOTLPLogs(req)
+	require.Len(t, packets, 1)
+	require.Len(t, packets[0].Messages, 1)
+
+	msg := packets[0].Messages[0]
+	assert.Equal(t, "ERROR", msg.Level)
+	assert.Equal(t, "payment declined", msg.Message)
+	assert.Equal(t, "checkout", msg.Source)
+	assert.Equal(t, int64(1737837045), msg.Timestamp.Unix())
+	assert.Equal(t, "o-42", msg.Metadata["order_id"])
+	assert.Equal(t, int64(3), msg.Metadata["attempt"])
+	assert.Equal(t, "node-7", msg.Metadata["resource.host.name"])
+	assert.Equal(t, "checkout.logger", msg.Metadata["scope.name"])
+	assert.Equal(t, "5b8efff798038103d269b633813fc60c", msg.Metadata["trace_id"])
+	assert.Equal(t, "eee19b7ec3c1b174", msg.Metadata["span_id"])
+}
+
+func TestOTLP_DecodeProtobuf(t *testing.T) {
+	original := &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{stringAttr("service.name", "auth-api")}},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				LogRecords: []*logspb.LogRecord{{
+					SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+					Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{
+						Values: []*commonpb.KeyValue{stringAttr("event", "login")},
+					}}},
+					TraceId: []byte{0x01, 0x02},
+				}},
+			}},
+		}},
+	}
+	body, err := proto.Marshal(original)
+	require.NoError(t, err)
+
+	req, err := ingestion.DecodeOTLPLogsRequest(body, false)
+	require.NoError(t, err)
+
+	packets := ingestion.TranslateOTLPLogs(req)
+	require.Len(t, packets, 1)
+
+	msg := packets[0].Messages[0]
+	assert.Equal(t, "WARN", msg.Level)
+	assert.Equal(t, "auth-api", msg.Source)
+	assert.JSONEq(t, `{"event":"login"}`, msg.Message)
+	assert.Equal(t, "0102", msg.Metadata["trace_id"])
+	assert.False(t, msg.Timestamp.IsZero(), "Records without timestamps should get the receive time")
+}
+
+func TestOTLP_DecodeInvalidPayload(t *testing.T) {
+	_, err := ingestion.DecodeOTLPLogsRequest([]byte("{not json"), true)
+	assert.Error(t, err)
+
+	_, err = ingestion.DecodeOTLPLogsRequest([]byte{0xff, 0xff, 0xff}, false)
+	assert.Error(t, err)
+}
+
+func TestOTLP_SeverityToLevel(t *testing.T) {
+	assert.Equal(t, "WARNING", ingestion.OTLPSeverityToLevel("warning", logspb.SeverityNumber_SEVERITY_NUMBER_WARN))
+	assert.Equal(t, "DEBUG", ingestion.OTLPSeverityToLevel("", logspb.SeverityNumber_SEVERITY_NUMBER_TRACE2))
+	assert.Equal(t, "INFO", ingestion.OTLPSeverityToLevel("", logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED))
+	assert.Equal(t, "INFO", ingestion.OTLPSeverityToLevel("", logspb.SeverityNumber_SEVERITY_NUMBER_INFO4))
+	assert.Equal(t, "ERROR", ingestion.OTLPSeverityToLevel("", logspb.SeverityNumber_SEVERITY_NUMBER_ERROR3))
+	assert.Equal(t, "FATAL", ingestion.OTLPSeverityToLevel("", logspb.SeverityNumber_SEVERITY_NUMBER_FATAL))
+}
+
+func TestOTLP_TranslateSplitsLargeRequests(t *testing.T) {
+	records := make([]*logspb.LogRecord, config.MaxMessagesPerPacket+1)
+	for i := range records {
+		records[i] = &logspb.LogRecord{Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "msg"}}}
+	}
+	// One oversized record is truncated instead of rejecting its whole packet
+	records[0].Body = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{
+		StringValue: strings.Repeat("x", config.MaxLogMessageLength+10),
+	}}
+
+	req := &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{ScopeLogs: []*logspb.ScopeLogs{{LogRecords: records}}}},
+	}
+
+	packets := ingestion.TranslateOTLPLogs(req)
+	require.Len(t, packets, 2)
+	assert.Len(t, packets[0].Messages, config.MaxMessagesPerPacket)
+	assert.Len(t, packets[1].Messages, 1)
+	assert.Equal(t, "otlp", packets[0].Messages[0].Source)
+	assert.Len(t, packets[0].Messages[0].Message, config.MaxLogMessageLength)
+	assert.Equal(t, true, packets[0].Messages[0].Metadata["truncated"])
+}
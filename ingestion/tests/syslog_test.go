@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/ingestion"
+	"logs-distributor/models"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func createTestLogger() *zap.Logger {
+	config := zap.NewDevelopmentConfig()
+	config.Level = zap.NewAtomicLevelAt(zap.FatalLevel) // Suppress logs during tests
+	logger, _ := config.Build()
+	return logger
+}
+
+// recordingDistributor captures submitted packets instead of processing them
+type recordingDistributor struct {
+	mu        sync.Mutex
+	packets   []models.LogPacket
+	submitErr func(packet models.LogPacket) error // optional, decides which packets are refused
+}
+
+func (r *recordingDistributor) Start() error { return nil }
+func (r *recordingDistributor) Stop() error  { return nil }
+func (r *recordingDistributor) GetStats() *models.DistributorStats {
+	return &models.DistributorStats{}
+}
+
+func (r *recordingDistributor) GetPacketStatus(tenantID, packetID string) (*models.PacketStatus, bool) {
+	return nil, false
+}
+
+func (r *recordingDistributor) SubscribeResults(filter models.ResultFilter) (<-chan models.AnalysisResult, func(), error) {
+	return nil, nil, interfaces.ErrShuttingDown
+}
+
+func (r *recordingDistributor) AddAnalyzer(cfg config.AnalyzerConfig) error    { return nil }
+func (r *recordingDistributor) UpdateAnalyzer(cfg config.AnalyzerConfig) error { return nil }
+func (r *recordingDistributor) RemoveAnalyzer(analyzerID string) error         { return nil }
+func (r *recordingDistributor) ListDeadLetters(filter models.DeadLetterFilter, cursor string, limit int) (*models.DeadLetterPage, error) {
+	return &models.DeadLetterPage{}, nil
+}
+
+func (r *recordingDistributor) ReplayDeadLetters(ctx context.Context, req models.ReplayRequest) (*models.ReplayResult, error) {
+	return &models.ReplayResult{}, nil
+}
+
+func (r *recordingDistributor) SetAnalyzerOverride(analyzerID string, override *models.HealthOverride) error {
+	return nil
+}
+
+func (r *recordingDistributor) SubmitPacket(packet models.LogPacket) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.submitErr != nil {
+		if err := r.submitErr(packet); err != nil {
+			return err
+		}
+	}
+	r.packets = append(r.packets, packet)
+	return nil
+}
+
+func (r *recordingDistributor) messages() []models.LogMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var messages []models.LogMessage
+	for _, packet := range r.packets {
+		messages = append(messages, packet.Messages...)
+	}
+	return messages
+}
+
+func TestSyslog_ParseRFC5424(t *testing.T) {
+	frame := `<34>1 2025-01-25T20:30:45.123Z host-1 auth-service 4242 ID47 [exampleSDID@32473 iut="3" eventSource="App\]lication"] login failed for user`
+
+	msg, err := ingestion.ParseSyslogMessage([]byte(frame))
+	require.NoError(t, err)
+
+	assert.Equal(t, "FATAL", msg.Level) // severity 2 (critical)
+	assert.Equal(t, "auth-service", msg.Source)
+	assert.Equal(t, "login failed for user", msg.Message)
+	assert.Equal(t, time.Date(2025, 1, 25, 20, 30, 45, 123000000, time.UTC), msg.Timestamp.UTC())
+	assert.Equal(t, "host-1", msg.Metadata["hostname"])
+	assert.Equal(t, "4242", msg.Metadata["proc_id"])
+	assert.Equal(t, "ID47", msg.Metadata["msg_id"])
+	assert.Equal(t, 4, msg.Metadata["syslog_facility"])
+	assert.Contains(t, msg.Metadata["structured_data"], "exampleSDID@32473")
+	assert.NotEmpty(t, msg.ID)
+}
+
+func TestSyslog_ParseRFC5424NilValues(t *testing.T) {
+	msg, err := ingestion.ParseSyslogMessage([]byte("<14>1 - - - - - - hello"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "INFO", msg.Level)
+	assert.Equal(t, "syslog", msg.Source)
+	assert.Equal(t, "hello", msg.Message)
+	assert.NotContains(t, msg.Metadata, "hostname")
+	assert.NotContains(t, msg.Metadata, "structured_data")
+}
+
+func TestSyslog_ParseRFC3164(t *testing.T) {
+	msg, err := ingestion.ParseSyslogMessage([]byte("<11>Oct  7 22:14:15 router-3 sshd[1234]: Failed password for root\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "ERROR", msg.Level)
+	assert.Equal(t, "sshd", msg.Source)
+	assert.Equal(t, "Failed password for root", msg.Message)
+	assert.Equal(t, "router-3", msg.Metadata["hostname"])
+	assert.Equal(t, "1234", msg.Metadata["proc_id"])
+	assert.Equal(t, time.October, msg.Timestamp.Month())
+	assert.Equal(t, 22, msg.Timestamp.Hour())
+}
+
+func TestSyslog_ParseRFC3164WithoutHeader(t *testing.T) {
+	msg, err := ingestion.ParseSyslogMessage([]byte("something went wrong"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "INFO", msg.Level) // default user.notice priority
+	assert.Equal(t, "syslog", msg.Source)
+	assert.Equal(t, "something went wrong", msg.Message)
+}
+
+func TestSyslog_ParseInvalidFrames(t *testing.T) {
+	tests := []string{
+		"",
+		"<999>1 - - - - - - too high",
+		"<abc>hello",
+		"<13>1 2025-01-25T20:30:45Z host app",
+		"<13>1 not-a-time host app - - - msg",
+		`<13>1 - host app - - [unterminated a="b" msg`,
+	}
+
+	for _, frame := range tests {
+		_, err := ingestion.ParseSyslogMessage([]byte(frame))
+		assert.Error(t, err, "frame %q should be rejected", frame)
+	}
+}
+
+func TestSyslog_SeverityToLevel(t *testing.T) {
+	expected := []string{"FATAL", "FATAL", "FATAL", "ERROR", "WARN", "INFO", "INFO", "DEBUG"}
+	for severity, level := range expected {
+		assert.Equal(t, level, ingestion.SyslogSeverityToLevel(severity), "severity %d", severity)
+	}
+}
+
+func TestSyslog_ReadFrames(t *testing.T) {
+	stream := "26 <13>1 - host app - - - one" +
+		"<13>newline delimited\n" +
+		"28 <13>1 - host app - - - two\nx" +
+		"<13>last frame without newline"
+
+	reader := bufio.NewReader(strings.NewReader(stream))
+
+	var frames []string
+	for {
+		frame, err := ingestion.ReadSyslogFrame(reader)
+		if len(frame) > 0 {
+			frames = append(frames, string(frame))
+		}
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{
+		"<13>1 - host app - - - one",
+		"<13>newline delimited\n",
+		"<13>1 - host app - - - two\nx",
+		"<13>last frame without newline",
+	}, frames)
+}
+
+func TestSyslog_ReadFramesInvalidOctetCount(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("12a <13>hello"))
+	_, err := ingestion.ReadSyslogFrame(reader)
+	assert.Error(t, err)
+}
+
+func TestPacketBatcher_FlushesOnSizeAndStop(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	dist := &recordingDistributor{}
+	batcher := ingestion.NewPacketBatcher(dist, logger, "test", 2, 10, time.Hour)
+	batcher.Start()
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, batcher.Add(models.NewLogMessage("INFO", "msg", "test", nil)))
+	}
+	batcher.Stop()
+
+	assert.Len(t, dist.messages(), 3)
+	assert.Equal(t, int64(2), batcher.GetSubmittedPackets())
+	assert.False(t, batcher.Add(models.NewLogMessage("INFO", "late", "test", nil)), "Add should fail after Stop")
+}
+
+func TestSyslogServer_UDPAndTCP(t *testing.T) {
+	logger := createTestLogger()
+	defer logger.Sync()
+
+	dist := &recordingDistributor{}
+	server := ingestion.NewSyslogServer(dist, logger)
+	require.NoError(t, server.ListenUDP("127.0.0.1:0"))
+	require.NoError(t, server.ListenTCP("127.0.0.1:0"))
+
+	udpConn, err := net.Dial("udp", server.UDPAddr().String())
+	require.NoError(t, err)
+	_, err = udpConn.Write([]byte("<14>1 - host udp-app - - - via udp"))
+	require.NoError(t, err)
+	udpConn.Close()
+
+	tcpConn, err := net.Dial("tcp", server.TCPAddr().String())
+	require.NoError(t, err)
+	_, err = tcpConn.Write([]byte("34 <14>1 - host tcp-app - - - via tcp"))
+	require.NoError(t, err)
+	tcpConn.Close()
+
+	assert.Eventually(t, func() bool {
+		return len(dist.messages()) == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, server.Shutdown(context.Background()))
+
+	sources := make(map[string]string)
+	for _, msg := range dist.messages() {
+		sources[msg.Source] = msg.Message
+	}
+	assert.Equal(t, "via udp", sources["udp-app"])
+	assert.Equal(t, "via tcp", sources["tcp-app"])
+}
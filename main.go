@@ -5,15 +5,21 @@ import (
 	"fmt"
 	"log"
 	"logs-distributor/api"
+	"logs-distributor/auth"
 	"logs-distributor/config"
 	"logs-distributor/distributor/implementations"
 	"logs-distributor/distributor/interfaces"
+	"logs-distributor/ingestion"
+	"logs-distributor/metrics"
 	"logs-distributor/models"
+	"logs-distributor/ratelimit"
+	"logs-distributor/tracing"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -25,6 +31,11 @@ const (
 )
 
 func main() {
+	// Subcommands talk to a running service instead of starting one
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	// Initialize logger
 	logger := initLogger()
 	defer func() {
@@ -38,23 +49,35 @@ func main() {
 		zap.String("version", Version),
 	)
 
+	// Tracing is installed before any component starts spans
+	shutdownTracing := setupTracing(logger)
+
 	// Create distributor with explicit dependency injection
-	dist := createDistributor(logger)
+	pipelineMetrics := metrics.New()
+	dist := createDistributor(logger, pipelineMetrics)
+	pipelineMetrics.WatchDistributor(dist.GetStats)
 
 	// Start distributor
 	if err := dist.Start(); err != nil {
 		logger.Fatal("Failed to start distributor", zap.Error(err))
 	}
 
+	// Load API keys; every route except health checks requires one when keys are configured
+	keyStore := loadKeyStore(logger)
+	rateLimiter := loadRateLimiter(logger)
+
 	// Setup API handlers
-	handler := api.NewHandler(dist, logger)
+	handler := api.NewHandler(dist, logger, &api.HandlerConfig{
+		KeyStore:       keyStore,
+		AllowedOrigins: splitList(os.Getenv("CORS_ALLOWED_ORIGINS")),
+		RateLimiter:    rateLimiter,
+		Metrics:        pipelineMetrics,
+		TrustedProxies: splitList(os.Getenv("TRUSTED_PROXIES")),
+	})
 	router := handler.SetupRoutes()
 
 	// Configure HTTP server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = config.DefaultPort
-	}
+	port := getEnv("PORT", config.DefaultPort)
 
 	server := &http.Server{
 		Addr:         ":" + port,
@@ -63,6 +86,7 @@ func main() {
 		WriteTimeout: config.WriteTimeout,
 		IdleTimeout:  config.IdleTimeout,
 	}
+	server.RegisterOnShutdown(handler.CloseStreams)
 
 	// Start HTTP server in a goroutine
 	go func() {
@@ -73,6 +97,35 @@ func main() {
 		}
 	}()
 
+	// Start gRPC server on its own port
+	grpcPort := getEnv("GRPC_PORT", config.DefaultGRPCPort)
+	grpcListener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		logger.Fatal("Failed to listen for gRPC", zap.Error(err))
+	}
+	grpcServer := ingestion.NewGRPCServer(dist, keyStore, rateLimiter, logger)
+	go func() {
+		logger.Info("Starting gRPC server", zap.String("port", grpcPort))
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logger.Fatal("Failed to start gRPC server", zap.Error(err))
+		}
+	}()
+
+	// Start syslog listeners
+	syslogServer := ingestion.NewSyslogServer(dist, logger)
+	syslogUDPPort := getEnv("SYSLOG_UDP_PORT", config.DefaultSyslogUDPPort)
+	syslogTCPPort := getEnv("SYSLOG_TCP_PORT", config.DefaultSyslogTCPPort)
+	if err := syslogServer.ListenUDP(":" + syslogUDPPort); err != nil {
+		logger.Fatal("Failed to start syslog UDP listener", zap.Error(err))
+	}
+	if err := syslogServer.ListenTCP(":" + syslogTCPPort); err != nil {
+		logger.Fatal("Failed to start syslog TCP listener", zap.Error(err))
+	}
+	logger.Info("Syslog listeners started",
+		zap.String("udp_port", syslogUDPPort),
+		zap.String("tcp_port", syslogTCPPort),
+	)
+
 	// Wait for interrupt signal for graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -91,15 +144,203 @@ func main() {
 		logger.Error("Failed to shutdown HTTP server gracefully", zap.Error(err))
 	}
 
+	logger.Info("Shutting down gRPC server...")
+	if err := grpcServer.Shutdown(ctx); err != nil {
+		logger.Error("Failed to shutdown gRPC server gracefully", zap.Error(err))
+	}
+
+	// Stop syslog listeners and flush buffered messages while the distributor still accepts packets
+	logger.Info("Shutting down syslog listeners...")
+	if err := syslogServer.Shutdown(ctx); err != nil {
+		logger.Error("Failed to shutdown syslog listeners gracefully", zap.Error(err))
+	}
+
 	// Shutdown distributor
 	logger.Info("Shutting down distributor...")
 	if err := dist.Stop(); err != nil {
 		logger.Error("Failed to shutdown distributor gracefully", zap.Error(err))
 	}
 
+	// Flush spans last so the shutdown's own spans are exported
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Failed to flush traces", zap.Error(err))
+	}
+
 	logger.Info("Service shutdown complete")
 }
 
+// getEnv returns the environment variable value or the given default
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// splitList parses a comma-separated environment value, ignoring blank entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadKeyStore loads the API keys file. A missing default file disables authentication,
+// but an explicitly configured API_KEYS_FILE must exist.
+func loadKeyStore(logger *zap.Logger) *auth.KeyStore {
+	path, explicit := os.LookupEnv("API_KEYS_FILE")
+	if !explicit || path == "" {
+		path = config.DefaultAPIKeysFile
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			logger.Warn("No API keys file found - authentication is DISABLED and all routes are open",
+				zap.String("file", path),
+			)
+			return nil
+		}
+	}
+
+	keyStore, err := auth.LoadKeyStore(path)
+	if err != nil {
+		logger.Fatal("Failed to load API keys", zap.String("file", path), zap.Error(err))
+	}
+
+	logger.Info("API key authentication enabled",
+		zap.String("file", path),
+		zap.Int("keys", keyStore.Len()),
+	)
+	return keyStore
+}
+
+// loadRateLimiter loads the rate limits file. A missing default file disables rate limiting,
+// but an explicitly configured RATE_LIMITS_FILE must exist.
+func loadRateLimiter(logger *zap.Logger) *ratelimit.Limiter {
+	path, explicit := os.LookupEnv("RATE_LIMITS_FILE")
+	if !explicit || path == "" {
+		path = config.DefaultRateLimitsFile
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			logger.Info("No rate limits file found - rate limiting is disabled", zap.String("file", path))
+			return nil
+		}
+	}
+
+	rules, err := ratelimit.LoadRules(path)
+	if err != nil {
+		logger.Fatal("Failed to load rate limits", zap.String("file", path), zap.Error(err))
+	}
+	limiter, err := ratelimit.NewLimiter(rules, config.RateLimitIdleTimeout, config.MaxRateLimitedKeysReported)
+	if err != nil {
+		logger.Fatal("Invalid rate limits", zap.String("file", path), zap.Error(err))
+	}
+
+	logger.Info("Rate limiting enabled", zap.String("file", path), zap.Int("rules", len(rules)))
+	return limiter
+}
+
+// loadRouter loads the routing rules file. A missing default file leaves every packet on the default route,
+// but an explicitly configured ROUTING_RULES_FILE must exist.
+func loadRouter(logger *zap.Logger) interfaces.Router {
+	path, explicit := os.LookupEnv("ROUTING_RULES_FILE")
+	if !explicit || path == "" {
+		path = config.DefaultRoutingRulesFile
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			logger.Info("No routing rules file found - packets are balanced across all analyzers", zap.String("file", path))
+			router, _ := implementations.NewRouter(models.RoutingConfig{}) // an empty config is always valid
+			return router
+		}
+	}
+
+	routing, err := implementations.LoadRoutingConfig(path)
+	if err != nil {
+		logger.Fatal("Failed to load routing rules", zap.String("file", path), zap.Error(err))
+	}
+	router, err := implementations.NewRouter(routing)
+	if err != nil {
+		logger.Fatal("Invalid routing rules", zap.String("file", path), zap.Error(err))
+	}
+
+	logger.Info("Routing rules enabled", zap.String("file", path), zap.Int("rules", len(routing.Rules)))
+	return router
+}
+
+// newLoadBalancer creates the load balancer named by LOAD_BALANCER, weighted round robin by default,
+// and the partitioner that keys packets by AFFINITY_KEY for consistent hashing
+func newLoadBalancer(logger *zap.Logger) (interfaces.LoadBalancer, interfaces.Partitioner) {
+	strategy := implementations.LoadBalancerStrategy(getEnv("LOAD_BALANCER", string(implementations.LoadBalancerWeighted)))
+	loadBalancer, err := implementations.NewLoadBalancerForStrategy(strategy, logger)
+	if err != nil {
+		logger.Fatal("Invalid load balancer", zap.Error(err))
+	}
+
+	// Only consistent hashing uses affinity keys, so other strategies keep packets whole
+	var affinity models.AffinityConfig
+	if strategy == implementations.LoadBalancerHash {
+		affinity = models.AffinityConfig{
+			Key:   getEnv("AFFINITY_KEY", config.DefaultAffinityKey),
+			Mixed: models.MixedAffinity(getEnv("AFFINITY_MIXED", string(models.MixedAffinitySplit))),
+		}
+	}
+	partitioner, err := implementations.NewPartitioner(affinity)
+	if err != nil {
+		logger.Fatal("Invalid affinity", zap.Error(err))
+	}
+
+	logger.Info("Load balancer selected",
+		zap.String("strategy", string(strategy)),
+		zap.String("affinity_key", affinity.Key),
+		zap.String("mixed_affinity", string(affinity.Mixed)),
+	)
+	return loadBalancer, partitioner
+}
+
+// openDeadLetterStore opens the segmented dead letter store in DEAD_LETTER_DIR and
+// imports any dead letters left in the legacy JSON file
+func openDeadLetterStore(logger *zap.Logger) interfaces.DeadLetterStore {
+	dir := getEnv("DEAD_LETTER_DIR", config.DeadLetterDir)
+	store, err := implementations.NewDeadLetterStore(dir, config.DeadLetterSegmentMaxBytes,
+		config.DeadLetterSegmentMaxAge, config.DeadLetterMaxSegments, logger)
+	if err != nil {
+		logger.Fatal("Failed to open dead letter store", zap.String("dir", dir), zap.Error(err))
+	}
+
+	imported, err := implementations.ImportDeadLetterFile(store, config.DeadLetterFile)
+	if err != nil {
+		logger.Fatal("Failed to import legacy dead letter file", zap.String("file", config.DeadLetterFile), zap.Error(err))
+	}
+	if imported > 0 {
+		logger.Info("Imported legacy dead letter file",
+			zap.String("file", config.DeadLetterFile),
+			zap.Int("entries", imported),
+		)
+	}
+	return store
+}
+
+// setupTracing installs the exporter selected by OTEL_TRACES_EXPORTER (none, otlp or file).
+// The OTLP exporter reads the standard OTEL_EXPORTER_OTLP_* variables; the file exporter writes to TRACES_FILE.
+func setupTracing(logger *zap.Logger) func(context.Context) error {
+	cfg := tracing.Config{
+		Exporter:       tracing.Exporter(getEnv("OTEL_TRACES_EXPORTER", string(tracing.ExporterNone))),
+		FilePath:       getEnv("TRACES_FILE", config.DefaultTracesFile),
+		ServiceName:    ServiceName,
+		ServiceVersion: Version,
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		logger.Fatal("Failed to set up tracing", zap.Error(err))
+	}
+
+	if cfg.Exporter == tracing.ExporterFile {
+		logger.Info("Tracing enabled", zap.String("exporter", string(cfg.Exporter)), zap.String("file", cfg.FilePath))
+	} else if cfg.Exporter != tracing.ExporterNone {
+		logger.Info("Tracing enabled", zap.String("exporter", string(cfg.Exporter)))
+	}
+	return shutdown
+}
+
 // initLogger initializes the zap logger with appropriate configuration
 func initLogger() *zap.Logger {
 	// Configure logger for production-like output
@@ -130,35 +371,34 @@ func initLogger() *zap.Logger {
 }
 
 // createDistributor creates a distributor with explicit dependency injection
-func createDistributor(logger *zap.Logger) interfaces.Distributor {
-	// Create analyzer configuration
-	analyzers := make(map[string]*models.Analyzer)
-	analyzerConfigs := config.GetDefaultAnalyzers()
-
-	for _, cfg := range analyzerConfigs {
-		analyzer := &models.Analyzer{
-			ID:               cfg.ID,
-			Name:             cfg.Name,
-			Weight:           cfg.Weight,
-			ProcessingTimeMs: cfg.ProcessingTimeMs,
-			IsHealthy:        true,
-			LastHealthCheck:  time.Now(),
-		}
-		analyzers[analyzer.ID] = analyzer
-	}
-
+func createDistributor(logger *zap.Logger, pipelineMetrics interfaces.MetricsRecorder) interfaces.Distributor {
 	// Create channels
 	retryChannel := make(chan models.LogPacket, config.RetryChannelBuffer)
-	ctx, _ := context.WithCancel(context.Background())
+	ctx := context.Background()
+
+	lifecycle := implementations.NewLifecycleStore(config.MaxTrackedPacketStatuses, config.MaxPacketTransitions)
+	deadLetters := openDeadLetterStore(logger)
+	loadBalancer, partitioner := newLoadBalancer(logger)
 
 	// Create implementations with dependency injection
+	// The distributor registers the analyzers with the load balancer and health monitor
 	distributorConfig := &implementations.DistributorConfig{
-		LoadBalancer:    implementations.NewLoadBalancer(analyzers, logger),
-		HealthMonitor:   implementations.NewHealthMonitor(analyzers, logger),
-		PersistenceMgr:  implementations.NewPersistenceManager(logger),
-		RetryHandler:    implementations.NewRetryHandler(retryChannel, logger, ctx),
+		Analyzers:       config.GetDefaultAnalyzers(),
+		LoadBalancer:    loadBalancer,
+		Router:          loadRouter(logger),
+		Hedger:          implementations.NewHedger(config.HedgeLatencyWindow, config.HedgeMinLatencies, config.HedgeBudgetBurst),
+		Partitioner:     partitioner,
+		PacketQueue:     implementations.NewPacketQueue(config.PacketChannelBuffer, implementations.DefaultLaneWeights()),
+		HealthMonitor:   implementations.NewHealthMonitor(implementations.DefaultCircuitBreakerConfig(), logger),
+		PersistenceMgr:  implementations.NewPersistenceManager(config.StateFilePath, logger),
+		RetryHandler:    implementations.NewRetryHandler(retryChannel, lifecycle, deadLetters, pipelineMetrics, logger, ctx),
 		PacketProcessor: implementations.NewPacketProcessor(logger),
 		PacketValidator: implementations.NewPacketValidator(),
+		Deduplicator:    implementations.NewDeduplicator(config.DeduplicationWindow, config.MaxDeduplicationEntries, logger),
+		LifecycleStore:  lifecycle,
+		ResultHub:       implementations.NewResultHub(config.MaxResultSubscribers, config.ResultSubscriberBuffer, logger),
+		DeadLetterStore: deadLetters,
+		Metrics:         pipelineMetrics,
 	}
 
 	return implementations.NewDistributor(logger, distributorConfig)
@@ -195,6 +435,11 @@ func printStartupMessage(port string, dist interfaces.Distributor, logger *zap.L
 		zap.String("health", "GET /api/v1/health"),
 		zap.String("stats", "GET /api/v1/stats"),
 		zap.String("logs", "POST /api/v1/logs"),
+		zap.String("logs_stream", "POST /api/v1/logs/stream"),
+		zap.String("otlp_logs", "POST /v1/logs"),
+		zap.String("grpc", "logingest.v1.LogIngest/SubmitPackets, logingest.v1.LogIngest/StreamPackets"),
+		zap.String("packet_status", "GET /api/v1/packets/:id"),
+		zap.String("results_stream", "GET /api/v1/results/stream (SSE), GET /api/v1/results/ws (WebSocket)"),
 		zap.String("dead_letter", "GET /api/v1/dead-letter"),
 	)
 	logger.Info("=========================================")
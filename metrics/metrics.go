@@ -0,0 +1,246 @@
+package metrics
+
+import (
+	"logs-distributor/config"
+	"logs-distributor/distributor/interfaces"
+	"logs-distributor/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "logs_distributor"
+
+// Metrics records packet pipeline and HTTP metrics and serves them in Prometheus exposition format.
+// Counters and histograms are updated as events happen; queue depths and analyzer health are
+// read from the distributor's stats on each scrape, so they are never stale.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	packetsReceived     prometheus.Counter
+	packetsRouted       *prometheus.CounterVec
+	packetsSucceeded    *prometheus.CounterVec
+	packetsRetried      *prometheus.CounterVec
+	packetsDeadLettered *prometheus.CounterVec
+	processingSeconds   *prometheus.HistogramVec
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+}
+
+// Ensure Metrics implements MetricsRecorder interface
+var _ interfaces.MetricsRecorder = (*Metrics)(nil)
+
+// New creates metrics in their own registry, along with the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		packetsReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "packets_received_total",
+			Help:      "Packets accepted for distribution, excluding duplicates and rejected packets.",
+		}),
+		packetsRouted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "packets_routed_total",
+			Help:      "Packets dispatched to an analyzer, including retries.",
+		}, []string{"analyzer"}),
+		packetsSucceeded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "packets_succeeded_total",
+			Help:      "Packets analyzed successfully.",
+		}, []string{"analyzer"}),
+		packetsRetried: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "packets_retried_total",
+			Help:      "Failed packets scheduled for another attempt.",
+		}, []string{"analyzer"}),
+		packetsDeadLettered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "packets_dead_lettered_total",
+			Help:      "Packets that failed permanently after exhausting their retries.",
+		}, []string{"analyzer"}),
+		processingSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "analyzer_processing_seconds",
+			Help:      "Time an analyzer took to process a packet.",
+			Buckets:   []float64{0.01, 0.025, 0.05, 0.1, 0.15, 0.2, 0.3, 0.5, 1, 2.5, 5},
+		}, []string{"analyzer", "outcome"}),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+
+	m.registry.MustRegister(
+		m.packetsReceived,
+		m.packetsRouted,
+		m.packetsSucceeded,
+		m.packetsRetried,
+		m.packetsDeadLettered,
+		m.processingSeconds,
+		m.httpRequests,
+		m.httpRequestDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// WatchDistributor exports queue depth and analyzer health gauges read from stats on each scrape
+func (m *Metrics) WatchDistributor(stats func() *models.DistributorStats) {
+	m.registry.MustRegister(newStatsCollector(stats))
+}
+
+// Handler serves the registry in Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// PacketReceived counts a packet accepted for distribution
+func (m *Metrics) PacketReceived() {
+	m.packetsReceived.Inc()
+}
+
+// PacketRouted counts a packet dispatched to an analyzer
+func (m *Metrics) PacketRouted(analyzerID string) {
+	m.packetsRouted.WithLabelValues(analyzerID).Inc()
+}
+
+// PacketSucceeded counts a successfully analyzed packet
+func (m *Metrics) PacketSucceeded(analyzerID string) {
+	m.packetsSucceeded.WithLabelValues(analyzerID).Inc()
+}
+
+// PacketRetried counts a failed packet scheduled for retry
+func (m *Metrics) PacketRetried(analyzerID string) {
+	m.packetsRetried.WithLabelValues(analyzerID).Inc()
+}
+
+// PacketDeadLettered counts a packet that failed permanently
+func (m *Metrics) PacketDeadLettered(analyzerID string) {
+	m.packetsDeadLettered.WithLabelValues(analyzerID).Inc()
+}
+
+// ObserveProcessing records how long an analyzer took to process a packet
+func (m *Metrics) ObserveProcessing(analyzerID string, success bool, duration time.Duration) {
+	outcome := "success"
+	if !success {
+		outcome = "failure"
+	}
+	m.processingSeconds.WithLabelValues(analyzerID, outcome).Observe(duration.Seconds())
+}
+
+// ForgetAnalyzer drops the series of a removed analyzer so they stop being exported
+func (m *Metrics) ForgetAnalyzer(analyzerID string) {
+	labels := prometheus.Labels{"analyzer": analyzerID}
+	m.packetsRouted.DeletePartialMatch(labels)
+	m.packetsSucceeded.DeletePartialMatch(labels)
+	m.packetsRetried.DeletePartialMatch(labels)
+	m.packetsDeadLettered.DeletePartialMatch(labels)
+	m.processingSeconds.DeletePartialMatch(labels)
+}
+
+// ObserveHTTPRequest records a served HTTP request. Route is the matched route pattern,
+// not the raw path, so packet IDs and analyzer IDs do not create a series each.
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// statsCollector exports gauges from a distributor stats snapshot taken at scrape time
+type statsCollector struct {
+	stats func() *models.DistributorStats
+
+	queueDepth        *prometheus.Desc
+	queueCapacity     *prometheus.Desc
+	activeAnalyzers   *prometheus.Desc
+	analyzerHealthy   *prometheus.Desc
+	analyzerAccepts   *prometheus.Desc
+	resultSubscribers *prometheus.Desc
+}
+
+func newStatsCollector(stats func() *models.DistributorStats) *statsCollector {
+	return &statsCollector{
+		stats: stats,
+		queueDepth: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "queue_depth"),
+			"Items waiting in an internal channel.", []string{"queue"}, nil),
+		queueCapacity: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "queue_capacity"),
+			"Buffer size of an internal channel.", []string{"queue"}, nil),
+		activeAnalyzers: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_analyzers"),
+			"Analyzers currently accepting packets.", nil, nil),
+		analyzerHealthy: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "analyzer_healthy"),
+			"Whether the analyzer passed its last health check or is forced healthy (1) or not (0).", []string{"analyzer"}, nil),
+		analyzerAccepts: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "analyzer_accepting_packets"),
+			"Whether the analyzer can be selected for new packets (1) or not (0), after overrides.", []string{"analyzer"}, nil),
+		resultSubscribers: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "result_stream_subscribers"),
+			"Live result stream subscribers.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.queueDepth
+	ch <- c.queueCapacity
+	ch <- c.activeAnalyzers
+	ch <- c.analyzerHealthy
+	ch <- c.analyzerAccepts
+	ch <- c.resultSubscribers
+}
+
+// Collect implements prometheus.Collector
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+
+	type queueGauge struct {
+		name     string
+		depth    int
+		capacity int
+	}
+	queues := []queueGauge{
+		{"packet", stats.PacketQueueDepth, config.PacketChannelBuffer},
+		{"result", stats.ResultQueueDepth, config.ResultChannelBuffer},
+		{"retry", stats.RetryQueueDepth, config.RetryChannelBuffer},
+	}
+	// Each priority lane of the packet queue is also exported on its own
+	for _, priority := range models.Priorities {
+		if lane, exists := stats.Lanes[priority]; exists {
+			queues = append(queues, queueGauge{"packet_" + string(priority), lane.Depth, lane.Capacity})
+		}
+	}
+	for _, queue := range queues {
+		ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(queue.depth), queue.name)
+		ch <- prometheus.MustNewConstMetric(c.queueCapacity, prometheus.GaugeValue, float64(queue.capacity), queue.name)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.activeAnalyzers, prometheus.GaugeValue, float64(stats.ActiveAnalyzers))
+	ch <- prometheus.MustNewConstMetric(c.resultSubscribers, prometheus.GaugeValue, float64(stats.ResultSubscribers))
+
+	now := time.Now()
+	for id, analyzer := range stats.AnalyzerStats {
+		ch <- prometheus.MustNewConstMetric(c.analyzerHealthy, prometheus.GaugeValue, boolValue(analyzer.IsHealthy), id)
+		ch <- prometheus.MustNewConstMetric(c.analyzerAccepts, prometheus.GaugeValue, boolValue(analyzer.AcceptsPackets(now)), id)
+	}
+}
+
+// boolValue converts a flag to a gauge value
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"io"
+	"logs-distributor/metrics"
+	"logs-distributor/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrape returns the exposition text served by the metrics handler
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics_PipelineCounters(t *testing.T) {
+	m := metrics.New()
+
+	m.PacketReceived()
+	m.PacketReceived()
+	m.PacketRouted("analyzer-a1")
+	m.PacketSucceeded("analyzer-a1")
+	m.PacketRetried("analyzer-a2")
+	m.PacketDeadLettered("analyzer-a2")
+	m.ObserveProcessing("analyzer-a1", true, 120*time.Millisecond)
+	m.ObserveProcessing("analyzer-a2", false, 40*time.Millisecond)
+
+	body := scrape(t, m)
+	assert.Contains(t, body, "logs_distributor_packets_received_total 2")
+	assert.Contains(t, body, `logs_distributor_packets_routed_total{analyzer="analyzer-a1"} 1`)
+	assert.Contains(t, body, `logs_distributor_packets_succeeded_total{analyzer="analyzer-a1"} 1`)
+	assert.Contains(t, body, `logs_distributor_packets_retried_total{analyzer="analyzer-a2"} 1`)
+	assert.Contains(t, body, `logs_distributor_packets_dead_lettered_total{analyzer="analyzer-a2"} 1`)
+	assert.Contains(t, body, `logs_distributor_analyzer_processing_seconds_bucket{analyzer="analyzer-a1",outcome="success",le="0.15"} 1`)
+	assert.Contains(t, body, `logs_distributor_analyzer_processing_seconds_bucket{analyzer="analyzer-a1",outcome="success",le="0.1"} 0`)
+	assert.Contains(t, body, `logs_distributor_analyzer_processing_seconds_count{analyzer="analyzer-a2",outcome="failure"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+
+	// Removed analyzers stop being exported
+	m.ForgetAnalyzer("analyzer-a2")
+	body = scrape(t, m)
+	assert.NotContains(t, body, `analyzer="analyzer-a2"`)
+	assert.Contains(t, body, `analyzer="analyzer-a1"`)
+}
+
+func TestMetrics_DistributorGauges(t *testing.T) {
+	m := metrics.New()
+
+	stats := &models.DistributorStats{
+		ActiveAnalyzers:  1,
+		PacketQueueDepth: 12,
+		ResultQueueDepth: 3,
+		Lanes: map[models.Priority]models.LaneStats{
+			models.PriorityHigh: {Depth: 2, Capacity: 667},
+			models.PriorityLow:  {Depth: 10, Capacity: 666},
+		},
+		AnalyzerStats: map[string]*models.Analyzer{
+			"healthy":  {ID: "healthy", IsHealthy: true},
+			"draining": {ID: "draining", IsHealthy: true, Override: &models.HealthOverride{Mode: models.OverrideDraining}},
+			"down":     {ID: "down", IsHealthy: false},
+		},
+	}
+	m.WatchDistributor(func() *models.DistributorStats { return stats })
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `logs_distributor_queue_depth{queue="packet"} 12`)
+	assert.Contains(t, body, `logs_distributor_queue_depth{queue="result"} 3`)
+	assert.Contains(t, body, `logs_distributor_queue_depth{queue="retry"} 0`)
+	assert.Contains(t, body, `logs_distributor_queue_capacity{queue="packet"} 2000`)
+	assert.Contains(t, body, `logs_distributor_queue_depth{queue="packet_high"} 2`)
+	assert.Contains(t, body, `logs_distributor_queue_depth{queue="packet_low"} 10`)
+	assert.Contains(t, body, `logs_distributor_queue_capacity{queue="packet_low"} 666`)
+	assert.Contains(t, body, "logs_distributor_active_analyzers 1")
+	assert.Contains(t, body, `logs_distributor_analyzer_healthy{analyzer="down"} 0`)
+	assert.Contains(t, body, `logs_distributor_analyzer_healthy{analyzer="draining"} 1`)
+	assert.Contains(t, body, `logs_distributor_analyzer_accepting_packets{analyzer="draining"} 0`)
+	assert.Contains(t, body, `logs_distributor_analyzer_accepting_packets{analyzer="healthy"} 1`)
+
+	// Gauges follow the stats at each scrape
+	stats.PacketQueueDepth = 0
+	delete(stats.AnalyzerStats, "down")
+	body = scrape(t, m)
+	assert.Contains(t, body, `logs_distributor_queue_depth{queue="packet"} 0`)
+	assert.NotContains(t, body, `analyzer="down"`)
+}
+
+func TestMetrics_HTTPRequests(t *testing.T) {
+	m := metrics.New()
+
+	m.ObserveHTTPRequest(http.MethodGet, "/api/v1/packets/:id", http.StatusOK, 5*time.Millisecond)
+	m.ObserveHTTPRequest(http.MethodGet, "/api/v1/packets/:id", http.StatusNotFound, 2*time.Millisecond)
+	m.ObserveHTTPRequest(http.MethodGet, "", http.StatusNotFound, time.Millisecond)
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `logs_distributor_http_requests_total{method="GET",route="/api/v1/packets/:id",status="200"} 1`)
+	assert.Contains(t, body, `logs_distributor_http_requests_total{method="GET",route="/api/v1/packets/:id",status="404"} 1`)
+	assert.Contains(t, body, `logs_distributor_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `logs_distributor_http_request_duration_seconds_count{method="GET",route="/api/v1/packets/:id"} 2`)
+}
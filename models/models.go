@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -19,9 +20,17 @@ type LogMessage struct {
 
 // LogPacket represents a collection of log messages sent to the distributor
 type LogPacket struct {
-	ID         string       `json:"id"`
-	Messages   []LogMessage `json:"messages"`
-	RetryCount int          `json:"retry_count,omitempty"` // Number of retry attempts
+	ID             string       `json:"id"`
+	Messages       []LogMessage `json:"messages"`
+	RetryCount     int          `json:"retry_count,omitempty"`     // Number of retry attempts
+	IdempotencyKey string       `json:"idempotency_key,omitempty"` // Deduplication key; the packet ID is used when empty
+	TenantID       string       `json:"tenant_id,omitempty"`       // Set from the authenticated API key, never from the client
+	TraceParent    string       `json:"traceparent,omitempty"`     // W3C trace context of the submission; retries and replays join its trace
+	Route          string       `json:"route,omitempty"`           // routing rule that matched the messages; empty for the default pool
+	Affinity       string       `json:"affinity,omitempty"`        // key consistent hashing keeps on one analyzer; the packet ID is used when empty
+	Priority       Priority     `json:"priority,omitempty"`        // queue lane; derived from the most severe message level when empty
+
+	EnqueuedAt time.Time `json:"-"` // when the packet last entered the packet channel, for queue wait spans
 }
 
 // Analyzer represents an analyzer service configuration
@@ -34,19 +43,117 @@ type Analyzer struct {
 	LastHealthCheck  time.Time `json:"last_health_check"`
 	ProcessedCount   int64     `json:"processed_count"` // Use atomic operations for these
 	ErrorCount       int64     `json:"error_count"`     // Use atomic operations for these
+
+	Override *HealthOverride `json:"override,omitempty"` // operator override, set through the HealthMonitor
+}
+
+// OverrideMode is an operator-set analyzer state that takes precedence over health checks
+type OverrideMode string
+
+// Analyzer override modes
+const (
+	OverrideForcedHealthy   OverrideMode = "forced-healthy"   // selectable regardless of health checks
+	OverrideForcedUnhealthy OverrideMode = "forced-unhealthy" // never selected
+	OverrideDraining        OverrideMode = "draining"         // no new packets; in-flight packets finish
+	OverrideMaintenance     OverrideMode = "maintenance"      // no new packets until the override expires
+)
+
+// HealthOverride is an operator override of an analyzer's health
+type HealthOverride struct {
+	Mode      OverrideMode `json:"mode"`
+	Reason    string       `json:"reason,omitempty"`
+	SetAt     time.Time    `json:"set_at"`
+	ExpiresAt *time.Time   `json:"expires_at,omitempty"` // required for maintenance
 }
 
 // DistributorStats represents current distributor statistics
 type DistributorStats struct {
-	TotalPacketsReceived int64                `json:"total_packets_received"`
-	TotalMessagesRouted  int64                `json:"total_messages_routed"`
-	ActiveAnalyzers      int                  `json:"active_analyzers"`
-	PacketChannelUtil    float64              `json:"packet_channel_util_percent"`
-	ResultChannelUtil    float64              `json:"result_channel_util_percent"`
-	RetryChannelUtil     float64              `json:"retry_channel_util_percent"`
-	AnalyzerStats        map[string]*Analyzer `json:"analyzer_stats"`
-	Uptime               time.Duration        `json:"uptime"`
-	LastFailure          *time.Time           `json:"last_failure,omitempty"`
+	TotalPacketsReceived int64                      `json:"total_packets_received"`
+	TotalMessagesRouted  int64                      `json:"total_messages_routed"`
+	DuplicatePackets     int64                      `json:"duplicate_packets"`
+	ResultSubscribers    int                        `json:"result_subscribers"`
+	DroppedStreamResults int64                      `json:"dropped_stream_results"` // results not delivered to slow live subscribers
+	ActiveAnalyzers      int                        `json:"active_analyzers"`
+	PacketChannelUtil    float64                    `json:"packet_channel_util_percent"`
+	ResultChannelUtil    float64                    `json:"result_channel_util_percent"`
+	RetryChannelUtil     float64                    `json:"retry_channel_util_percent"`
+	PacketQueueDepth     int                        `json:"packet_queue_depth"`
+	Lanes                map[Priority]LaneStats     `json:"lanes"` // the packet queue by priority
+	ResultQueueDepth     int                        `json:"result_queue_depth"`
+	RetryQueueDepth      int                        `json:"retry_queue_depth"`
+	AnalyzerStats        map[string]*Analyzer       `json:"analyzer_stats"`
+	Routes               []RouteStats               `json:"routes,omitempty"`
+	EffectiveWeights     map[string]EffectiveWeight `json:"effective_weights,omitempty"` // set when the load balancer adapts weights
+	Circuits             map[string]CircuitStats    `json:"circuits,omitempty"`
+	Bulkheads            map[string]BulkheadStats   `json:"bulkheads,omitempty"`
+	Hedging              map[string]HedgeStats      `json:"hedging,omitempty"` // by route name
+	Uptime               time.Duration              `json:"uptime"`
+	LastFailure          *time.Time                 `json:"last_failure,omitempty"`
+}
+
+// LaneStats reports one priority lane of the packet queue
+type LaneStats struct {
+	Depth    int   `json:"depth"`
+	Capacity int   `json:"capacity"`
+	Weight   int   `json:"weight"`   // share of dequeues while the lane is backlogged
+	Dequeued int64 `json:"dequeued"` // packets taken by the workers since startup
+}
+
+// EffectiveWeight is the weight the load balancer currently gives an analyzer and the reason for its last change
+type EffectiveWeight struct {
+	Weight    float64   `json:"effective_weight"`
+	ErrorRate float64   `json:"error_rate"` // over the sliding window
+	Reason    string    `json:"weight_reason"`
+	ChangedAt time.Time `json:"weight_changed_at,omitempty"`
+}
+
+// BulkheadStats reports an analyzer's concurrency limit and the packets held by it
+type BulkheadStats struct {
+	InFlight    int `json:"in_flight"`
+	Queued      int `json:"queued"` // waiting for an in-flight slot
+	MaxInFlight int `json:"max_in_flight"`
+	QueueSize   int `json:"queue_size"`
+}
+
+// HedgeStats reports hedged requests on one route
+type HedgeStats struct {
+	Packets    int64   `json:"packets"`    // dispatched on the route
+	Hedged     int64   `json:"hedged"`     // packets sent to a second analyzer
+	Won        int64   `json:"won"`        // hedged packets whose second analyzer succeeded first
+	Throttled  int64   `json:"throttled"`  // hedges skipped by the rate cap
+	Duplicates int64   `json:"duplicates"` // successes discarded because the other leg had already won
+	DelayMs    float64 `json:"delay_ms"`   // current hedge delay; 0 until enough latencies are measured
+}
+
+// HedgeOutcome is an event in the life of a hedged packet
+type HedgeOutcome string
+
+// Hedge outcomes
+const (
+	HedgeSent      HedgeOutcome = "sent"      // the packet went to a second analyzer
+	HedgeWon       HedgeOutcome = "won"       // the second analyzer succeeded first
+	HedgeDuplicate HedgeOutcome = "duplicate" // a success arrived after the other leg won
+)
+
+// CircuitState is the state of an analyzer's circuit breaker
+type CircuitState string
+
+// Circuit breaker states
+const (
+	CircuitClosed   CircuitState = "closed"    // packets flow normally
+	CircuitOpen     CircuitState = "open"      // no packets until the open timeout passes
+	CircuitHalfOpen CircuitState = "half-open" // a few probe packets test whether the analyzer recovered
+)
+
+// CircuitStats reports an analyzer's circuit breaker
+type CircuitStats struct {
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	FailureRatio        float64      `json:"failure_ratio"` // over the recent results window
+	ProbesInFlight      int          `json:"probes_in_flight,omitempty"`
+	Trips               int64        `json:"trips"`            // times the circuit opened
+	Reason              string       `json:"reason,omitempty"` // why it last opened
+	ChangedAt           time.Time    `json:"changed_at,omitempty"`
 }
 
 // DistributorState represents the state that needs to be persisted for recovery
@@ -55,6 +162,45 @@ type DistributorState struct {
 	PendingPackets []LogPacket          `json:"pending_packets"`
 	LastCheckpoint time.Time            `json:"last_checkpoint"`
 	TotalProcessed int64                `json:"total_processed"`
+	DedupEntries   map[string]time.Time `json:"dedup_entries,omitempty"` // Idempotency keys still inside the window
+}
+
+// PacketState is a stage in a packet's lifecycle
+type PacketState string
+
+// Packet lifecycle states recorded by the distributor and retry handler
+const (
+	PacketStateQueued       PacketState = "queued"        // waiting in the packet channel
+	PacketStateDispatched   PacketState = "dispatched"    // handed to an analyzer
+	PacketStateRetrying     PacketState = "retrying"      // failed, waiting for backoff before requeue
+	PacketStateSucceeded    PacketState = "succeeded"     // analyzer returned a successful result
+	PacketStateDeadLettered PacketState = "dead_lettered" // failed permanently after max retries
+	PacketStateRejected     PacketState = "rejected"      // could not be queued (timeout or shutdown)
+	PacketStateSplit        PacketState = "split"         // divided by routing rules into parts tracked under their own IDs
+)
+
+// PacketTransition records a single lifecycle state change
+type PacketTransition struct {
+	State      PacketState `json:"state"`
+	At         time.Time   `json:"at"`
+	AnalyzerID string      `json:"analyzer_id,omitempty"`
+	RetryCount int         `json:"retry_count"`
+	Error      string      `json:"error,omitempty"`
+	Parts      []string    `json:"parts,omitempty"` // packet IDs of the parts, for split packets
+}
+
+// PacketStatus is the current lifecycle state of a packet along with its transition history
+type PacketStatus struct {
+	PacketID    string             `json:"packet_id"`
+	TenantID    string             `json:"tenant_id,omitempty"` // tenant that submitted the packet; statuses are looked up within it
+	State       PacketState        `json:"state"`
+	AnalyzerID  string             `json:"analyzer_id,omitempty"`
+	RetryCount  int                `json:"retry_count"`
+	LastError   string             `json:"last_error,omitempty"`
+	Parts       []string           `json:"parts,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Transitions []PacketTransition `json:"transitions"`
 }
 
 // AnalysisResult represents the result from an analyzer
@@ -62,9 +208,204 @@ type AnalysisResult struct {
 	PacketID    string                 `json:"packet_id"`
 	AnalyzerID  string                 `json:"analyzer_id"`
 	Success     bool                   `json:"success"`
+	RetryCount  int                    `json:"retry_count,omitempty"` // retry attempt that produced this result
 	ProcessedAt time.Time              `json:"processed_at"`
 	Results     map[string]interface{} `json:"results,omitempty"`
 	Error       string                 `json:"error,omitempty"`
+	TraceParent string                 `json:"traceparent,omitempty"` // trace of the analyzed packet
+	TenantID    string                 `json:"tenant_id,omitempty"`   // tenant of the analyzed packet
+}
+
+// TrackingKey returns the analyzed packet's tracking key
+func (r AnalysisResult) TrackingKey() string {
+	return TenantScopedKey(r.TenantID, r.PacketID)
+}
+
+// ResultFilter selects which analysis results a live subscriber receives; empty fields match everything
+type ResultFilter struct {
+	AnalyzerID string `json:"analyzer_id,omitempty"`
+	PacketID   string `json:"packet_id,omitempty"`
+	Success    *bool  `json:"success,omitempty"`
+	TenantID   string `json:"-"` // set from the subscriber's API key, never from the client
+}
+
+// Matches reports whether the result passes the filter
+func (f ResultFilter) Matches(result AnalysisResult) bool {
+	if f.TenantID != "" && f.TenantID != result.TenantID {
+		return false
+	}
+	if f.AnalyzerID != "" && f.AnalyzerID != result.AnalyzerID {
+		return false
+	}
+	if f.PacketID != "" && f.PacketID != result.PacketID {
+		return false
+	}
+	if f.Success != nil && *f.Success != result.Success {
+		return false
+	}
+	return true
+}
+
+// DeadLetterEntry is a packet that failed permanently after exhausting its retries
+type DeadLetterEntry struct {
+	ID         string     `json:"id,omitempty"` // assigned by the dead letter store from the entry's position
+	Packet     LogPacket  `json:"packet"`
+	FinalError string     `json:"final_error"`
+	AnalyzerID string     `json:"analyzer_id,omitempty"` // analyzer of the final attempt
+	FailedAt   time.Time  `json:"failed_at"`
+	ReplayedAt *time.Time `json:"replayed_at,omitempty"` // set once the entry has been replayed
+}
+
+// DeadLetterFilter selects dead-letter entries; empty fields match everything
+type DeadLetterFilter struct {
+	PacketIDs     []string   `json:"packet_ids,omitempty"`
+	Since         *time.Time `json:"since,omitempty"` // inclusive
+	Until         *time.Time `json:"until,omitempty"` // exclusive
+	ErrorContains string     `json:"error_contains,omitempty"`
+	AnalyzerID    string     `json:"analyzer_id,omitempty"`
+	Replayed      *bool      `json:"replayed,omitempty"`
+	TenantID      string     `json:"-"` // set from the caller's API key, never from the client
+}
+
+// Matches reports whether the entry passes the filter
+func (f DeadLetterFilter) Matches(entry DeadLetterEntry) bool {
+	if f.TenantID != "" && f.TenantID != entry.Packet.TenantID {
+		return false
+	}
+	if len(f.PacketIDs) > 0 {
+		found := false
+		for _, id := range f.PacketIDs {
+			if id == entry.Packet.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Since != nil && entry.FailedAt.Before(*f.Since) {
+		return false
+	}
+	if f.Until != nil && !entry.FailedAt.Before(*f.Until) {
+		return false
+	}
+	if f.ErrorContains != "" && !strings.Contains(entry.FinalError, f.ErrorContains) {
+		return false
+	}
+	if f.AnalyzerID != "" && f.AnalyzerID != entry.AnalyzerID {
+		return false
+	}
+	if f.Replayed != nil && *f.Replayed != (entry.ReplayedAt != nil) {
+		return false
+	}
+	return true
+}
+
+// DeadLetterPage is one page of dead letter entries, newest first
+type DeadLetterPage struct {
+	Entries    []DeadLetterEntry `json:"entries"`
+	NextCursor string            `json:"next_cursor,omitempty"` // pass back to fetch older entries; empty on the last page
+}
+
+// ReplayRequest selects dead-letter entries to resubmit
+type ReplayRequest struct {
+	DeadLetterFilter
+	DryRun        bool    `json:"dry_run,omitempty"`         // report what would be replayed without submitting
+	RatePerSecond float64 `json:"rate_per_second,omitempty"` // submission cap; defaults to config.DefaultReplayRate
+	Limit         int     `json:"limit,omitempty"`           // maximum entries to replay in this call
+}
+
+// ReplayResult reports the outcome of a dead-letter replay
+type ReplayResult struct {
+	DryRun    bool              `json:"dry_run"`
+	Matched   int               `json:"matched"`   // unreplayed entries matching the filter
+	Replayed  int               `json:"replayed"`  // entries resubmitted (or that would be, in a dry run)
+	Failed    int               `json:"failed"`    // entries whose resubmission was refused
+	Remaining int               `json:"remaining"` // matching entries left for a later call
+	PacketIDs []string          `json:"packet_ids"`
+	Errors    map[string]string `json:"errors,omitempty"` // packet ID to submission error
+}
+
+// DeliveryMode selects how many analyzers on a route receive each packet
+type DeliveryMode string
+
+// Delivery modes
+const (
+	DeliveryOne  DeliveryMode = "one"    // a single analyzer chosen by the load balancer
+	DeliveryAll  DeliveryMode = "all"    // every analyzer on the route that accepts packets
+	DeliveryKOfN DeliveryMode = "k-of-n" // fan_out analyzers; done once quorum of them succeed
+)
+
+// Delivery configures fan-out for a route. The packet counts as done once Quorum legs succeed;
+// for DeliveryAll that is every analyzer it was sent to.
+type Delivery struct {
+	Mode   DeliveryMode `json:"delivery,omitempty"` // DeliveryOne when empty
+	FanOut int          `json:"fan_out,omitempty"`  // n, for DeliveryKOfN
+	Quorum int          `json:"quorum,omitempty"`   // k, for DeliveryKOfN
+}
+
+// DeliveryProgress reports which analyzers hold legs of a packet's delivery
+type DeliveryProgress struct {
+	Quorum    int      // successful legs needed; 0 before the first dispatch
+	Pending   []string // analyzers still processing a leg
+	Succeeded []string // analyzers whose leg succeeded
+}
+
+// RoutingRule sends messages matching all of its set conditions to one analyzer or analyzer group.
+// Within a list condition any entry may match. Source and metadata patterns accept * wildcards.
+type RoutingRule struct {
+	Name     string            `json:"name"`
+	Levels   []string          `json:"levels,omitempty"`   // case-insensitive
+	Sources  []string          `json:"sources,omitempty"`  // patterns such as "auth-*"
+	Message  string            `json:"message,omitempty"`  // regular expression
+	Metadata map[string]string `json:"metadata,omitempty"` // key to value pattern; "*" only requires the key
+	Analyzer string            `json:"analyzer,omitempty"`
+	Group    string            `json:"group,omitempty"`
+	Delivery
+}
+
+// RoutingConfig is the set of routing rules, checked in order, and the analyzer groups they route to.
+// Messages matching no rule go to the default group, or to the analyzers no rule routes to when it is empty,
+// with the config's own delivery mode.
+type RoutingConfig struct {
+	Groups  map[string][]string    `json:"groups,omitempty"`
+	Rules   []RoutingRule          `json:"rules"`
+	Default string                 `json:"default_group,omitempty"`
+	Hedging map[string]HedgeConfig `json:"hedging,omitempty"` // by group name
+	Delivery
+}
+
+// HedgeConfig enables hedged requests on the routes to an analyzer group: a packet whose analyzer has not
+// returned within the hedge delay is also sent to a second analyzer of the group, and the first success wins.
+// Zero fields take the defaults from the config package.
+type HedgeConfig struct {
+	Percentile float64 `json:"percentile,omitempty"`   // of the route's recent latencies, used as the hedge delay
+	MinDelayMs int     `json:"min_delay_ms,omitempty"` // floor on the hedge delay
+	MaxRate    float64 `json:"max_rate,omitempty"`     // share of the route's packets that may be hedged
+}
+
+// RouteStats reports the traffic sent down one route
+type RouteStats struct {
+	Name      string   `json:"name"`
+	Analyzers []string `json:"analyzers,omitempty"` // empty for a default route covering every unrouted analyzer
+	Delivery
+	Packets  int64 `json:"packets"`
+	Messages int64 `json:"messages"`
+}
+
+// MixedAffinity decides what happens to a packet whose messages have different affinity keys
+type MixedAffinity string
+
+const (
+	MixedAffinitySplit MixedAffinity = "split" // each key's messages become a part of their own
+	MixedAffinityFirst MixedAffinity = "first" // the whole packet follows its first message's key
+)
+
+// AffinityConfig selects the message field consistent hashing keeps on one analyzer
+type AffinityConfig struct {
+	Key   string        // "source" or "metadata.<key>"; empty disables affinity
+	Mixed MixedAffinity // MixedAffinitySplit when empty
 }
 
 // NewLogMessage creates a new log message with generated ID and timestamp
@@ -87,6 +428,128 @@ func NewLogPacket(messages []LogMessage) LogPacket {
 	}
 }
 
+// NewIdempotentLogPacket creates a log packet whose ID is derived from the tenant's idempotency key,
+// so a retried submission gets the same packet ID as the original
+func NewIdempotentLogPacket(messages []LogMessage, tenantID, idempotencyKey string) LogPacket {
+	packet := LogPacket{
+		Messages:       messages,
+		IdempotencyKey: idempotencyKey,
+		TenantID:       tenantID,
+	}
+	packet.ID = uuid.NewSHA1(uuid.NameSpaceURL, []byte(packet.DedupKey())).String()
+	return packet
+}
+
+// DedupKey returns the key used to detect duplicate submissions of this packet.
+// Idempotency keys and packet IDs are scoped to the tenant so tenants cannot collide with each other.
+func (p LogPacket) DedupKey() string {
+	key := p.ID
+	if p.IdempotencyKey != "" {
+		key = p.IdempotencyKey
+	}
+	return TenantScopedKey(p.TenantID, key)
+}
+
+// TrackingKey returns the packet's ID scoped to its tenant. The distributor tracks packets and their
+// lifecycle under it, so two tenants may submit the same packet ID.
+func (p LogPacket) TrackingKey() string {
+	return TenantScopedKey(p.TenantID, p.ID)
+}
+
+// TenantScopedKey prefixes a key with its tenant; keys without a tenant are returned unchanged
+func TenantScopedKey(tenantID, key string) string {
+	if key != "" && tenantID != "" {
+		return tenantID + "/" + key
+	}
+	return key
+}
+
+// Priority is a packet's lane in the distributor's packet queue
+type Priority string
+
+// Packet priorities
+const (
+	PriorityHigh   Priority = "high"   // ERROR and FATAL logs
+	PriorityNormal Priority = "normal" // WARN, INFO and unrecognized levels
+	PriorityLow    Priority = "low"    // DEBUG and TRACE logs
+)
+
+// Priorities lists the packet priorities from the highest
+var Priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// IsValid reports whether the priority is a known priority
+func (p Priority) IsValid() bool {
+	switch p {
+	case PriorityHigh, PriorityNormal, PriorityLow:
+		return true
+	}
+	return false
+}
+
+// LevelPriority returns the priority of a message with the given log level
+func LevelPriority(level string) Priority {
+	switch strings.ToUpper(level) {
+	case "FATAL", "CRITICAL", "ERROR":
+		return PriorityHigh
+	case "DEBUG", "TRACE":
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+// EffectivePriority returns the packet's explicit priority, or the priority of its most severe message
+func (p LogPacket) EffectivePriority() Priority {
+	if p.Priority != "" {
+		return p.Priority
+	}
+
+	priority := PriorityLow
+	for _, msg := range p.Messages {
+		switch LevelPriority(msg.Level) {
+		case PriorityHigh:
+			return PriorityHigh
+		case PriorityNormal:
+			priority = PriorityNormal
+		}
+	}
+	return priority
+}
+
+// IsValid reports whether the mode is a known override mode
+func (m OverrideMode) IsValid() bool {
+	switch m {
+	case OverrideForcedHealthy, OverrideForcedUnhealthy, OverrideDraining, OverrideMaintenance:
+		return true
+	}
+	return false
+}
+
+// ActiveMode returns the override mode in effect at now, or "" if there is none or it has expired
+func (o *HealthOverride) ActiveMode(now time.Time) OverrideMode {
+	if o == nil || o.Expired(now) {
+		return ""
+	}
+	return o.Mode
+}
+
+// Expired reports whether the override has passed its expiry time
+func (o *HealthOverride) Expired(now time.Time) bool {
+	return o != nil && o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+// AcceptsPackets reports whether the analyzer may be selected for new packets,
+// applying any operator override before the health check result
+func (a *Analyzer) AcceptsPackets(now time.Time) bool {
+	switch a.Override.ActiveMode(now) {
+	case OverrideForcedHealthy:
+		return true
+	case OverrideForcedUnhealthy, OverrideDraining, OverrideMaintenance:
+		return false
+	}
+	return a.IsHealthy
+}
+
 // GetProcessedCount returns the processed count safely
 func (a *Analyzer) GetProcessedCount() int64 {
 	return atomic.LoadInt64(&a.ProcessedCount)
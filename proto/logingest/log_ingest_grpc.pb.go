@@ -0,0 +1,191 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: logingest/log_ingest.proto
+
+package logingest
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	LogIngest_SubmitPackets_FullMethodName = "/logingest.v1.LogIngest/SubmitPackets"
+	LogIngest_StreamPackets_FullMethodName = "/logingest.v1.LogIngest/StreamPackets"
+)
+
+// LogIngestClient is the client API for LogIngest service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// LogIngest accepts log packets over gRPC and hands them to the distributor
+type LogIngestClient interface {
+	// SubmitPackets submits a batch of packets in a single call
+	SubmitPackets(ctx context.Context, in *SubmitPacketsRequest, opts ...grpc.CallOption) (*SubmitPacketsResponse, error)
+	// StreamPackets submits packets as they arrive and reports on all of them when the client closes the stream
+	StreamPackets(ctx context.Context, opts ...grpc.CallOption) (LogIngest_StreamPacketsClient, error)
+}
+
+type logIngestClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLogIngestClient(cc grpc.ClientConnInterface) LogIngestClient {
+	return &logIngestClient{cc}
+}
+
+func (c *logIngestClient) SubmitPackets(ctx context.Context, in *SubmitPacketsRequest, opts ...grpc.CallOption) (*SubmitPacketsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitPacketsResponse)
+	err := c.cc.Invoke(ctx, LogIngest_SubmitPackets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logIngestClient) StreamPackets(ctx context.Context, opts ...grpc.CallOption) (LogIngest_StreamPacketsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LogIngest_ServiceDesc.Streams[0], LogIngest_StreamPackets_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &logIngestStreamPacketsClient{ClientStream: stream}
+	return x, nil
+}
+
+type LogIngest_StreamPacketsClient interface {
+	Send(*LogPacket) error
+	CloseAndRecv() (*SubmitPacketsResponse, error)
+	grpc.ClientStream
+}
+
+type logIngestStreamPacketsClient struct {
+	grpc.ClientStream
+}
+
+func (x *logIngestStreamPacketsClient) Send(m *LogPacket) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *logIngestStreamPacketsClient) CloseAndRecv() (*SubmitPacketsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(SubmitPacketsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LogIngestServer is the server API for LogIngest service.
+// All implementations must embed UnimplementedLogIngestServer
+// for forward compatibility
+//
+// LogIngest accepts log packets over gRPC and hands them to the distributor
+type LogIngestServer interface {
+	// SubmitPackets submits a batch of packets in a single call
+	SubmitPackets(context.Context, *SubmitPacketsRequest) (*SubmitPacketsResponse, error)
+	// StreamPackets submits packets as they arrive and reports on all of them when the client closes the stream
+	StreamPackets(LogIngest_StreamPacketsServer) error
+	mustEmbedUnimplementedLogIngestServer()
+}
+
+// UnimplementedLogIngestServer must be embedded to have forward compatible implementations.
+type UnimplementedLogIngestServer struct {
+}
+
+func (UnimplementedLogIngestServer) SubmitPackets(context.Context, *SubmitPacketsRequest) (*SubmitPacketsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitPackets not implemented")
+}
+func (UnimplementedLogIngestServer) StreamPackets(LogIngest_StreamPacketsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamPackets not implemented")
+}
+func (UnimplementedLogIngestServer) mustEmbedUnimplementedLogIngestServer() {}
+
+// UnsafeLogIngestServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LogIngestServer will
+// result in compilation errors.
+type UnsafeLogIngestServer interface {
+	mustEmbedUnimplementedLogIngestServer()
+}
+
+func RegisterLogIngestServer(s grpc.ServiceRegistrar, srv LogIngestServer) {
+	s.RegisterService(&LogIngest_ServiceDesc, srv)
+}
+
+func _LogIngest_SubmitPackets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitPacketsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogIngestServer).SubmitPackets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LogIngest_SubmitPackets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogIngestServer).SubmitPackets(ctx, req.(*SubmitPacketsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LogIngest_StreamPackets_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogIngestServer).StreamPackets(&logIngestStreamPacketsServer{ServerStream: stream})
+}
+
+type LogIngest_StreamPacketsServer interface {
+	SendAndClose(*SubmitPacketsResponse) error
+	Recv() (*LogPacket, error)
+	grpc.ServerStream
+}
+
+type logIngestStreamPacketsServer struct {
+	grpc.ServerStream
+}
+
+func (x *logIngestStreamPacketsServer) SendAndClose(m *SubmitPacketsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *logIngestStreamPacketsServer) Recv() (*LogPacket, error) {
+	m := new(LogPacket)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LogIngest_ServiceDesc is the grpc.ServiceDesc for LogIngest service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LogIngest_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "logingest.v1.LogIngest",
+	HandlerType: (*LogIngestServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitPackets",
+			Handler:    _LogIngest_SubmitPackets_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamPackets",
+			Handler:       _LogIngest_StreamPackets_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "logingest/log_ingest.proto",
+}
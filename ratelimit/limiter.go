@@ -0,0 +1,273 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// KeyType selects what a rate limit rule counts requests against
+type KeyType string
+
+// Rule key types; callers without an API key or tenant fall back to their client IP
+const (
+	KeyClientIP KeyType = "ip"
+	KeyAPIKey   KeyType = "api_key"
+	KeyTenant   KeyType = "tenant"
+)
+
+// Rule is a pair of token buckets (packets and messages) kept per key.
+// A zero rate leaves that dimension unlimited.
+type Rule struct {
+	Key               KeyType `json:"key"`
+	PacketsPerSecond  float64 `json:"packets_per_second"`
+	PacketBurst       float64 `json:"packet_burst"`
+	MessagesPerSecond float64 `json:"messages_per_second"`
+	MessageBurst      float64 `json:"message_burst"`
+}
+
+// Caller identifies who a submission is counted against
+type Caller struct {
+	ClientIP string
+	KeyName  string // API key name, never the key itself
+	TenantID string
+}
+
+// Decision is the outcome of a rate limit check
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration // how long until the request would be allowed
+	Rule       KeyType       // the rule that limited the request
+	Key        string        // the key that was over its limit
+}
+
+// RuleStats reports the current state of a single rule
+type RuleStats struct {
+	Rule
+	TrackedKeys     int      `json:"tracked_keys"`
+	AllowedRequests int64    `json:"allowed_requests"`
+	LimitedRequests int64    `json:"limited_requests"`
+	LimitedKeys     []string `json:"limited_keys,omitempty"` // keys currently out of tokens
+}
+
+// bucket is a token bucket refilled continuously at a fixed rate
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// keyState holds the buckets for one key under one rule
+type keyState struct {
+	packets  bucket
+	messages bucket
+	lastSeen time.Time
+}
+
+// ruleState tracks every key seen by a rule
+type ruleState struct {
+	rule    Rule
+	keys    map[string]*keyState
+	allowed int64
+	limited int64
+}
+
+// Limiter applies token-bucket rules to submissions. A request must pass every rule.
+type Limiter struct {
+	mu          sync.Mutex
+	rules       []*ruleState
+	idleTimeout time.Duration
+	maxReported int
+	lastSweep   time.Time
+}
+
+// limitsFile is the on-disk format of the rate limits file
+type limitsFile struct {
+	Limits []Rule `json:"limits"`
+}
+
+// LoadRules reads rate limit rules from a JSON file
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limits file: %w", err)
+	}
+
+	var file limitsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limits file: %w", err)
+	}
+	return file.Limits, nil
+}
+
+// NewLimiter validates the rules and creates a limiter. Keys idle longer than
+// idleTimeout are forgotten; maxReported caps the limited keys listed in Stats.
+func NewLimiter(rules []Rule, idleTimeout time.Duration, maxReported int) (*Limiter, error) {
+	l := &Limiter{idleTimeout: idleTimeout, maxReported: maxReported, lastSweep: time.Now()}
+
+	for i, rule := range rules {
+		if rule.Key != KeyClientIP && rule.Key != KeyAPIKey && rule.Key != KeyTenant {
+			return nil, fmt.Errorf("limit %d: unknown key %q", i, rule.Key)
+		}
+		if rule.PacketsPerSecond < 0 || rule.MessagesPerSecond < 0 || rule.PacketBurst < 0 || rule.MessageBurst < 0 {
+			return nil, fmt.Errorf("limit %d: rates and bursts cannot be negative", i)
+		}
+		if rule.PacketsPerSecond == 0 && rule.MessagesPerSecond == 0 {
+			return nil, fmt.Errorf("limit %d: at least one of packets_per_second or messages_per_second is required", i)
+		}
+		// An unset burst allows one second's worth of traffic
+		if rule.PacketBurst == 0 {
+			rule.PacketBurst = math.Max(rule.PacketsPerSecond, 1)
+		}
+		if rule.MessageBurst == 0 {
+			rule.MessageBurst = math.Max(rule.MessagesPerSecond, 1)
+		}
+		l.rules = append(l.rules, &ruleState{rule: rule, keys: make(map[string]*keyState)})
+	}
+
+	return l, nil
+}
+
+// Allow checks the submission against every rule and consumes tokens only if all of them allow it.
+// Requests larger than a bucket's burst are allowed once the bucket is full, leaving it in debt.
+func (l *Limiter) Allow(caller Caller, packets, messages int) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweepLocked(now)
+
+	states := make([]*keyState, len(l.rules))
+	decision := Decision{Allowed: true}
+
+	for i, rs := range l.rules {
+		key := rs.rule.keyFor(caller)
+		state, exists := rs.keys[key]
+		if !exists {
+			state = &keyState{
+				packets:  bucket{tokens: rs.rule.PacketBurst, updated: now},
+				messages: bucket{tokens: rs.rule.MessageBurst, updated: now},
+			}
+			rs.keys[key] = state
+		}
+		state.lastSeen = now
+		states[i] = state
+
+		state.packets.refill(now, rs.rule.PacketsPerSecond, rs.rule.PacketBurst)
+		state.messages.refill(now, rs.rule.MessagesPerSecond, rs.rule.MessageBurst)
+
+		wait := math.Max(
+			state.packets.wait(float64(packets), rs.rule.PacketsPerSecond, rs.rule.PacketBurst),
+			state.messages.wait(float64(messages), rs.rule.MessagesPerSecond, rs.rule.MessageBurst),
+		)
+		if wait > 0 && (decision.Allowed || wait > decision.RetryAfter.Seconds()) {
+			decision = Decision{
+				Allowed:    false,
+				RetryAfter: time.Duration(wait * float64(time.Second)),
+				Rule:       rs.rule.Key,
+				Key:        key,
+			}
+		}
+	}
+
+	for i, rs := range l.rules {
+		if !decision.Allowed {
+			rs.limited++
+			continue
+		}
+		rs.allowed++
+		if rs.rule.PacketsPerSecond > 0 {
+			states[i].packets.tokens -= float64(packets)
+		}
+		if rs.rule.MessagesPerSecond > 0 {
+			states[i].messages.tokens -= float64(messages)
+		}
+	}
+
+	return decision
+}
+
+// Stats returns the current state of every rule
+func (l *Limiter) Stats() []RuleStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]RuleStats, 0, len(l.rules))
+	for _, rs := range l.rules {
+		ruleStats := RuleStats{
+			Rule:            rs.rule,
+			TrackedKeys:     len(rs.keys),
+			AllowedRequests: rs.allowed,
+			LimitedRequests: rs.limited,
+		}
+		for key, state := range rs.keys {
+			state.packets.refill(now, rs.rule.PacketsPerSecond, rs.rule.PacketBurst)
+			state.messages.refill(now, rs.rule.MessagesPerSecond, rs.rule.MessageBurst)
+			if state.packets.wait(1, rs.rule.PacketsPerSecond, rs.rule.PacketBurst) > 0 ||
+				state.messages.wait(1, rs.rule.MessagesPerSecond, rs.rule.MessageBurst) > 0 {
+				ruleStats.LimitedKeys = append(ruleStats.LimitedKeys, key)
+			}
+		}
+		sort.Strings(ruleStats.LimitedKeys)
+		if len(ruleStats.LimitedKeys) > l.maxReported {
+			ruleStats.LimitedKeys = ruleStats.LimitedKeys[:l.maxReported]
+		}
+		stats = append(stats, ruleStats)
+	}
+	return stats
+}
+
+// sweepLocked forgets keys that have been idle longer than the idle timeout
+func (l *Limiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idleTimeout {
+		return
+	}
+	l.lastSweep = now
+
+	for _, rs := range l.rules {
+		for key, state := range rs.keys {
+			if now.Sub(state.lastSeen) > l.idleTimeout {
+				delete(rs.keys, key)
+			}
+		}
+	}
+}
+
+// keyFor returns the bucket key for the caller under this rule
+func (r Rule) keyFor(caller Caller) string {
+	switch r.Key {
+	case KeyAPIKey:
+		if caller.KeyName != "" {
+			return "key:" + caller.KeyName
+		}
+	case KeyTenant:
+		if caller.TenantID != "" {
+			return "tenant:" + caller.TenantID
+		}
+	}
+	return "ip:" + caller.ClientIP
+}
+
+// refill adds the tokens earned since the last update, up to the burst
+func (b *bucket) refill(now time.Time, rate, burst float64) {
+	if rate > 0 {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	}
+	b.updated = now
+}
+
+// wait returns the seconds until cost tokens are available, or 0 if they already are
+func (b *bucket) wait(cost, rate, burst float64) float64 {
+	if rate == 0 {
+		return 0
+	}
+	needed := math.Min(cost, burst)
+	if b.tokens >= needed {
+		return 0
+	}
+	return (needed - b.tokens) / rate
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"logs-distributor/ratelimit"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_BurstThenLimit(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter([]ratelimit.Rule{
+		{Key: ratelimit.KeyAPIKey, PacketsPerSecond: 1, PacketBurst: 3},
+	}, time.Minute, 10)
+	require.NoError(t, err)
+
+	caller := ratelimit.Caller{ClientIP: "10.0.0.1", KeyName: "shipper"}
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow(caller, 1, 1).Allowed, "request %d should fit in the burst", i)
+	}
+
+	decision := limiter.Allow(caller, 1, 1)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, ratelimit.KeyAPIKey, decision.Rule)
+	assert.Equal(t, "key:shipper", decision.Key)
+	assert.Greater(t, decision.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, decision.RetryAfter, time.Second)
+}
+
+func TestLimiter_Refill(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter([]ratelimit.Rule{
+		{Key: ratelimit.KeyClientIP, PacketsPerSecond: 20, PacketBurst: 1},
+	}, time.Minute, 10)
+	require.NoError(t, err)
+
+	caller := ratelimit.Caller{ClientIP: "10.0.0.1"}
+	require.True(t, limiter.Allow(caller, 1, 1).Allowed)
+	require.False(t, limiter.Allow(caller, 1, 1).Allowed)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, limiter.Allow(caller, 1, 1).Allowed, "Tokens should refill over time")
+}
+
+func TestLimiter_MessagesAndOversizedRequests(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter([]ratelimit.Rule{
+		{Key: ratelimit.KeyClientIP, MessagesPerSecond: 10, MessageBurst: 10},
+	}, time.Minute, 10)
+	require.NoError(t, err)
+
+	caller := ratelimit.Caller{ClientIP: "10.0.0.1"}
+	assert.True(t, limiter.Allow(caller, 1, 25).Allowed, "A request larger than the burst is allowed from a full bucket")
+
+	decision := limiter.Allow(caller, 1, 1)
+	assert.False(t, decision.Allowed)
+	assert.Greater(t, decision.RetryAfter, time.Second, "The bucket should be in debt after an oversized request")
+}
+
+func TestLimiter_KeysAreIsolated(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter([]ratelimit.Rule{
+		{Key: ratelimit.KeyTenant, PacketsPerSecond: 1, PacketBurst: 1},
+	}, time.Minute, 10)
+	require.NoError(t, err)
+
+	tenantA := ratelimit.Caller{ClientIP: "10.0.0.1", TenantID: "a"}
+	tenantB := ratelimit.Caller{ClientIP: "10.0.0.1", TenantID: "b"}
+	anonymous := ratelimit.Caller{ClientIP: "10.0.0.1"}
+
+	assert.True(t, limiter.Allow(tenantA, 1, 1).Allowed)
+	assert.False(t, limiter.Allow(tenantA, 1, 1).Allowed)
+	assert.True(t, limiter.Allow(tenantB, 1, 1).Allowed, "Other tenants should have their own bucket")
+
+	decision := limiter.Allow(anonymous, 1, 1)
+	assert.True(t, decision.Allowed)
+	decision = limiter.Allow(anonymous, 1, 1)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "ip:10.0.0.1", decision.Key, "Callers without a tenant should fall back to their IP")
+}
+
+func TestLimiter_AllRulesMustAllow(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter([]ratelimit.Rule{
+		{Key: ratelimit.KeyAPIKey, PacketsPerSecond: 1, PacketBurst: 5},
+		{Key: ratelimit.KeyTenant, PacketsPerSecond: 1, PacketBurst: 2},
+	}, time.Minute, 10)
+	require.NoError(t, err)
+
+	caller := ratelimit.Caller{ClientIP: "10.0.0.1", KeyName: "shipper", TenantID: "acme"}
+	assert.True(t, limiter.Allow(caller, 1, 1).Allowed)
+	assert.True(t, limiter.Allow(caller, 1, 1).Allowed)
+
+	decision := limiter.Allow(caller, 1, 1)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, ratelimit.KeyTenant, decision.Rule)
+
+	stats := limiter.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, int64(2), stats[0].AllowedRequests)
+	assert.Equal(t, int64(1), stats[0].LimitedRequests)
+	assert.Empty(t, stats[0].LimitedKeys, "A rejected request should not consume tokens from other rules")
+	assert.Equal(t, 1, stats[1].TrackedKeys)
+	assert.Equal(t, []string{"tenant:acme"}, stats[1].LimitedKeys)
+}
+
+func TestLimiter_LoadRulesAndValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rate_limits.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"limits": [
+		{"key": "tenant", "packets_per_second": 100, "messages_per_second": 5000}
+	]}`), 0600))
+
+	rules, err := ratelimit.LoadRules(path)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	limiter, err := ratelimit.NewLimiter(rules, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Equal(t, float64(100), limiter.Stats()[0].PacketBurst, "An unset burst should default to one second of traffic")
+
+	invalid := [][]ratelimit.Rule{
+		{{Key: "user", PacketsPerSecond: 1}},
+		{{Key: ratelimit.KeyClientIP}},
+		{{Key: ratelimit.KeyClientIP, PacketsPerSecond: -1}},
+	}
+	for _, rules := range invalid {
+		_, err := ratelimit.NewLimiter(rules, time.Minute, 10)
+		assert.Error(t, err, "rules %+v should be rejected", rules)
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"logs-distributor/models"
+	"logs-distributor/tracing"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestTracing_PropagatesWithoutExporter(t *testing.T) {
+	header := http.Header{}
+	header.Set("traceparent", testTraceParent)
+
+	ctx := tracing.ExtractHTTP(context.Background(), header)
+	assert.Equal(t, testTraceParent, tracing.TraceParent(ctx), "The caller's trace context should be carried even when spans are not recorded")
+
+	packet := models.LogPacket{ID: "packet-1", TraceParent: testTraceParent}
+	_, span := tracing.StartPacketSpan(packet, "test")
+	defer span.End()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+
+	assert.Empty(t, tracing.TraceParent(context.Background()))
+}
+
+func TestTracing_FileExporter(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:       tracing.ExporterFile,
+		FilePath:       path,
+		ServiceName:    "logs-distributor",
+		ServiceVersion: "test",
+	})
+	require.NoError(t, err)
+
+	packet := models.LogPacket{ID: "packet-1", TraceParent: testTraceParent}
+	_, span := tracing.StartPacketSpan(packet, "distributor.submit")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"distributor.submit"`)
+	assert.Contains(t, string(data), "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Contains(t, string(data), "packet-1")
+}
+
+func TestTracing_UnknownExporter(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), tracing.Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{Exporter: tracing.ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
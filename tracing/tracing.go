@@ -0,0 +1,156 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"logs-distributor/models"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporter selects where finished spans are sent
+type Exporter string
+
+// Supported exporters
+const (
+	ExporterNone Exporter = "none" // spans are not recorded; trace context is still propagated
+	ExporterOTLP Exporter = "otlp" // OTLP/HTTP, configured by the standard OTEL_EXPORTER_OTLP_* variables
+	ExporterFile Exporter = "file" // one JSON span per line, for local testing
+)
+
+// tracerName identifies spans created by this service
+const tracerName = "logs-distributor"
+
+// propagator reads and writes W3C traceparent and tracestate headers
+var propagator = propagation.TraceContext{}
+
+// Config selects the exporter and identifies the service in exported spans
+type Config struct {
+	Exporter       Exporter
+	FilePath       string // used by ExporterFile
+	ServiceName    string
+	ServiceVersion string
+}
+
+// Setup installs the global tracer provider for the configured exporter.
+// The returned function flushes buffered spans and must be called on shutdown.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+
+	var exporter sdktrace.SpanExporter
+	var file *os.File
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var err error
+		if exporter, err = otlptracehttp.New(ctx); err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+	case ExporterFile:
+		var err error
+		if file, err = os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+			return nil, fmt.Errorf("failed to open traces file: %w", err)
+		}
+		if exporter, err = stdouttrace.New(stdouttrace.WithWriter(file)); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to create file trace exporter: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q - expected %s, %s or %s", cfg.Exporter, ExporterNone, ExporterOTLP, ExporterFile)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", cfg.ServiceName),
+			attribute.String("service.version", cfg.ServiceVersion),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Tracer returns the service's tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// ExtractHTTP returns ctx carrying the trace context of an incoming request's traceparent header
+func ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract returns ctx carrying the trace context from a carrier such as gRPC metadata
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return propagator.Extract(ctx, carrier)
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or "" without a valid span
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// PacketContext returns a context carrying the packet's trace, so its spans join the submission's trace
+func PacketContext(packet models.LogPacket) context.Context {
+	return traceParentContext(packet.TraceParent)
+}
+
+// StartPacketSpan starts a span for one stage of a packet's processing in the packet's trace
+func StartPacketSpan(packet models.LogPacket, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	opts = append(opts, trace.WithAttributes(
+		attribute.String("packet.id", packet.ID),
+		attribute.Int("packet.retry_count", packet.RetryCount),
+		attribute.Int("packet.messages", len(packet.Messages)),
+	))
+	return Tracer().Start(PacketContext(packet), name, opts...)
+}
+
+// StartResultSpan starts a span for handling an analysis result in the analyzed packet's trace
+func StartResultSpan(result models.AnalysisResult, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	opts = append(opts, trace.WithAttributes(
+		attribute.String("packet.id", result.PacketID),
+		attribute.Int("packet.retry_count", result.RetryCount),
+		attribute.String("analyzer.id", result.AnalyzerID),
+		attribute.Bool("analysis.success", result.Success),
+	))
+	return Tracer().Start(traceParentContext(result.TraceParent), name, opts...)
+}
+
+// traceParentContext returns a context carrying a traceparent; an empty one starts a new trace
+func traceParentContext(traceParent string) context.Context {
+	if traceParent == "" {
+		return context.Background()
+	}
+	return propagator.Extract(context.Background(), propagation.MapCarrier{"traceparent": traceParent})
+}
+
+// EndSpan ends a span, marking it failed when err is not nil
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}